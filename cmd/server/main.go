@@ -38,6 +38,27 @@ func main() {
 		log.Fatal("Failed to initialize auth user:", err)
 	}
 
+	// Start background job workers
+	jobManager := service.NewJobManager(db, cfg)
+	jobManager.Start()
+
+	// Start scheduled action runner
+	scheduler := service.NewScheduler(db, cfg, jobManager)
+	scheduler.Start()
+
+	// Start background ONU telemetry collector
+	collector := service.NewCollector(db, cfg)
+	collector.Start()
+
+	// Start device reachability polling
+	service.NewReachabilityPoller(db, cfg).Start()
+
+	// Start ONU history rollups and retention
+	service.NewHistoryMaintainer(db, cfg).Start()
+
+	// Start alert notification delivery
+	service.NewNotifier(db, cfg).Start()
+
 	// Set Gin mode based on logging level
 	if cfg.Logging.Level != "debug" {
 		gin.SetMode(gin.ReleaseMode)
@@ -67,6 +88,98 @@ func main() {
 		{
 			protected.GET("/audit-logs", handlers.ListAuditLogs(db, cfg))
 
+			// Background jobs
+			jobs := protected.Group("/jobs")
+			{
+				jobs.GET("", handlers.ListJobs(db, cfg))
+				jobs.GET("/:id", handlers.GetJob(db, cfg))
+				jobs.DELETE("/:id", handlers.CancelJob(db, cfg, jobManager))
+			}
+
+			// Scheduled actions
+			schedules := protected.Group("/schedules")
+			{
+				schedules.GET("", handlers.ListSchedules(db, cfg))
+				schedules.POST("", handlers.CreateSchedule(db, cfg))
+				schedules.GET("/:id", handlers.GetSchedule(db, cfg))
+				schedules.DELETE("/:id", handlers.DeleteSchedule(db, cfg))
+				schedules.GET("/:id/runs", handlers.ListScheduleRuns(db, cfg))
+			}
+
+			// Cross-device ONU search
+			protected.GET("/onus/search", handlers.SearchONUs(db, cfg))
+
+			// Fleet-wide ONU health
+			protected.GET("/onus/health", handlers.GetONUHealth(db, cfg, collector))
+
+			// Availability of a tag or subscriber group
+			protected.GET("/availability", handlers.GetAvailabilityReport(db, cfg))
+
+			// Fleet-wide dashboard summary
+			protected.GET("/fleet/summary", handlers.GetFleetSummary(db, cfg))
+
+			// Background telemetry collector
+			protected.GET("/collector/status", handlers.GetCollectorStatus(db, cfg, collector))
+
+			// Alerts raised by the collector and their rules
+			protected.GET("/alerts", handlers.ListAlerts(db, cfg))
+			protected.GET("/alerts/:id", handlers.GetAlert(db, cfg))
+			protected.POST("/alerts/:id/ack", handlers.AckAlert(db, cfg))
+			silences := protected.Group("/alert-silences")
+			{
+				silences.GET("", handlers.ListAlertSilences(db, cfg))
+				silences.POST("", handlers.CreateAlertSilence(db, cfg))
+				silences.DELETE("/:id", handlers.DeleteAlertSilence(db, cfg))
+			}
+			alertRules := protected.Group("/alert-rules")
+			{
+				alertRules.GET("", handlers.ListAlertRules(db, cfg))
+				alertRules.POST("", handlers.CreateAlertRule(db, cfg))
+				alertRules.GET("/:id", handlers.GetAlertRule(db, cfg))
+				alertRules.PUT("/:id", handlers.UpdateAlertRule(db, cfg))
+				alertRules.DELETE("/:id", handlers.DeleteAlertRule(db, cfg))
+			}
+
+			// Rx power degradation trends
+			protected.GET("/optics/trends", handlers.GetOpticalTrends(db, cfg))
+			protected.POST("/optics/trends/analyze", handlers.AnalyzeOpticalTrends(db, cfg))
+
+			// Correlated PON outages
+			protected.GET("/incidents", handlers.ListIncidents(db, cfg))
+			protected.GET("/incidents/:id", handlers.GetIncident(db, cfg))
+			protected.POST("/incidents/:id/resolve", handlers.ResolveIncident(db, cfg))
+
+			// Alert notification channels and delivery log
+			channels := protected.Group("/notification-channels")
+			{
+				channels.GET("", handlers.ListNotificationChannels(db, cfg))
+				channels.POST("", handlers.CreateNotificationChannel(db, cfg))
+				channels.GET("/:id", handlers.GetNotificationChannel(db, cfg))
+				channels.PUT("/:id", handlers.UpdateNotificationChannel(db, cfg))
+				channels.DELETE("/:id", handlers.DeleteNotificationChannel(db, cfg))
+				channels.POST("/:id/test", handlers.TestNotificationChannel(db, cfg))
+			}
+			protected.GET("/notifications", handlers.ListNotifications(db, cfg))
+
+			// Subscriber records linked to ONUs
+			subscribers := protected.Group("/subscribers")
+			{
+				subscribers.GET("", handlers.ListSubscribers(db, cfg))
+				subscribers.POST("", handlers.CreateSubscriber(db, cfg))
+				subscribers.GET("/:id", handlers.GetSubscriber(db, cfg))
+				subscribers.PUT("/:id", handlers.UpdateSubscriber(db, cfg))
+				subscribers.DELETE("/:id", handlers.DeleteSubscriber(db, cfg))
+			}
+
+			// Tags for devices and ONUs
+			tags := protected.Group("/tags")
+			{
+				tags.GET("", handlers.ListTags(db, cfg))
+				tags.POST("", handlers.CreateTag(db, cfg))
+				tags.PUT("/:id", handlers.UpdateTag(db, cfg))
+				tags.DELETE("/:id", handlers.DeleteTag(db, cfg))
+			}
+
 			authProtected := protected.Group("/auth")
 			{
 				authProtected.GET("/me", handlers.Me(db, cfg))
@@ -87,21 +200,44 @@ func main() {
 				devices.DELETE("/:id", handlers.DeleteDevice(db, cfg))
 				devices.DELETE("", handlers.DeleteAllDevices(db, cfg))
 				devices.GET("/:id/status", handlers.CheckDeviceStatus(db, cfg))
+				devices.GET("/:id/status/history", handlers.GetDeviceStatusHistory(db, cfg))
+				devices.POST("/:id/tags", handlers.AddDeviceTags(db, cfg))
+				devices.DELETE("/:id/tags/:tag", handlers.RemoveDeviceTag(db, cfg))
+
+				// Maintenance freezes
+				devices.GET("/:id/maintenance", handlers.ListMaintenanceWindows(db, cfg))
+				devices.POST("/:id/maintenance", handlers.CreateMaintenanceWindow(db, cfg))
+				devices.DELETE("/:id/maintenance/:window_id", handlers.DeleteMaintenanceWindow(db, cfg))
 
 				// PON operations (using :id consistently)
 				devices.GET("/:id/pons", handlers.GetPONs(db, cfg))
+				devices.GET("/:id/pons/capacity", handlers.GetPONCapacity(db, cfg))
+				devices.POST("/:id/pons/:pon_id/action", handlers.PONAction(db, cfg))
 
 				// ONU operations
 				devices.GET("/:id/onus", handlers.GetONUs(db, cfg))
 				devices.GET("/:id/pons/:pon_id/onus", handlers.GetONUs(db, cfg))
+				devices.GET("/:id/onus/all", handlers.GetAllONUs(db, cfg))
+				devices.POST("/:id/onus/bulk-action", handlers.BulkONUAction(db, cfg, jobManager))
+				devices.POST("/:id/onus/scan", handlers.ScanONUs(db, cfg, jobManager))
+				devices.GET("/:id/onus/inventory", handlers.GetONUInventory(db, cfg))
+				devices.GET("/:id/onus/changes", handlers.GetONUChanges(db, cfg))
+				devices.GET("/:id/onus/flapping", handlers.GetONUFlapping(db, cfg))
 				devices.GET("/:id/onus/:onu_id", handlers.GetONUDetail(db, cfg))
 				devices.GET("/:id/onus/:onu_id/traffic", handlers.GetONUTraffic(db, cfg))
+				devices.GET("/:id/onus/:onu_id/traffic/rate", handlers.GetONUTrafficRate(db, cfg))
+				devices.GET("/:id/onus/:onu_id/traffic/history", handlers.GetONUTrafficHistory(db, cfg))
+				devices.GET("/:id/onus/:onu_id/history", handlers.GetONUHistory(db, cfg))
+				devices.GET("/:id/onus/:onu_id/availability", handlers.GetONUAvailability(db, cfg))
 				devices.PUT("/:id/onus/:onu_id", handlers.UpdateONU(db, cfg))
 				devices.POST("/:id/onus/:onu_id/action", handlers.ONUAction(db, cfg))
 				devices.DELETE("/:id/onus/:onu_id", handlers.DeleteONU(db, cfg))
+				devices.POST("/:id/onus/:onu_id/tags", handlers.AddONUTags(db, cfg))
+				devices.DELETE("/:id/onus/:onu_id/tags/:tag", handlers.RemoveONUTag(db, cfg))
 
 				// System operations
 				devices.GET("/:id/system", handlers.GetSystemInfo(db, cfg))
+				devices.GET("/:id/system/history", handlers.GetSystemHistory(db, cfg))
 				devices.POST("/:id/save-config", handlers.SaveConfig(db, cfg))
 				devices.GET("/:id/logs", handlers.GetLogs(db, cfg))
 			}
@@ -12,12 +12,26 @@ import (
 
 // Config holds all configuration for the application
 type Config struct {
-	Server   ServerConfig   `mapstructure:"server"`
-	Database DatabaseConfig `mapstructure:"database"`
-	Cache    CacheConfig    `mapstructure:"cache"`
-	Scraper  ScraperConfig  `mapstructure:"scraper"`
-	Logging  LoggingConfig  `mapstructure:"logging"`
-	Auth     AuthConfig     `mapstructure:"auth"`
+	Server        ServerConfig        `mapstructure:"server"`
+	Database      DatabaseConfig      `mapstructure:"database"`
+	Cache         CacheConfig         `mapstructure:"cache"`
+	Scraper       ScraperConfig       `mapstructure:"scraper"`
+	Logging       LoggingConfig       `mapstructure:"logging"`
+	Auth          AuthConfig          `mapstructure:"auth"`
+	Jobs          JobsConfig          `mapstructure:"jobs"`
+	Scheduler     SchedulerConfig     `mapstructure:"scheduler"`
+	Fleet         FleetConfig         `mapstructure:"fleet"`
+	Optics        OpticsConfig        `mapstructure:"optics"`
+	PON           PONConfig           `mapstructure:"pon"`
+	Collector     CollectorConfig     `mapstructure:"collector"`
+	Retention     RetentionConfig     `mapstructure:"retention"`
+	Traffic       TrafficConfig       `mapstructure:"traffic"`
+	Alerts        AlertsConfig        `mapstructure:"alerts"`
+	Notifications NotificationsConfig `mapstructure:"notifications"`
+	Incidents     IncidentsConfig     `mapstructure:"incidents"`
+	Health        HealthConfig        `mapstructure:"health"`
+	Reachability  ReachabilityConfig  `mapstructure:"reachability"`
+	SystemMetrics SystemMetricsConfig `mapstructure:"system_metrics"`
 }
 
 // ServerConfig holds server-related configuration
@@ -41,9 +55,11 @@ type CacheConfig struct {
 
 // ScraperConfig holds scraper-related configuration
 type ScraperConfig struct {
-	Timeout       time.Duration `mapstructure:"timeout"`
-	MaxWorkers    int           `mapstructure:"max_workers"`
-	RetryAttempts int           `mapstructure:"retry_attempts"`
+	Timeout           time.Duration `mapstructure:"timeout"`
+	MaxWorkers        int           `mapstructure:"max_workers"`
+	RetryAttempts     int           `mapstructure:"retry_attempts"`
+	DeviceConcurrency int           `mapstructure:"device_concurrency"`
+	DeviceMinInterval time.Duration `mapstructure:"device_min_interval"`
 }
 
 // LoggingConfig holds logging-related configuration
@@ -60,6 +76,155 @@ type AuthConfig struct {
 	InitialPassword string        `mapstructure:"initial_password"`
 }
 
+// JobsConfig holds background job runner configuration
+type JobsConfig struct {
+	Workers      int           `mapstructure:"workers"`
+	PollInterval time.Duration `mapstructure:"poll_interval"`
+}
+
+// SchedulerConfig holds scheduled action configuration
+type SchedulerConfig struct {
+	Enabled bool          `mapstructure:"enabled"`
+	Tick    time.Duration `mapstructure:"tick"`
+}
+
+// FleetConfig holds fleet summary caching configuration. A summary younger
+// than FreshTTL is served as is; up to StaleTTL it is served while a refresh
+// runs in the background.
+type FleetConfig struct {
+	FreshTTL time.Duration `mapstructure:"fresh_ttl"`
+	StaleTTL time.Duration `mapstructure:"stale_ttl"`
+}
+
+// OpticsConfig holds optical power thresholds and Rx trend analysis settings
+type OpticsConfig struct {
+	LowRxThreshold   float64       `mapstructure:"low_rx_threshold"`  // dBm
+	SensitivityLimit float64       `mapstructure:"sensitivity_limit"` // dBm, ONU receiver sensitivity
+	TrendWindow      time.Duration `mapstructure:"trend_window"`      // Rx history fitted per ONU
+	TrendInterval    time.Duration `mapstructure:"trend_interval"`    // how often trends are recomputed
+	DegradationRate  float64       `mapstructure:"degradation_rate"`  // dB per day flagged as degrading
+	ProjectionDays   int           `mapstructure:"projection_days"`   // flag ONUs projected to cross the limit within this many days
+}
+
+// PONConfig holds PON port capacity and admin settings
+type PONConfig struct {
+	MaxONUs           int            `mapstructure:"max_onus"`           // ONU slots per PON (64 or 128)
+	CapacityThreshold float64        `mapstructure:"capacity_threshold"` // utilization percentage flagged as near full
+	AdminForm         PONAdminConfig `mapstructure:"admin_form"`
+}
+
+// PONAdminConfig describes the OLT web form that enables and disables a PON
+// port. PON actions are refused while Path is empty, since the form differs
+// between firmware versions.
+type PONAdminConfig struct {
+	Path         string `mapstructure:"path"`         // e.g. /goform/...
+	PONField     string `mapstructure:"pon_field"`    // form field carrying the PON ID
+	StatusField  string `mapstructure:"status_field"` // form field carrying the admin status
+	EnableValue  string `mapstructure:"enable_value"`
+	DisableValue string `mapstructure:"disable_value"`
+}
+
+// CollectorConfig holds background ONU telemetry collection settings
+type CollectorConfig struct {
+	Enabled   bool          `mapstructure:"enabled"`
+	Interval  time.Duration `mapstructure:"interval"`
+	Stagger   time.Duration `mapstructure:"stagger"`    // device start times are spread over this window
+	BatchSize int           `mapstructure:"batch_size"` // ONU log rows per insert
+}
+
+// RetentionConfig holds ONU history rollup and retention settings
+type RetentionConfig struct {
+	Interval time.Duration `mapstructure:"interval"` // how often rollups and cleanup run
+	Raw      time.Duration `mapstructure:"raw"`
+	Hourly   time.Duration `mapstructure:"hourly"`
+	Daily    time.Duration `mapstructure:"daily"`
+}
+
+// TrafficConfig holds ONU traffic rate sampling settings
+type TrafficConfig struct {
+	Collect     bool          `mapstructure:"collect"`      // sample online ONUs on every collector cycle
+	MinInterval time.Duration `mapstructure:"min_interval"` // newer samples are reused instead of polling again
+	ProbeDelay  time.Duration `mapstructure:"probe_delay"`  // wait between the two reads of an on-demand rate
+	MaxBps      float64       `mapstructure:"max_bps"`      // faster deltas are treated as a counter reset
+	Retention   time.Duration `mapstructure:"retention"`
+}
+
+// AlertsConfig holds alert evaluation settings
+type AlertsConfig struct {
+	Enabled bool `mapstructure:"enabled"` // evaluate rules after each collector cycle
+}
+
+// NotificationsConfig holds alert notification delivery settings
+type NotificationsConfig struct {
+	Enabled      bool          `mapstructure:"enabled"`
+	PollInterval time.Duration `mapstructure:"poll_interval"` // how often due deliveries are sent
+	Timeout      time.Duration `mapstructure:"timeout"`       // per delivery attempt
+	MaxAttempts  int           `mapstructure:"max_attempts"`
+	Backoff      time.Duration `mapstructure:"backoff"`     // delay after the first failure, doubled per attempt
+	MaxBackoff   time.Duration `mapstructure:"max_backoff"` // cap on the retry delay
+}
+
+// IncidentsConfig holds outage correlation settings. ONUs of one PON that
+// drop within Window of each other form an incident once MinONUs are down.
+type IncidentsConfig struct {
+	Enabled bool          `mapstructure:"enabled"` // correlate during alert evaluation
+	Window  time.Duration `mapstructure:"window"`
+	MinONUs int           `mapstructure:"min_onus"`
+}
+
+// HealthConfig holds ONU health scoring thresholds. Profiles override the
+// default thresholds for ONUs of a firmware version or model; the first
+// matching profile wins.
+type HealthConfig struct {
+	Thresholds HealthThresholds `mapstructure:"thresholds"`
+	Profiles   []HealthProfile  `mapstructure:"profiles"`
+}
+
+// HealthProfile matches ONUs by firmware version and/or model (chip ID)
+// prefix. Bounds it leaves unset fall back to the default thresholds.
+type HealthProfile struct {
+	Name       string           `mapstructure:"name"`
+	FwVersion  string           `mapstructure:"fw_version"`
+	Model      string           `mapstructure:"model"`
+	Thresholds HealthThresholds `mapstructure:"thresholds"`
+}
+
+// HealthThresholds holds the bounds of every scored metric
+type HealthThresholds struct {
+	RxPower     HealthRange `mapstructure:"rx_power"`     // dBm
+	TxPower     HealthRange `mapstructure:"tx_power"`     // dBm
+	Temperature HealthRange `mapstructure:"temperature"`  // °C
+	Voltage     HealthRange `mapstructure:"voltage"`      // V
+	BiasCurrent HealthRange `mapstructure:"bias_current"` // mA
+	Distance    HealthRange `mapstructure:"distance"`     // meters
+}
+
+// HealthRange holds the warning and critical bounds of one metric. Unset
+// bounds are not checked.
+type HealthRange struct {
+	WarnBelow *float64 `mapstructure:"warn_below"`
+	CritBelow *float64 `mapstructure:"crit_below"`
+	WarnAbove *float64 `mapstructure:"warn_above"`
+	CritAbove *float64 `mapstructure:"crit_above"`
+}
+
+// ReachabilityConfig holds device reachability polling settings
+type ReachabilityConfig struct {
+	Enabled       bool          `mapstructure:"enabled"`
+	Interval      time.Duration `mapstructure:"interval"`
+	Timeout       time.Duration `mapstructure:"timeout"`        // per check stage
+	ConfirmChecks int           `mapstructure:"confirm_checks"` // consecutive checks before a status change is recorded
+	Retention     time.Duration `mapstructure:"retention"`      // status history kept
+}
+
+// SystemMetricsConfig holds OLT CPU, memory and run time sampling settings.
+// Rollups follow the retention tiers.
+type SystemMetricsConfig struct {
+	Collect         bool          `mapstructure:"collect"`          // store the system info read by each reachability check
+	Retention       time.Duration `mapstructure:"retention"`        // raw samples kept
+	RebootTolerance time.Duration `mapstructure:"reboot_tolerance"` // boot time drift ignored when detecting reboots
+}
+
 // Load reads configuration from file and environment variables
 func Load() (*Config, error) {
 	viper.SetConfigName("config")
@@ -78,12 +243,82 @@ func Load() (*Config, error) {
 	viper.SetDefault("scraper.timeout", "60s")
 	viper.SetDefault("scraper.max_workers", 200)
 	viper.SetDefault("scraper.retry_attempts", 3)
+	viper.SetDefault("scraper.device_concurrency", 4)
+	viper.SetDefault("scraper.device_min_interval", "50ms")
 	viper.SetDefault("logging.level", "info")
 	viper.SetDefault("logging.file", "./logs/app.log")
 	viper.SetDefault("auth.jwt_secret", "")
 	viper.SetDefault("auth.access_token_ttl", "12h")
 	viper.SetDefault("auth.initial_username", "admin")
 	viper.SetDefault("auth.initial_password", "")
+	viper.SetDefault("jobs.workers", 2)
+	viper.SetDefault("jobs.poll_interval", "5s")
+	viper.SetDefault("scheduler.enabled", true)
+	viper.SetDefault("scheduler.tick", "30s")
+	viper.SetDefault("fleet.fresh_ttl", "30s")
+	viper.SetDefault("fleet.stale_ttl", "10m")
+	viper.SetDefault("optics.low_rx_threshold", -27.0)
+	viper.SetDefault("optics.sensitivity_limit", -28.0)
+	viper.SetDefault("optics.trend_window", "336h")
+	viper.SetDefault("optics.trend_interval", "6h")
+	viper.SetDefault("optics.degradation_rate", 0.1)
+	viper.SetDefault("optics.projection_days", 30)
+	viper.SetDefault("pon.max_onus", 64)
+	viper.SetDefault("pon.capacity_threshold", 80.0)
+	viper.SetDefault("pon.admin_form.path", "")
+	viper.SetDefault("pon.admin_form.pon_field", "oltponno")
+	viper.SetDefault("pon.admin_form.status_field", "")
+	viper.SetDefault("pon.admin_form.enable_value", "1")
+	viper.SetDefault("pon.admin_form.disable_value", "0")
+	viper.SetDefault("collector.enabled", true)
+	viper.SetDefault("collector.interval", "5m")
+	viper.SetDefault("collector.stagger", "30s")
+	viper.SetDefault("collector.batch_size", 500)
+	viper.SetDefault("retention.interval", "15m")
+	viper.SetDefault("retention.raw", "168h")
+	viper.SetDefault("retention.hourly", "2160h")
+	viper.SetDefault("retention.daily", "17520h")
+	viper.SetDefault("traffic.collect", false)
+	viper.SetDefault("traffic.min_interval", "5s")
+	viper.SetDefault("traffic.probe_delay", "3s")
+	viper.SetDefault("traffic.max_bps", 10e9)
+	viper.SetDefault("traffic.retention", "168h")
+	viper.SetDefault("alerts.enabled", true)
+	viper.SetDefault("notifications.enabled", true)
+	viper.SetDefault("notifications.poll_interval", "5s")
+	viper.SetDefault("notifications.timeout", "10s")
+	viper.SetDefault("notifications.max_attempts", 5)
+	viper.SetDefault("notifications.backoff", "30s")
+	viper.SetDefault("notifications.max_backoff", "30m")
+	viper.SetDefault("incidents.enabled", true)
+	viper.SetDefault("incidents.window", "10m")
+	viper.SetDefault("incidents.min_onus", 5)
+	viper.SetDefault("health.thresholds.rx_power.warn_below", -25.0)
+	viper.SetDefault("health.thresholds.rx_power.crit_below", -27.0)
+	viper.SetDefault("health.thresholds.rx_power.warn_above", -10.0)
+	viper.SetDefault("health.thresholds.rx_power.crit_above", -8.0)
+	viper.SetDefault("health.thresholds.tx_power.warn_below", 0.5)
+	viper.SetDefault("health.thresholds.tx_power.crit_below", -1.0)
+	viper.SetDefault("health.thresholds.tx_power.warn_above", 5.0)
+	viper.SetDefault("health.thresholds.tx_power.crit_above", 7.0)
+	viper.SetDefault("health.thresholds.temperature.warn_above", 70.0)
+	viper.SetDefault("health.thresholds.temperature.crit_above", 85.0)
+	viper.SetDefault("health.thresholds.voltage.warn_below", 3.135)
+	viper.SetDefault("health.thresholds.voltage.crit_below", 3.0)
+	viper.SetDefault("health.thresholds.voltage.warn_above", 3.465)
+	viper.SetDefault("health.thresholds.voltage.crit_above", 3.6)
+	viper.SetDefault("health.thresholds.bias_current.warn_above", 40.0)
+	viper.SetDefault("health.thresholds.bias_current.crit_above", 60.0)
+	viper.SetDefault("health.thresholds.distance.warn_above", 18000)
+	viper.SetDefault("health.thresholds.distance.crit_above", 20000)
+	viper.SetDefault("reachability.enabled", true)
+	viper.SetDefault("reachability.interval", "1m")
+	viper.SetDefault("reachability.timeout", "10s")
+	viper.SetDefault("reachability.confirm_checks", 2)
+	viper.SetDefault("reachability.retention", "2160h")
+	viper.SetDefault("system_metrics.collect", true)
+	viper.SetDefault("system_metrics.retention", "720h")
+	viper.SetDefault("system_metrics.reboot_tolerance", "2m")
 
 	// Read config file
 	if err := viper.ReadInConfig(); err != nil {
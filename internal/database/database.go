@@ -42,7 +42,13 @@ func Init(dbPath string) (*gorm.DB, error) {
 	}
 
 	// Run migrations
-	if err := db.AutoMigrate(&Device{}, &ONULog{}, &CacheEntry{}, &User{}, &AuditLog{}); err != nil {
+	if err := db.AutoMigrate(
+		&Device{}, &ONULog{}, &CacheEntry{}, &User{}, &AuditLog{}, &Job{}, &JobItem{},
+		&Schedule{}, &ScheduleRun{}, &MaintenanceWindow{}, &ONUInventory{}, &ONUInventoryEvent{},
+		&Subscriber{}, &Tag{}, &ONUTag{}, &ONUMetricRollup{}, &ONUTrafficSample{}, &ONUOpticalTrend{},
+		&AlertRule{}, &Alert{}, &AlertSilence{}, &NotificationChannel{}, &Notification{}, &Incident{}, &DeviceStatusEvent{},
+		&DeviceSystemSample{}, &DeviceSystemRollup{}, &DeviceReboot{},
+	); err != nil {
 		return nil, err
 	}
 
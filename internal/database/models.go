@@ -1,6 +1,9 @@
 package database
 
-import "time"
+import (
+	"encoding/json"
+	"time"
+)
 
 // Device represents an OLT device
 type Device struct {
@@ -11,8 +14,34 @@ type Device struct {
 	Username  string    `gorm:"not null" json:"username"`
 	Password  string    `gorm:"not null" json:"-"` // encrypted, never expose in JSON
 	Status    string    `gorm:"default:active" json:"status"`
+	Tags      []Tag     `gorm:"many2many:device_tags" json:"tags,omitempty"`
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
+
+	DeviceReachability `gorm:"embedded"`
+	Uptime             map[string]*float64 `gorm:"-" json:"uptime,omitempty"` // percentage per window, filled in by handlers
+}
+
+// DeviceReachability is the polled reachability of a device, maintained by
+// the reachability poller
+type DeviceReachability struct {
+	LiveStatus      string     `json:"live_status,omitempty"` // up, degraded, down
+	LiveStatusSince *time.Time `json:"live_status_since,omitempty"`
+	LastCheckedAt   *time.Time `json:"last_checked_at,omitempty"`
+	LatencyMs       int64      `json:"latency_ms,omitempty"`
+	LastError       string     `gorm:"type:text" json:"last_error,omitempty"`
+	LastErrorAt     *time.Time `json:"last_error_at,omitempty"`
+}
+
+// DeviceStatusEvent records a change of the polled reachability of a device
+type DeviceStatusEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	DeviceID  string    `gorm:"index;not null" json:"device_id"`
+	Status    string    `json:"status"`
+	Previous  string    `json:"previous,omitempty"`
+	Stage     string    `json:"stage,omitempty"` // failed check: tcp, auth or system
+	Error     string    `gorm:"type:text" json:"error,omitempty"`
+	ChangedAt time.Time `gorm:"index" json:"changed_at"`
 }
 
 // User represents dashboard user account
@@ -92,7 +121,10 @@ type ResetUserPasswordRequest struct {
 	NewPassword string `json:"new_password" binding:"required,min=8"`
 }
 
-// ONULog for historical tracking of ONU metrics
+// ONULog for historical tracking of ONU metrics. Readings the OLT did not
+// report are stored as 0 and the row keeps no other trace of them, so
+// everything that reads stored rows treats an exact 0 Rx power, Tx power or
+// temperature as unreported. Live ONU lists use Metrics.Unreported instead.
 type ONULog struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
 	DeviceID    string    `gorm:"index" json:"device_id"`
@@ -105,6 +137,541 @@ type ONULog struct {
 	RecordedAt  time.Time `gorm:"index" json:"recorded_at"`
 }
 
+// ONUMetricRollup is an hourly or daily aggregate of ONULog rows for one ONU.
+// Optical figures only cover online samples with a reading.
+type ONUMetricRollup struct {
+	ID            uint      `gorm:"primaryKey" json:"-"`
+	DeviceID      string    `gorm:"uniqueIndex:idx_onu_rollup;not null" json:"-"`
+	ONUID         string    `gorm:"column:onu_id;uniqueIndex:idx_onu_rollup;not null" json:"-"`
+	Tier          string    `gorm:"uniqueIndex:idx_onu_rollup;not null" json:"-"` // hour, day
+	BucketStart   time.Time `gorm:"uniqueIndex:idx_onu_rollup;index" json:"time"`
+	Samples       int       `json:"samples"`
+	OnlineSamples int       `json:"online_samples"`
+	RxSamples     int       `json:"-"`
+	RxMin         *float64  `json:"rx_min"`
+	RxAvg         *float64  `json:"rx_avg"`
+	RxMax         *float64  `json:"rx_max"`
+	TxMin         *float64  `json:"tx_min"`
+	TxAvg         *float64  `json:"tx_avg"`
+	TxMax         *float64  `json:"tx_max"`
+	TempMin       *float64  `json:"temp_min"`
+	TempAvg       *float64  `json:"temp_avg"`
+	TempMax       *float64  `json:"temp_max"`
+}
+
+// ONUTrafficSample is one reading of an ONU's traffic counters together with
+// the rates computed against the previous reading. Rates are nil for the first
+// sample of an ONU and after a counter reset.
+type ONUTrafficSample struct {
+	ID              uint      `gorm:"primaryKey" json:"-"`
+	DeviceID        string    `gorm:"index:idx_traffic_onu;not null" json:"-"`
+	ONUID           string    `gorm:"column:onu_id;index:idx_traffic_onu;not null" json:"-"`
+	RecordedAt      time.Time `gorm:"index:idx_traffic_onu;index" json:"time"`
+	RxBytes         uint64    `json:"rx_bytes"`
+	TxBytes         uint64    `json:"tx_bytes"`
+	RxPackets       uint64    `json:"rx_packets"`
+	TxPackets       uint64    `json:"tx_packets"`
+	RxErrors        uint64    `json:"rx_errors"`
+	TxErrors        uint64    `json:"tx_errors"`
+	RxUnicast       uint64    `json:"-"` // packet counters summed in RxPackets and TxPackets; each one wraps on its own
+	RxBroadcast     uint64    `json:"-"`
+	RxMulticast     uint64    `json:"-"`
+	TxUnicast       uint64    `json:"-"`
+	TxBroadcast     uint64    `json:"-"`
+	TxMulticast     uint64    `json:"-"`
+	IntervalSeconds float64   `json:"interval_seconds"`
+	Reset           bool      `json:"reset,omitempty"`
+	RxBps           *float64  `json:"rx_bps"`
+	TxBps           *float64  `json:"tx_bps"`
+	RxPps           *float64  `json:"rx_pps"`
+	TxPps           *float64  `json:"tx_pps"`
+	RxErrorRate     *float64  `json:"rx_errors_per_sec"`
+	TxErrorRate     *float64  `json:"tx_errors_per_sec"`
+}
+
+// DeviceSystemSample is one reading of an OLT's CPU, memory and run time
+type DeviceSystemSample struct {
+	ID            uint      `gorm:"primaryKey" json:"-"`
+	DeviceID      string    `gorm:"index:idx_system_device;not null" json:"-"`
+	RecordedAt    time.Time `gorm:"index:idx_system_device;index" json:"time"`
+	CPUUsage      float64   `json:"cpu_usage"`
+	MemoryUsage   float64   `json:"memory_usage"`
+	RunTime       string    `json:"run_time"`
+	UptimeSeconds *int64    `json:"uptime_seconds"` // nil when the run time could not be parsed
+	Rebooted      bool      `json:"rebooted,omitempty"`
+}
+
+// DeviceSystemRollup is an hourly or daily aggregate of DeviceSystemSample
+// rows for one device
+type DeviceSystemRollup struct {
+	ID          uint      `gorm:"primaryKey" json:"-"`
+	DeviceID    string    `gorm:"uniqueIndex:idx_system_rollup;not null" json:"-"`
+	Tier        string    `gorm:"uniqueIndex:idx_system_rollup;not null" json:"-"` // hour, day
+	BucketStart time.Time `gorm:"uniqueIndex:idx_system_rollup;index" json:"time"`
+	Samples     int       `json:"samples"`
+	CPUMin      *float64  `json:"cpu_min"`
+	CPUAvg      *float64  `json:"cpu_avg"`
+	CPUMax      *float64  `json:"cpu_max"`
+	MemoryMin   *float64  `json:"memory_min"`
+	MemoryAvg   *float64  `json:"memory_avg"`
+	MemoryMax   *float64  `json:"memory_max"`
+	Reboots     int       `json:"reboots"`
+}
+
+// DeviceReboot records an OLT reboot detected from its run time
+type DeviceReboot struct {
+	ID                    uint      `gorm:"primaryKey" json:"id"`
+	DeviceID              string    `gorm:"index;not null" json:"device_id"`
+	DetectedAt            time.Time `gorm:"index" json:"detected_at"`
+	BootedAt              time.Time `json:"booted_at"` // detection time minus the reported run time
+	PreviousUptimeSeconds int64     `json:"previous_uptime_seconds"`
+	UptimeSeconds         int64     `json:"uptime_seconds"`
+}
+
+// ONUOpticalTrend is the linear fit of one ONU's hourly Rx power averages
+// over the trend window, replaced on every analysis run. Slope is in dB per
+// day; DaysToLimit is set when the fit is falling.
+type ONUOpticalTrend struct {
+	ID          uint      `gorm:"primaryKey" json:"-"`
+	DeviceID    string    `gorm:"uniqueIndex:idx_optical_trend;not null" json:"device_id"`
+	ONUID       string    `gorm:"column:onu_id;uniqueIndex:idx_optical_trend;not null" json:"onu_id"`
+	PONID       string    `gorm:"column:pon_id;index" json:"pon_id"`
+	Name        string    `json:"name,omitempty"`
+	MAC         string    `json:"mac,omitempty"`
+	Points      int       `json:"points"`
+	From        time.Time `json:"from"`
+	To          time.Time `json:"to"`
+	Slope       float64   `gorm:"index" json:"slope_db_per_day"`
+	R2          float64   `gorm:"column:r2" json:"r2"`
+	FirstRx     float64   `json:"first_rx"`
+	LastRx      float64   `json:"last_rx"`
+	FittedRx    float64   `json:"fitted_rx"` // fit value at To
+	DaysToLimit *float64  `json:"days_to_limit,omitempty"`
+	AnalyzedAt  time.Time `json:"analyzed_at"`
+}
+
+// AlertRule is a condition evaluated against collected data. ONU and PON
+// rules can be scoped to a device and PON; an empty DeviceID matches all
+// devices. Escalations go to EscalateChannelID regardless of channel routes.
+type AlertRule struct {
+	ID                uint      `gorm:"primaryKey" json:"id"`
+	Name              string    `gorm:"not null" json:"name"`
+	Type              string    `gorm:"index;not null" json:"type"` // onu_offline, onu_rx_low, onu_temp_high, onu_flapping, pon_onus_down, device_unreachable, device_cpu_high, device_memory_high
+	DeviceID          string    `gorm:"index" json:"device_id,omitempty"`
+	PONID             string    `gorm:"column:pon_id" json:"pon_id,omitempty"`
+	Threshold         float64   `json:"threshold"`
+	For               string    `json:"for,omitempty"`    // condition must hold this long before firing, e.g. 10m
+	Window            string    `json:"window,omitempty"` // look-back of onu_flapping rules, e.g. 6h
+	Severity          string    `json:"severity"`
+	Enabled           bool      `json:"enabled"`
+	Description       string    `gorm:"type:text" json:"description,omitempty"`
+	EscalateAfter     string    `json:"escalate_after,omitempty"` // unacknowledged firing alerts are escalated after this long
+	EscalateChannelID uint      `json:"escalate_channel_id,omitempty"`
+	CreatedBy         string    `json:"created_by"`
+	CreatedAt         time.Time `json:"created_at"`
+	UpdatedAt         time.Time `json:"updated_at"`
+}
+
+// AlertRuleRequest is used for creating and updating alert rules.
+// Threshold and Window default per type when omitted.
+type AlertRuleRequest struct {
+	Name        string   `json:"name" binding:"required"`
+	Type        string   `json:"type" binding:"required"`
+	DeviceID    string   `json:"device_id"`
+	PONID       string   `json:"pon_id"`
+	Threshold   *float64 `json:"threshold"`
+	For         string   `json:"for"`
+	Window      string   `json:"window"`
+	Severity    string   `json:"severity"`
+	Enabled     *bool    `json:"enabled"`
+	Description string   `json:"description"`
+
+	EscalateAfter     string `json:"escalate_after"`
+	EscalateChannelID uint   `json:"escalate_channel_id"`
+}
+
+// Alert is one occurrence of a rule condition on a device, PON or ONU.
+// Fingerprint identifies the subject, so a condition that persists updates
+// its open alert instead of creating new ones.
+type Alert struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	RuleID          uint       `gorm:"index;not null" json:"rule_id"`
+	RuleName        string     `json:"rule_name"`
+	Type            string     `gorm:"index" json:"type"`
+	Severity        string     `gorm:"index" json:"severity"`
+	Fingerprint     string     `gorm:"index;not null" json:"fingerprint"`
+	Status          string     `gorm:"index;not null" json:"status"` // pending, firing, resolved
+	DeviceID        string     `gorm:"index" json:"device_id"`
+	PONID           string     `gorm:"column:pon_id" json:"pon_id,omitempty"`
+	ONUID           string     `gorm:"column:onu_id" json:"onu_id,omitempty"`
+	Value           float64    `json:"value"`
+	Message         string     `gorm:"type:text" json:"message"`
+	StartedAt       time.Time  `json:"started_at"` // condition first seen
+	FiredAt         *time.Time `json:"fired_at,omitempty"`
+	ResolvedAt      *time.Time `gorm:"index" json:"resolved_at,omitempty"`
+	LastEvaluatedAt time.Time  `json:"last_evaluated_at"`
+	AckedAt         *time.Time `json:"acked_at,omitempty"`
+	AckedBy         string     `json:"acked_by,omitempty"`
+	AckComment      string     `gorm:"type:text" json:"ack_comment,omitempty"`
+	EscalatedAt     *time.Time `json:"escalated_at,omitempty"`
+	Silenced        bool       `gorm:"-" json:"silenced,omitempty"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// AlertAckRequest is used for acknowledging an alert
+type AlertAckRequest struct {
+	Comment string `json:"comment"`
+}
+
+// AlertSilence suppresses notifications for matching alerts during a time
+// range. Empty matchers match everything; at least one must be set.
+type AlertSilence struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	DeviceID  string    `gorm:"index" json:"device_id,omitempty"`
+	PONID     string    `gorm:"column:pon_id" json:"pon_id,omitempty"`
+	ONUID     string    `gorm:"column:onu_id" json:"onu_id,omitempty"`
+	RuleID    uint      `json:"rule_id,omitempty"`
+	StartsAt  time.Time `gorm:"index" json:"starts_at"`
+	EndsAt    time.Time `gorm:"index" json:"ends_at"`
+	Comment   string    `gorm:"type:text" json:"comment"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// AlertSilenceRequest is used for creating silences. The end is ends_at or
+// starts_at plus duration; starts_at defaults to now.
+type AlertSilenceRequest struct {
+	DeviceID string     `json:"device_id"`
+	PONID    string     `json:"pon_id"`
+	ONUID    string     `json:"onu_id"`
+	RuleID   uint       `json:"rule_id"`
+	StartsAt *time.Time `json:"starts_at"`
+	EndsAt   *time.Time `json:"ends_at"`
+	Duration string     `json:"duration"`
+	Comment  string     `json:"comment" binding:"required"`
+}
+
+// Incident groups ONUs of one PON that went down together into a single
+// outage. Type is the suspected cause, from the down statuses the ONUs
+// reported. StartedAt is the last time all affected ONUs were seen online.
+type Incident struct {
+	ID         uint          `gorm:"primaryKey" json:"id"`
+	DeviceID   string        `gorm:"index;not null" json:"device_id"`
+	PONID      string        `gorm:"column:pon_id;index" json:"pon_id"`
+	Type       string        `gorm:"index" json:"type"`            // fiber_cut, power_outage, olt_issue
+	Status     string        `gorm:"index;not null" json:"status"` // open, resolved
+	AlertID    uint          `gorm:"index" json:"alert_id,omitempty"`
+	Affected   int           `json:"affected"`
+	Down       int           `json:"down"`
+	PONTotal   int           `json:"pon_total"`
+	LOS        int           `gorm:"column:los" json:"los"`
+	PowerOff   int           `json:"poweroff"`
+	Offline    int           `json:"offline"`
+	Message    string        `gorm:"type:text" json:"message"`
+	ONUsJSON   string        `gorm:"column:onus;type:text" json:"-"`
+	ONUs       []IncidentONU `gorm:"-" json:"onus"`
+	StartedAt  time.Time     `json:"started_at"`
+	DetectedAt time.Time     `gorm:"index" json:"detected_at"`
+	ResolvedAt *time.Time    `gorm:"index" json:"resolved_at,omitempty"`
+	ResolvedBy string        `json:"resolved_by,omitempty"` // empty when the ONUs came back
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
+
+// IncidentONU is one ONU affected by an incident. DownStatus is the status it
+// went down with; Status is the latest one seen.
+type IncidentONU struct {
+	ONUID        string              `json:"onu_id"`
+	Name         string              `json:"name,omitempty"`
+	MacAddress   string              `json:"mac_address,omitempty"`
+	DownStatus   string              `json:"down_status"`
+	Status       string              `json:"status"`
+	LastOnlineAt *time.Time          `json:"last_online_at,omitempty"`
+	RestoredAt   *time.Time          `json:"restored_at,omitempty"`
+	Subscriber   *IncidentSubscriber `json:"subscriber,omitempty"`
+}
+
+// IncidentSubscriber is the subscriber linked to an affected ONU
+type IncidentSubscriber struct {
+	ID            uint   `json:"id"`
+	AccountNumber string `json:"account_number"`
+	Name          string `json:"name"`
+	Phone         string `json:"phone,omitempty"`
+}
+
+// NotificationChannel delivers alert notifications to one destination
+type NotificationChannel struct {
+	ID              uint                 `gorm:"primaryKey" json:"id"`
+	Name            string               `gorm:"not null" json:"name"`
+	Type            string               `gorm:"index;not null" json:"type"` // webhook, slack, telegram, email
+	SettingsJSON    string               `gorm:"column:settings;type:text" json:"-"`
+	Settings        NotificationSettings `gorm:"-" json:"settings"` // secrets are masked in responses
+	RouteJSON       string               `gorm:"column:route;type:text" json:"-"`
+	Route           NotificationRoute    `gorm:"-" json:"route"`
+	Template        string               `gorm:"type:text" json:"template,omitempty"`
+	SubjectTemplate string               `gorm:"type:text" json:"subject_template,omitempty"`
+	Enabled         bool                 `json:"enabled"`
+	CreatedBy       string               `json:"created_by"`
+	CreatedAt       time.Time            `json:"created_at"`
+	UpdatedAt       time.Time            `json:"updated_at"`
+}
+
+// NotificationSettings holds the destination of a channel. Which fields
+// apply depends on the channel type.
+type NotificationSettings struct {
+	URL      string            `json:"url,omitempty"`       // webhook, slack
+	Secret   string            `json:"secret,omitempty"`    // webhook HMAC key
+	Headers  map[string]string `json:"headers,omitempty"`   // webhook, slack
+	BotToken string            `json:"bot_token,omitempty"` // telegram
+	ChatID   string            `json:"chat_id,omitempty"`   // telegram
+	APIURL   string            `json:"api_url,omitempty"`   // telegram, default https://api.telegram.org
+	SMTPHost string            `json:"smtp_host,omitempty"`
+	SMTPPort int               `json:"smtp_port,omitempty"`
+	TLS      string            `json:"tls,omitempty"` // starttls (default), tls, none
+	Username string            `json:"username,omitempty"`
+	Password string            `json:"password,omitempty"`
+	From     string            `json:"from,omitempty"`
+	To       []string          `json:"to,omitempty"`
+}
+
+// NotificationRoute selects the alerts a channel receives. Empty lists match
+// everything; Tags match device tags.
+type NotificationRoute struct {
+	Events     []string `json:"events,omitempty"` // firing, resolved
+	Severities []string `json:"severities,omitempty"`
+	DeviceIDs  []string `json:"device_ids,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// NotificationChannelRequest is used for creating and updating channels.
+// On update, empty secret, password and bot_token keep the stored value.
+type NotificationChannelRequest struct {
+	Name            string               `json:"name" binding:"required"`
+	Type            string               `json:"type" binding:"required"`
+	Settings        NotificationSettings `json:"settings"`
+	Route           NotificationRoute    `json:"route"`
+	Template        string               `json:"template"`
+	SubjectTemplate string               `json:"subject_template"`
+	Enabled         *bool                `json:"enabled"`
+}
+
+// Notification is one delivery of an alert event to a channel, retried with
+// backoff until it is sent or runs out of attempts
+type Notification struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	ChannelID     uint       `gorm:"index;not null" json:"channel_id"`
+	AlertID       uint       `gorm:"index" json:"alert_id,omitempty"`
+	Event         string     `json:"event"`                        // firing, resolved, test
+	Status        string     `gorm:"index;not null" json:"status"` // pending, sent, failed
+	Attempts      int        `json:"attempts"`
+	NextAttemptAt time.Time  `gorm:"index" json:"next_attempt_at"`
+	LastError     string     `gorm:"type:text" json:"last_error,omitempty"`
+	Subject       string     `json:"subject"`
+	Body          string     `gorm:"type:text" json:"body"`
+	PayloadJSON   string     `gorm:"column:payload;type:text" json:"-"`
+	CreatedAt     time.Time  `gorm:"index" json:"created_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
+
+// Job tracks a long-running background operation such as a bulk ONU action
+type Job struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	Type       string          `gorm:"index;not null" json:"type"`
+	DeviceID   string          `gorm:"index" json:"device_id,omitempty"`
+	Status     string          `gorm:"index;default:pending" json:"status"` // pending, running, completed, failed, cancelled
+	Total      int             `json:"total"`
+	Succeeded  int             `json:"succeeded"`
+	Failed     int             `json:"failed"`
+	Progress   float64         `gorm:"-" json:"progress"`
+	Payload    string          `gorm:"type:text" json:"-"`
+	ResultJSON string          `gorm:"column:result;type:text" json:"-"`
+	Result     json.RawMessage `gorm:"-" json:"result,omitempty"`
+	Error      string          `gorm:"type:text" json:"error,omitempty"`
+	UserID     uint            `gorm:"index" json:"user_id"`
+	Username   string          `json:"username"`
+	Role       string          `json:"role"`
+	IPAddress  string          `json:"-"`
+	UserAgent  string          `gorm:"type:text" json:"-"`
+	CreatedAt  time.Time       `gorm:"index" json:"created_at"`
+	StartedAt  *time.Time      `json:"started_at,omitempty"`
+	FinishedAt *time.Time      `json:"finished_at,omitempty"`
+	Items      []JobItem       `gorm:"foreignKey:JobID" json:"items,omitempty"`
+}
+
+// JobItem is the per-target outcome of a job (e.g. one ONU in a bulk action)
+type JobItem struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	JobID      uint       `gorm:"index;not null" json:"job_id"`
+	Target     string     `gorm:"not null" json:"target"`
+	Status     string     `gorm:"default:pending" json:"status"` // pending, running, succeeded, failed, cancelled
+	Error      string     `gorm:"type:text" json:"error,omitempty"`
+	FinishedAt *time.Time `json:"finished_at,omitempty"`
+}
+
+// Schedule is a one-shot or cron-style recurring operation
+type Schedule struct {
+	ID            uint            `gorm:"primaryKey" json:"id"`
+	Name          string          `gorm:"not null" json:"name"`
+	Kind          string          `gorm:"index;not null" json:"kind"` // onu.action, onu.bulk_action, device.save_config
+	DeviceID      string          `gorm:"index" json:"device_id"`     // empty = all devices (device.save_config only)
+	PayloadJSON   string          `gorm:"column:payload;type:text" json:"-"`
+	Payload       json.RawMessage `gorm:"-" json:"payload,omitempty"`
+	Cron          string          `json:"cron,omitempty"`
+	RunAt         *time.Time      `json:"run_at,omitempty"`
+	Enabled       bool            `gorm:"default:true" json:"enabled"`
+	NextRunAt     *time.Time      `gorm:"index" json:"next_run_at,omitempty"`
+	LastRunAt     *time.Time      `json:"last_run_at,omitempty"`
+	LastStatus    string          `json:"last_status,omitempty"`
+	CreatedByID   uint            `gorm:"index" json:"created_by_id"`
+	CreatedBy     string          `json:"created_by"`
+	CreatedByRole string          `json:"created_by_role"`
+	CreatedAt     time.Time       `json:"created_at"`
+	UpdatedAt     time.Time       `json:"updated_at"`
+}
+
+// ScheduleRun is one execution (or skipped execution) of a schedule
+type ScheduleRun struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	ScheduleID   uint      `gorm:"index;not null" json:"schedule_id"`
+	DeviceID     string    `gorm:"index" json:"device_id"`
+	Status       string    `gorm:"index" json:"status"` // queued, skipped, failed
+	JobID        uint      `json:"job_id,omitempty"`
+	Message      string    `gorm:"type:text" json:"message,omitempty"`
+	ScheduledFor time.Time `json:"scheduled_for"`
+	CreatedAt    time.Time `gorm:"index" json:"created_at"`
+}
+
+// MaintenanceWindow freezes scheduled changes on a device for a time range
+type MaintenanceWindow struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	DeviceID  string    `gorm:"index;not null" json:"device_id"`
+	StartsAt  time.Time `gorm:"index" json:"starts_at"`
+	EndsAt    time.Time `gorm:"index" json:"ends_at"`
+	Reason    string    `gorm:"type:text" json:"reason,omitempty"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MaintenanceWindowRequest is used for creating maintenance freezes
+type MaintenanceWindowRequest struct {
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Reason   string    `json:"reason"`
+}
+
+// ONUInventory is the last known state of an ONU, keyed by device and MAC so
+// it survives slot changes
+type ONUInventory struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	DeviceID     string     `gorm:"uniqueIndex:idx_onu_inventory_device_mac;not null" json:"device_id"`
+	MAC          string     `gorm:"uniqueIndex:idx_onu_inventory_device_mac;not null" json:"mac"` // normalized: lowercase, no separators
+	MacAddress   string     `json:"mac_address"`                                                  // as reported by the OLT
+	ONUID        string     `gorm:"column:onu_id;index" json:"onu_id"`
+	PONID        string     `gorm:"column:pon_id;index" json:"pon_id"`
+	Name         string     `json:"name"`
+	Status       string     `json:"status"`
+	FwVersion    string     `json:"fw_version"`
+	ChipID       string     `json:"chip_id"`
+	Distance     int        `json:"distance_meters"`
+	Present      bool       `gorm:"index;default:true" json:"present"`
+	FirstSeenAt  time.Time  `json:"first_seen_at"`
+	LastSeenAt   time.Time  `gorm:"index" json:"last_seen_at"`
+	LastOnlineAt *time.Time `json:"last_online_at,omitempty"`
+	UpdatedAt    time.Time  `json:"updated_at"`
+}
+
+// ONUInventoryEvent records a change detected in the ONU inventory
+type ONUInventoryEvent struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	DeviceID  string    `gorm:"index;not null" json:"device_id"`
+	MAC       string    `gorm:"index" json:"mac"`
+	ONUID     string    `gorm:"column:onu_id" json:"onu_id"`
+	PONID     string    `gorm:"column:pon_id;index" json:"pon_id"`
+	Type      string    `gorm:"index;not null" json:"type"` // appeared, disappeared, moved, renamed
+	OldValue  string    `json:"old_value,omitempty"`
+	NewValue  string    `json:"new_value,omitempty"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// Subscriber is a customer record linked to an ONU by device and MAC
+type Subscriber struct {
+	ID             uint                   `gorm:"primaryKey" json:"id"`
+	AccountNumber  string                 `gorm:"uniqueIndex;not null" json:"account_number"`
+	Name           string                 `gorm:"index;not null" json:"name"`
+	Address        string                 `gorm:"type:text" json:"address,omitempty"`
+	Phone          string                 `gorm:"index" json:"phone,omitempty"`
+	Plan           string                 `gorm:"index" json:"plan,omitempty"`
+	Notes          string                 `gorm:"type:text" json:"notes,omitempty"`
+	AttributesJSON string                 `gorm:"column:attributes;type:text" json:"-"`
+	Attributes     map[string]interface{} `gorm:"-" json:"attributes,omitempty"`
+	DeviceID       string                 `gorm:"index:idx_subscriber_device_mac" json:"device_id,omitempty"`
+	MAC            string                 `gorm:"index:idx_subscriber_device_mac" json:"mac,omitempty"` // normalized: lowercase, no separators
+	CreatedAt      time.Time              `json:"created_at"`
+	UpdatedAt      time.Time              `json:"updated_at"`
+}
+
+// SubscriberRequest is used for creating subscribers.
+// The ONU link is given as device_id plus mac, or device_id plus onu_id
+// (resolved to the MAC through the ONU inventory).
+type SubscriberRequest struct {
+	AccountNumber string                 `json:"account_number" binding:"required"`
+	Name          string                 `json:"name" binding:"required"`
+	Address       string                 `json:"address"`
+	Phone         string                 `json:"phone"`
+	Plan          string                 `json:"plan"`
+	Notes         string                 `json:"notes"`
+	Attributes    map[string]interface{} `json:"attributes"`
+	DeviceID      string                 `json:"device_id"`
+	MAC           string                 `json:"mac"`
+	ONUID         string                 `json:"onu_id"`
+}
+
+// SubscriberUpdateRequest is used for updating subscribers; nil fields are
+// left unchanged. Set device_id to "" to unlink the ONU.
+type SubscriberUpdateRequest struct {
+	AccountNumber *string                `json:"account_number"`
+	Name          *string                `json:"name"`
+	Address       *string                `json:"address"`
+	Phone         *string                `json:"phone"`
+	Plan          *string                `json:"plan"`
+	Notes         *string                `json:"notes"`
+	Attributes    map[string]interface{} `json:"attributes"`
+	DeviceID      *string                `json:"device_id"`
+	MAC           *string                `json:"mac"`
+	ONUID         *string                `json:"onu_id"`
+}
+
+// Tag is a label that can be attached to devices and ONUs
+type Tag struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"uniqueIndex;not null" json:"name"` // lowercase, e.g. vip, building-12
+	Color       string    `json:"color,omitempty"`
+	Description string    `gorm:"type:text" json:"description,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+// TagRequest is used for creating and updating tags
+type TagRequest struct {
+	Name        string `json:"name" binding:"required"`
+	Color       string `json:"color"`
+	Description string `json:"description"`
+}
+
+// TagAssignRequest is used for attaching tags to a device or ONU.
+// Unknown tag names are created.
+type TagAssignRequest struct {
+	Tags []string `json:"tags" binding:"required,min=1"`
+}
+
+// ONUTag links a tag to an ONU by device and MAC
+type ONUTag struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	TagID     uint      `gorm:"uniqueIndex:idx_onu_tag;not null" json:"tag_id"`
+	DeviceID  string    `gorm:"uniqueIndex:idx_onu_tag;not null" json:"device_id"`
+	MAC       string    `gorm:"uniqueIndex:idx_onu_tag;not null" json:"mac"` // normalized: lowercase, no separators
+	CreatedAt time.Time `json:"created_at"`
+}
+
 // CacheEntry for response caching
 type CacheEntry struct {
 	Key       string    `gorm:"primaryKey" json:"key"`
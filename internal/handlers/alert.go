@@ -0,0 +1,344 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func parseAlertID(c *gin.Context, label string) (uint, bool) {
+	idValue, err := strconv.ParseUint(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || idValue == 0 {
+		response.BadRequest(c, "Invalid "+label+" ID")
+		return 0, false
+	}
+	return uint(idValue), true
+}
+
+// ListAlerts handles GET /api/v1/alerts
+// Optional: status (active, pending, firing, resolved, all), device_id,
+// severity, rule_id, limit.
+func ListAlerts(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := service.AlertFilter{
+			Status:   strings.ToLower(strings.TrimSpace(c.Query("status"))),
+			DeviceID: strings.TrimSpace(c.Query("device_id")),
+			Severity: strings.ToLower(strings.TrimSpace(c.Query("severity"))),
+		}
+		if raw := strings.TrimSpace(c.Query("rule_id")); raw != "" {
+			parsed, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil || parsed == 0 {
+				response.BadRequest(c, "Invalid rule_id value")
+				return
+			}
+			filter.RuleID = uint(parsed)
+		}
+		if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				response.BadRequest(c, "Invalid limit value")
+				return
+			}
+			filter.Limit = parsed
+		}
+
+		alerts, err := service.NewAlertService(db, cfg).ListAlerts(filter)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidInput) {
+				response.BadRequest(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, alerts, "")
+	}
+}
+
+// GetAlert handles GET /api/v1/alerts/:id
+func GetAlert(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseAlertID(c, "alert")
+		if !ok {
+			return
+		}
+
+		alert, err := service.NewAlertService(db, cfg).GetAlert(id)
+		if err != nil {
+			response.NotFound(c, err.Error())
+			return
+		}
+
+		response.Success(c, alert, alert.DeviceID)
+	}
+}
+
+// ListAlertRules handles GET /api/v1/alert-rules
+func ListAlertRules(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rules, err := service.NewAlertService(db, cfg).ListRules()
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, rules, "")
+	}
+}
+
+// GetAlertRule handles GET /api/v1/alert-rules/:id
+func GetAlertRule(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseAlertID(c, "alert rule")
+		if !ok {
+			return
+		}
+
+		rule, err := service.NewAlertService(db, cfg).GetRule(id)
+		if err != nil {
+			response.NotFound(c, err.Error())
+			return
+		}
+
+		response.Success(c, rule, rule.DeviceID)
+	}
+}
+
+// CreateAlertRule handles POST /api/v1/alert-rules
+func CreateAlertRule(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			c.Abort()
+			return
+		}
+
+		var req database.AlertRuleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+
+		_, username, _ := actorFromContext(c)
+		rule, err := service.NewAlertService(db, cfg).CreateRule(&req, username)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidInput) {
+				response.BadRequest(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "alert_rule.created", "alert_rule", strconv.FormatUint(uint64(rule.ID), 10), map[string]interface{}{
+			"name":      rule.Name,
+			"type":      rule.Type,
+			"device_id": rule.DeviceID,
+			"threshold": rule.Threshold,
+		})
+		response.Created(c, "Alert rule created successfully", rule)
+	}
+}
+
+// UpdateAlertRule handles PUT /api/v1/alert-rules/:id
+func UpdateAlertRule(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			c.Abort()
+			return
+		}
+
+		id, ok := parseAlertID(c, "alert rule")
+		if !ok {
+			return
+		}
+
+		var req database.AlertRuleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+
+		rule, err := service.NewAlertService(db, cfg).UpdateRule(id, &req)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrNotFound):
+				response.NotFound(c, err.Error())
+			case errors.Is(err, service.ErrInvalidInput):
+				response.BadRequest(c, err.Error())
+			default:
+				response.InternalError(c, err.Error())
+			}
+			return
+		}
+
+		writeAuditLog(c, db, "alert_rule.updated", "alert_rule", strconv.FormatUint(uint64(rule.ID), 10), map[string]interface{}{
+			"name":      rule.Name,
+			"type":      rule.Type,
+			"device_id": rule.DeviceID,
+			"threshold": rule.Threshold,
+			"enabled":   rule.Enabled,
+		})
+		response.SuccessWithMessage(c, "Alert rule updated successfully", rule)
+	}
+}
+
+// DeleteAlertRule handles DELETE /api/v1/alert-rules/:id
+func DeleteAlertRule(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			c.Abort()
+			return
+		}
+
+		id, ok := parseAlertID(c, "alert rule")
+		if !ok {
+			return
+		}
+
+		alertSvc := service.NewAlertService(db, cfg)
+		rule, err := alertSvc.GetRule(id)
+		if err != nil {
+			response.NotFound(c, err.Error())
+			return
+		}
+		if err := alertSvc.DeleteRule(id); err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "alert_rule.deleted", "alert_rule", strconv.FormatUint(uint64(id), 10), map[string]interface{}{
+			"name": rule.Name,
+			"type": rule.Type,
+		})
+		response.SuccessWithMessage(c, "Alert rule deleted successfully", nil)
+	}
+}
+
+// AckAlert handles POST /api/v1/alerts/:id/ack
+func AckAlert(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseAlertID(c, "alert")
+		if !ok {
+			return
+		}
+
+		var req database.AlertAckRequest
+		if c.Request.ContentLength > 0 {
+			if err := c.ShouldBindJSON(&req); err != nil {
+				response.BadRequest(c, "Invalid request: "+err.Error())
+				return
+			}
+		}
+
+		_, username, _ := actorFromContext(c)
+		alert, err := service.NewAlertService(db, cfg).Acknowledge(id, username, req.Comment)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrNotFound):
+				response.NotFound(c, err.Error())
+			case errors.Is(err, service.ErrConflict):
+				response.Error(c, 409, err.Error())
+			default:
+				response.InternalError(c, err.Error())
+			}
+			return
+		}
+
+		writeAuditLog(c, db, "alert.acknowledged", "alert", strconv.FormatUint(uint64(alert.ID), 10), map[string]interface{}{
+			"rule_id":   alert.RuleID,
+			"device_id": alert.DeviceID,
+			"onu_id":    alert.ONUID,
+			"comment":   alert.AckComment,
+		})
+		response.SuccessWithMessage(c, "Alert acknowledged", alert)
+	}
+}
+
+// ListAlertSilences handles GET /api/v1/alert-silences
+// Optional: all=true includes expired silences.
+func ListAlertSilences(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		silences, err := service.NewAlertService(db, cfg).ListSilences(queryBool(c, "all"))
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, silences, "")
+	}
+}
+
+// CreateAlertSilence handles POST /api/v1/alert-silences
+func CreateAlertSilence(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req database.AlertSilenceRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+		if req.ONUID != "" {
+			req.ONUID = normalizeONUID(req.ONUID)
+		}
+		req.PONID = normalizePONID(req.PONID)
+
+		_, username, _ := actorFromContext(c)
+		silence, err := service.NewAlertService(db, cfg).CreateSilence(&req, username)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidInput) {
+				response.BadRequest(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "alert_silence.created", "alert_silence", strconv.FormatUint(uint64(silence.ID), 10), map[string]interface{}{
+			"device_id": silence.DeviceID,
+			"pon_id":    silence.PONID,
+			"onu_id":    silence.ONUID,
+			"rule_id":   silence.RuleID,
+			"starts_at": silence.StartsAt,
+			"ends_at":   silence.EndsAt,
+			"comment":   silence.Comment,
+		})
+		response.Created(c, "Alert silence created successfully", silence)
+	}
+}
+
+// DeleteAlertSilence handles DELETE /api/v1/alert-silences/:id
+// The silence is expired rather than removed.
+func DeleteAlertSilence(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseAlertID(c, "alert silence")
+		if !ok {
+			return
+		}
+
+		silence, err := service.NewAlertService(db, cfg).ExpireSilence(id)
+		if err != nil {
+			if errors.Is(err, service.ErrNotFound) {
+				response.NotFound(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "alert_silence.expired", "alert_silence", strconv.FormatUint(uint64(id), 10), map[string]interface{}{
+			"device_id": silence.DeviceID,
+			"pon_id":    silence.PONID,
+			"onu_id":    silence.ONUID,
+			"rule_id":   silence.RuleID,
+		})
+		response.SuccessWithMessage(c, "Alert silence expired", silence)
+	}
+}
@@ -63,3 +63,15 @@ func writeAuditLog(
 	}
 }
 
+// jobActorFromContext captures the requesting user so background jobs can
+// write audit entries on their behalf after the request has returned.
+func jobActorFromContext(c *gin.Context) service.JobActor {
+	userID, username, role := actorFromContext(c)
+	return service.JobActor{
+		UserID:    userID,
+		Username:  username,
+		Role:      role,
+		IPAddress: c.ClientIP(),
+		UserAgent: c.GetHeader("User-Agent"),
+	}
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// availabilityLookBack is the default range of availability queries
+const availabilityLookBack = 30 * 24 * time.Hour
+
+// GetONUAvailability handles GET /api/v1/devices/:id/onus/:onu_id/availability
+// from and to default to the last 30 days. olt=false skips reading the last
+// up/down times from the OLT.
+func GetONUAvailability(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		onuID := c.Param("onu_id")
+		if deviceID == "" || onuID == "" {
+			response.BadRequest(c, "Device ID and ONU ID are required")
+			return
+		}
+		onuID = normalizeONUID(onuID)
+
+		from, to, ok := parseTimeRangeDefault(c, availabilityLookBack)
+		if !ok {
+			return
+		}
+		useOLT := c.Query("olt") == "" || queryBool(c, "olt")
+
+		deviceSvc := service.NewDeviceService(db, cfg)
+		availability, err := service.NewAvailabilityService(db, cfg, deviceSvc).ONU(deviceID, onuID, from, to, useOLT)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidRange):
+				response.BadRequest(c, err.Error())
+			case errors.Is(err, service.ErrNotFound):
+				response.NotFound(c, err.Error())
+			default:
+				response.InternalError(c, err.Error())
+			}
+			return
+		}
+
+		response.Success(c, availability, deviceID)
+	}
+}
+
+// GetAvailabilityReport handles GET /api/v1/availability
+// Requires tag (ONU tag), plan (subscriber plan) or subscriber_id; optional
+// device_id, target (percentage), from and to (default: last 30 days).
+func GetAvailabilityReport(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := service.AvailabilityFilter{
+			Tag:      strings.TrimSpace(c.Query("tag")),
+			Plan:     strings.TrimSpace(c.Query("plan")),
+			DeviceID: strings.TrimSpace(c.Query("device_id")),
+		}
+		if raw := strings.TrimSpace(c.Query("subscriber_id")); raw != "" {
+			parsed, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil || parsed == 0 {
+				response.BadRequest(c, "Invalid subscriber_id value")
+				return
+			}
+			filter.SubscriberID = uint(parsed)
+		}
+		if raw := strings.TrimSpace(c.Query("target")); raw != "" {
+			parsed, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				response.BadRequest(c, "Invalid target value")
+				return
+			}
+			filter.Target = parsed
+		}
+
+		from, to, ok := parseTimeRangeDefault(c, availabilityLookBack)
+		if !ok {
+			return
+		}
+
+		deviceSvc := service.NewDeviceService(db, cfg)
+		report, err := service.NewAvailabilityService(db, cfg, deviceSvc).Report(filter, from, to)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidRange) || errors.Is(err, service.ErrInvalidInput) {
+				response.BadRequest(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, report, filter.DeviceID)
+	}
+}
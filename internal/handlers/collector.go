@@ -0,0 +1,17 @@
+package handlers
+
+import (
+	"olt-api/internal/config"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetCollectorStatus handles GET /api/v1/collector/status
+func GetCollectorStatus(db *gorm.DB, cfg *config.Config, collector *service.Collector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		response.Success(c, collector.Status(), "")
+	}
+}
@@ -1,6 +1,9 @@
 package handlers
 
 import (
+	"errors"
+	"time"
+
 	"olt-api/internal/config"
 	"olt-api/internal/database"
 	"olt-api/internal/service"
@@ -36,15 +39,22 @@ func CreateDevice(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 	}
 }
 
-// ListDevices handles GET /api/v1/devices
+// ListDevices handles GET /api/v1/devices?tag=...
 func ListDevices(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		svc := service.NewDeviceService(db, cfg)
-		devices, err := svc.GetAll()
+		devices, err := svc.GetAllWithTags(service.ParseTagList(c.Query("tag")))
 		if err != nil {
 			response.InternalError(c, err.Error())
 			return
 		}
+		reachability := service.NewReachabilityService(db, cfg, svc)
+		now := time.Now()
+		for i := range devices {
+			if uptime, err := reachability.Uptime(devices[i].ID, now); err == nil {
+				devices[i].Uptime = uptime
+			}
+		}
 
 		response.Success(c, devices, "")
 	}
@@ -65,6 +75,12 @@ func GetDevice(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 			response.NotFound(c, err.Error())
 			return
 		}
+		if tags, err := service.NewTagService(db).DeviceTags(id); err == nil {
+			device.Tags = tags
+		}
+		if uptime, err := service.NewReachabilityService(db, cfg, svc).Uptime(id, time.Now()); err == nil {
+			device.Uptime = uptime
+		}
 
 		response.Success(c, device, id)
 	}
@@ -111,7 +127,11 @@ func DeleteDevice(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 
 		svc := service.NewDeviceService(db, cfg)
 		if err := svc.Delete(id); err != nil {
-			response.NotFound(c, err.Error())
+			if errors.Is(err, service.ErrNotFound) {
+				response.NotFound(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
 			return
 		}
 
@@ -158,6 +178,39 @@ func CheckDeviceStatus(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 	}
 }
 
+// GetDeviceStatusHistory handles GET /api/v1/devices/:id/status/history
+// Optional from and to (default: last 7 days).
+func GetDeviceStatusHistory(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if id == "" {
+			response.BadRequest(c, "Device ID is required")
+			return
+		}
+
+		from, to, ok := parseTimeRangeDefault(c, 7*24*time.Hour)
+		if !ok {
+			return
+		}
+
+		svc := service.NewDeviceService(db, cfg)
+		history, err := service.NewReachabilityService(db, cfg, svc).History(id, from, to)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidRange):
+				response.BadRequest(c, err.Error())
+			case errors.Is(err, service.ErrNotFound):
+				response.NotFound(c, err.Error())
+			default:
+				response.InternalError(c, err.Error())
+			}
+			return
+		}
+
+		response.Success(c, history, id)
+	}
+}
+
 // CheckDeviceConnection handles POST /api/v1/devices/check-connection
 func CheckDeviceConnection(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
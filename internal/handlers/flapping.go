@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetONUFlapping handles GET /api/v1/devices/:id/onus/flapping
+// Optional: window (look-back such as 24h or 7d, or an RFC3339 start;
+// default 24h), min_flaps (default 3), pon_id.
+func GetONUFlapping(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		if deviceID == "" {
+			response.BadRequest(c, "Device ID is required")
+			return
+		}
+
+		flapSvc := service.NewFlapService(db, cfg)
+		to := time.Now()
+		from := to.Add(-24 * time.Hour)
+		if raw := strings.TrimSpace(c.Query("window")); raw != "" {
+			since, err := parseSince(raw)
+			if err != nil {
+				response.BadRequest(c, "Invalid window value (use a duration such as 24h or 7d, or RFC3339)")
+				return
+			}
+			from = since
+		}
+		if to.Sub(from) > flapSvc.MaxWindow() {
+			response.BadRequest(c, "window cannot exceed raw ONU log retention ("+flapSvc.MaxWindow().String()+")")
+			return
+		}
+
+		minFlaps := 3
+		if raw := strings.TrimSpace(c.Query("min_flaps")); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				response.BadRequest(c, "Invalid min_flaps value")
+				return
+			}
+			minFlaps = parsed
+		}
+
+		if _, err := service.NewDeviceService(db, cfg).GetByID(deviceID); err != nil {
+			response.NotFound(c, err.Error())
+			return
+		}
+
+		report, err := flapSvc.Report(deviceID, normalizePONID(c.Query("pon_id")), from, to, minFlaps)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidRange) {
+				response.BadRequest(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, report, deviceID)
+	}
+}
@@ -0,0 +1,30 @@
+package handlers
+
+import (
+	"fmt"
+
+	"olt-api/internal/config"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetFleetSummary handles GET /api/v1/fleet/summary
+func GetFleetSummary(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceSvc := service.NewDeviceService(db, cfg)
+		fleetSvc := service.NewFleetService(db, cfg, deviceSvc)
+
+		summary, err := fleetSvc.Summary(queryBool(c, "refresh"))
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		c.Header("Cache-Control", fmt.Sprintf("private, max-age=%d, stale-while-revalidate=%d",
+			int(cfg.Fleet.FreshTTL.Seconds()), int((cfg.Fleet.StaleTTL-cfg.Fleet.FreshTTL).Seconds())))
+		response.Success(c, summary, "")
+	}
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetONUHistory handles GET /api/v1/devices/:id/onus/:onu_id/history
+// from and to accept RFC3339 timestamps or look-back durations ("24h");
+// they default to the last 24 hours.
+func GetONUHistory(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		onuID := c.Param("onu_id")
+		if deviceID == "" || onuID == "" {
+			response.BadRequest(c, "Device ID and ONU ID are required")
+			return
+		}
+		onuID = normalizeONUID(onuID)
+
+		from, to, ok := parseTimeRange(c)
+		if !ok {
+			return
+		}
+
+		step, err := service.ParseHistoryStep(c.Query("step"))
+		if err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+
+		history, err := service.NewHistoryService(db, cfg).ONUHistory(deviceID, onuID, from, to, step)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidRange) {
+				response.BadRequest(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, history, deviceID)
+	}
+}
+
+// parseTimeRange reads the from and to query parameters, defaulting to the
+// last 24 hours. It writes a bad request response and returns false when a
+// value is invalid.
+func parseTimeRange(c *gin.Context) (time.Time, time.Time, bool) {
+	return parseTimeRangeDefault(c, 24*time.Hour)
+}
+
+// parseTimeRangeDefault is parseTimeRange with the given default look-back
+func parseTimeRangeDefault(c *gin.Context, lookBack time.Duration) (time.Time, time.Time, bool) {
+	to := time.Now()
+	if raw := strings.TrimSpace(c.Query("to")); raw != "" {
+		parsed, err := parseSince(raw)
+		if err != nil {
+			response.BadRequest(c, "Invalid to value (use RFC3339 or a duration such as 1h)")
+			return time.Time{}, time.Time{}, false
+		}
+		to = parsed
+	}
+	from := to.Add(-lookBack)
+	if raw := strings.TrimSpace(c.Query("from")); raw != "" {
+		parsed, err := parseSince(raw)
+		if err != nil {
+			response.BadRequest(c, "Invalid from value (use RFC3339 or a duration such as 24h)")
+			return time.Time{}, time.Time{}, false
+		}
+		from = parsed
+	}
+	return from, to, true
+}
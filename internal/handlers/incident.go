@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"olt-api/internal/config"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListIncidents handles GET /api/v1/incidents
+// Optional: status (open, resolved, all), device_id, type, limit.
+func ListIncidents(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := service.IncidentFilter{
+			Status:   strings.ToLower(strings.TrimSpace(c.Query("status"))),
+			DeviceID: strings.TrimSpace(c.Query("device_id")),
+			Type:     strings.ToLower(strings.TrimSpace(c.Query("type"))),
+		}
+		if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				response.BadRequest(c, "Invalid limit value")
+				return
+			}
+			filter.Limit = parsed
+		}
+
+		incidents, err := service.NewIncidentService(db, cfg).ListIncidents(filter)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidInput) {
+				response.BadRequest(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, incidents, "")
+	}
+}
+
+// GetIncident handles GET /api/v1/incidents/:id
+func GetIncident(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseAlertID(c, "incident")
+		if !ok {
+			return
+		}
+
+		incident, err := service.NewIncidentService(db, cfg).GetIncident(id)
+		if err != nil {
+			response.NotFound(c, err.Error())
+			return
+		}
+
+		response.Success(c, incident, incident.DeviceID)
+	}
+}
+
+// ResolveIncident handles POST /api/v1/incidents/:id/resolve
+func ResolveIncident(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseAlertID(c, "incident")
+		if !ok {
+			return
+		}
+
+		_, username, _ := actorFromContext(c)
+		incident, err := service.NewIncidentService(db, cfg).Resolve(id, username)
+		if incident == nil {
+			switch {
+			case errors.Is(err, service.ErrNotFound):
+				response.NotFound(c, err.Error())
+			case errors.Is(err, service.ErrConflict):
+				response.Error(c, 409, err.Error())
+			default:
+				response.InternalError(c, err.Error())
+			}
+			return
+		}
+
+		writeAuditLog(c, db, "incident.resolved", "incident", strconv.FormatUint(uint64(id), 10), map[string]interface{}{
+			"device_id": incident.DeviceID,
+			"pon_id":    incident.PONID,
+			"type":      incident.Type,
+			"down":      incident.Down,
+		})
+		if err != nil {
+			response.SuccessWithMessage(c, "Incident resolved, but notifications could not be queued: "+err.Error(), incident)
+			return
+		}
+		response.SuccessWithMessage(c, "Incident resolved", incident)
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetONUInventory handles GET /api/v1/devices/:id/onus/inventory
+func GetONUInventory(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		if deviceID == "" {
+			response.BadRequest(c, "Device ID is required")
+			return
+		}
+
+		var present *bool
+		if raw := strings.TrimSpace(c.Query("present")); raw != "" {
+			parsed, err := strconv.ParseBool(raw)
+			if err != nil {
+				response.BadRequest(c, "Invalid present value")
+				return
+			}
+			present = &parsed
+		}
+
+		deviceSvc := service.NewDeviceService(db, cfg)
+		if _, err := deviceSvc.GetByID(deviceID); err != nil {
+			response.NotFound(c, err.Error())
+			return
+		}
+
+		items, err := service.NewInventoryService(db).List(deviceID, normalizePONID(c.Query("pon_id")), present)
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, items, deviceID)
+	}
+}
+
+// GetONUChanges handles GET /api/v1/devices/:id/onus/changes
+func GetONUChanges(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		if deviceID == "" {
+			response.BadRequest(c, "Device ID is required")
+			return
+		}
+
+		filter := service.InventoryChangeFilter{
+			PONID: normalizePONID(c.Query("pon_id")),
+			Type:  c.Query("type"),
+		}
+		if raw := strings.TrimSpace(c.Query("since")); raw != "" {
+			since, err := parseSince(raw)
+			if err != nil {
+				response.BadRequest(c, "Invalid since value (use RFC3339 or a duration such as 24h)")
+				return
+			}
+			filter.Since = since
+		}
+		if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				response.BadRequest(c, "Invalid limit value")
+				return
+			}
+			filter.Limit = parsed
+		}
+
+		deviceSvc := service.NewDeviceService(db, cfg)
+		if _, err := deviceSvc.GetByID(deviceID); err != nil {
+			response.NotFound(c, err.Error())
+			return
+		}
+
+		events, err := service.NewInventoryService(db).Changes(deviceID, filter)
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, events, deviceID)
+	}
+}
+
+// normalizePONID converts a simplified PON ID ("1") to full format ("0/1")
+func normalizePONID(ponID string) string {
+	ponID = strings.TrimSpace(ponID)
+	if _, err := strconv.Atoi(ponID); err == nil {
+		return "0/" + ponID
+	}
+	return ponID
+}
+
+// parseSince accepts an RFC3339 timestamp or a look-back duration ("24h", "7d")
+func parseSince(raw string) (time.Time, error) {
+	if since, err := time.Parse(time.RFC3339, raw); err == nil {
+		return since, nil
+	}
+	if days, found := strings.CutSuffix(raw, "d"); found {
+		if n, err := strconv.Atoi(days); err == nil && n > 0 {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	window, err := time.ParseDuration(raw)
+	if err != nil || window <= 0 {
+		return time.Time{}, fmt.Errorf("invalid since value %q", raw)
+	}
+	return time.Now().Add(-window), nil
+}
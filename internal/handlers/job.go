@@ -0,0 +1,228 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// BulkONUAction handles POST /api/v1/devices/:id/onus/bulk-action
+func BulkONUAction(db *gorm.DB, cfg *config.Config, jobs *service.JobManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		if deviceID == "" {
+			response.BadRequest(c, "Device ID is required")
+			return
+		}
+
+		var req service.BulkONUActionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+		if len(req.ONUIDs) == 0 && len(req.Names) == 0 && req.Filter == nil {
+			response.BadRequest(c, "Either onu_ids, names or filter is required")
+			return
+		}
+
+		// Convert simplified IDs to full format
+		normalizeBulkRequest(&req)
+
+		deviceSvc := service.NewDeviceService(db, cfg)
+		if _, err := deviceSvc.GetByID(deviceID); err != nil {
+			response.NotFound(c, err.Error())
+			return
+		}
+
+		bulkSvc := service.NewBulkService(db, cfg, deviceSvc)
+		targets, err := bulkSvc.Prepare(deviceID, &req)
+		if err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+
+		job, err := jobs.Enqueue(service.JobTypeONUBulkAction, deviceID, jobActorFromContext(c), req, targets)
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "onu.bulk_action.submitted", "job", strconv.FormatUint(uint64(job.ID), 10), map[string]interface{}{
+			"device_id": deviceID,
+			"action":    req.Action,
+			"total":     job.Total,
+		})
+		response.Accepted(c, "Bulk action queued", job)
+	}
+}
+
+// normalizeBulkRequest converts simplified ONU and PON IDs in a bulk request
+func normalizeBulkRequest(req *service.BulkONUActionRequest) {
+	for i, onuID := range req.ONUIDs {
+		req.ONUIDs[i] = normalizeONUID(strings.TrimSpace(onuID))
+	}
+	if len(req.Names) > 0 {
+		names := make(map[string]string, len(req.Names))
+		for onuID, name := range req.Names {
+			names[normalizeONUID(strings.TrimSpace(onuID))] = name
+		}
+		req.Names = names
+	}
+	if req.Filter != nil {
+		ponID := strings.TrimSpace(req.Filter.PONID)
+		if _, err := strconv.Atoi(ponID); err == nil {
+			ponID = "0/" + ponID
+		}
+		req.Filter.PONID = ponID
+	}
+}
+
+// ScanONUs handles POST /api/v1/devices/:id/onus/scan
+func ScanONUs(db *gorm.DB, cfg *config.Config, jobs *service.JobManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		if deviceID == "" {
+			response.BadRequest(c, "Device ID is required")
+			return
+		}
+
+		deviceSvc := service.NewDeviceService(db, cfg)
+		if _, err := deviceSvc.GetByID(deviceID); err != nil {
+			response.NotFound(c, err.Error())
+			return
+		}
+
+		req := service.ONUScanRequest{Filter: c.Query("filter")}
+		job, err := jobs.Enqueue(service.JobTypeONUScan, deviceID, jobActorFromContext(c), req, nil)
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "onu.scan.submitted", "job", strconv.FormatUint(uint64(job.ID), 10), map[string]interface{}{
+			"device_id": deviceID,
+		})
+		response.Accepted(c, "ONU scan queued", job)
+	}
+}
+
+// ListJobs handles GET /api/v1/jobs
+// Non-admin users only see their own jobs.
+func ListJobs(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_ = cfg
+
+		limit := 50
+		if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				response.BadRequest(c, "Invalid limit")
+				return
+			}
+			limit = parsed
+		}
+
+		var userID uint
+		if raw := strings.TrimSpace(c.Query("user_id")); raw != "" {
+			parsed, err := strconv.ParseUint(raw, 10, 64)
+			if err != nil || parsed == 0 {
+				response.BadRequest(c, "Invalid user_id")
+				return
+			}
+			userID = uint(parsed)
+		}
+
+		actorID, _, role := actorFromContext(c)
+		if !strings.EqualFold(role, "admin") {
+			userID = actorID
+		}
+
+		filter := service.JobFilter{
+			Limit:    limit,
+			UserID:   userID,
+			DeviceID: strings.TrimSpace(c.Query("device_id")),
+			Type:     strings.TrimSpace(c.Query("type")),
+			Status:   strings.TrimSpace(c.Query("status")),
+		}
+
+		jobSvc := service.NewJobService(db)
+		jobs, err := jobSvc.List(filter)
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, jobs, filter.DeviceID)
+	}
+}
+
+// GetJob handles GET /api/v1/jobs/:id
+func GetJob(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_ = cfg
+
+		job, ok := loadAccessibleJob(c, db)
+		if !ok {
+			return
+		}
+
+		response.Success(c, job, job.DeviceID)
+	}
+}
+
+// CancelJob handles DELETE /api/v1/jobs/:id
+func CancelJob(db *gorm.DB, cfg *config.Config, jobs *service.JobManager) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_ = cfg
+
+		job, ok := loadAccessibleJob(c, db)
+		if !ok {
+			return
+		}
+
+		if err := jobs.Cancel(job.ID); err != nil {
+			response.Error(c, 409, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "job.cancelled", "job", strconv.FormatUint(uint64(job.ID), 10), map[string]interface{}{
+			"type":      job.Type,
+			"device_id": job.DeviceID,
+		})
+		response.SuccessWithMessage(c, "Job cancellation requested", map[string]interface{}{
+			"job_id": job.ID,
+		})
+	}
+}
+
+// loadAccessibleJob loads the job from the :id param; non-admin users may
+// only access their own jobs.
+func loadAccessibleJob(c *gin.Context, db *gorm.DB) (*database.Job, bool) {
+	idValue, err := strconv.ParseUint(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || idValue == 0 {
+		response.BadRequest(c, "Invalid job ID")
+		return nil, false
+	}
+
+	jobSvc := service.NewJobService(db)
+	job, err := jobSvc.Get(uint(idValue))
+	if err != nil {
+		response.NotFound(c, err.Error())
+		return nil, false
+	}
+
+	actorID, _, role := actorFromContext(c)
+	if !strings.EqualFold(role, "admin") && job.UserID != actorID {
+		response.NotFound(c, "job '"+strconv.FormatUint(idValue, 10)+"' not found")
+		return nil, false
+	}
+
+	return job, true
+}
@@ -0,0 +1,100 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// ListMaintenanceWindows handles GET /api/v1/devices/:id/maintenance
+func ListMaintenanceWindows(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_ = cfg
+
+		deviceID := c.Param("id")
+		if deviceID == "" {
+			response.BadRequest(c, "Device ID is required")
+			return
+		}
+
+		svc := service.NewMaintenanceService(db)
+		windows, err := svc.List(deviceID)
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, windows, deviceID)
+	}
+}
+
+// CreateMaintenanceWindow handles POST /api/v1/devices/:id/maintenance
+func CreateMaintenanceWindow(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		if deviceID == "" {
+			response.BadRequest(c, "Device ID is required")
+			return
+		}
+
+		var req database.MaintenanceWindowRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+
+		deviceSvc := service.NewDeviceService(db, cfg)
+		if _, err := deviceSvc.GetByID(deviceID); err != nil {
+			response.NotFound(c, err.Error())
+			return
+		}
+
+		_, username, _ := actorFromContext(c)
+		svc := service.NewMaintenanceService(db)
+		window, err := svc.Create(deviceID, &req, username)
+		if err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "device.maintenance.created", "device", deviceID, map[string]interface{}{
+			"window_id": window.ID,
+			"starts_at": window.StartsAt,
+			"ends_at":   window.EndsAt,
+			"reason":    window.Reason,
+		})
+		response.Created(c, "Maintenance window created successfully", window)
+	}
+}
+
+// DeleteMaintenanceWindow handles DELETE /api/v1/devices/:id/maintenance/:window_id
+func DeleteMaintenanceWindow(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		_ = cfg
+
+		deviceID := c.Param("id")
+		windowID, err := strconv.ParseUint(strings.TrimSpace(c.Param("window_id")), 10, 64)
+		if err != nil || windowID == 0 {
+			response.BadRequest(c, "Invalid maintenance window ID")
+			return
+		}
+
+		svc := service.NewMaintenanceService(db)
+		if err := svc.Delete(deviceID, uint(windowID)); err != nil {
+			response.NotFound(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "device.maintenance.deleted", "device", deviceID, map[string]interface{}{
+			"window_id": windowID,
+		})
+		response.SuccessWithMessage(c, "Maintenance window deleted successfully", nil)
+	}
+}
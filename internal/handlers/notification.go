@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func parseChannelID(c *gin.Context) (uint, bool) {
+	idValue, err := strconv.ParseUint(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || idValue == 0 {
+		response.BadRequest(c, "Invalid notification channel ID")
+		return 0, false
+	}
+	return uint(idValue), true
+}
+
+// ListNotificationChannels handles GET /api/v1/notification-channels
+func ListNotificationChannels(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			c.Abort()
+			return
+		}
+
+		channels, err := service.NewNotificationService(db, cfg).ListChannels()
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, channels, "")
+	}
+}
+
+// GetNotificationChannel handles GET /api/v1/notification-channels/:id
+func GetNotificationChannel(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			c.Abort()
+			return
+		}
+
+		id, ok := parseChannelID(c)
+		if !ok {
+			return
+		}
+
+		channel, err := service.NewNotificationService(db, cfg).GetChannel(id)
+		if err != nil {
+			response.NotFound(c, err.Error())
+			return
+		}
+
+		response.Success(c, service.MaskedChannel(channel), "")
+	}
+}
+
+// CreateNotificationChannel handles POST /api/v1/notification-channels
+func CreateNotificationChannel(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			c.Abort()
+			return
+		}
+
+		var req database.NotificationChannelRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+
+		_, username, _ := actorFromContext(c)
+		channel, err := service.NewNotificationService(db, cfg).CreateChannel(&req, username)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidInput) {
+				response.BadRequest(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "notification_channel.created", "notification_channel", strconv.FormatUint(uint64(channel.ID), 10), map[string]interface{}{
+			"name": channel.Name,
+			"type": channel.Type,
+		})
+		response.Created(c, "Notification channel created successfully", channel)
+	}
+}
+
+// UpdateNotificationChannel handles PUT /api/v1/notification-channels/:id
+func UpdateNotificationChannel(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			c.Abort()
+			return
+		}
+
+		id, ok := parseChannelID(c)
+		if !ok {
+			return
+		}
+
+		var req database.NotificationChannelRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+
+		channel, err := service.NewNotificationService(db, cfg).UpdateChannel(id, &req)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrNotFound):
+				response.NotFound(c, err.Error())
+			case errors.Is(err, service.ErrInvalidInput):
+				response.BadRequest(c, err.Error())
+			default:
+				response.InternalError(c, err.Error())
+			}
+			return
+		}
+
+		writeAuditLog(c, db, "notification_channel.updated", "notification_channel", strconv.FormatUint(uint64(channel.ID), 10), map[string]interface{}{
+			"name":    channel.Name,
+			"type":    channel.Type,
+			"enabled": channel.Enabled,
+		})
+		response.SuccessWithMessage(c, "Notification channel updated successfully", channel)
+	}
+}
+
+// DeleteNotificationChannel handles DELETE /api/v1/notification-channels/:id
+func DeleteNotificationChannel(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			c.Abort()
+			return
+		}
+
+		id, ok := parseChannelID(c)
+		if !ok {
+			return
+		}
+
+		notifySvc := service.NewNotificationService(db, cfg)
+		channel, err := notifySvc.GetChannel(id)
+		if err != nil {
+			response.NotFound(c, err.Error())
+			return
+		}
+		if err := notifySvc.DeleteChannel(id); err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "notification_channel.deleted", "notification_channel", strconv.FormatUint(uint64(id), 10), map[string]interface{}{
+			"name": channel.Name,
+			"type": channel.Type,
+		})
+		response.SuccessWithMessage(c, "Notification channel deleted successfully", nil)
+	}
+}
+
+// TestNotificationChannel handles POST /api/v1/notification-channels/:id/test
+func TestNotificationChannel(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			c.Abort()
+			return
+		}
+
+		id, ok := parseChannelID(c)
+		if !ok {
+			return
+		}
+
+		notification, err := service.NewNotificationService(db, cfg).SendTest(id)
+		if notification == nil {
+			if errors.Is(err, service.ErrNotFound) {
+				response.NotFound(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "notification_channel.tested", "notification_channel", strconv.FormatUint(uint64(id), 10), map[string]interface{}{
+			"status": notification.Status,
+		})
+		if err != nil {
+			response.Error(c, 502, "Test notification failed: "+err.Error())
+			return
+		}
+		response.SuccessWithMessage(c, "Test notification sent", notification)
+	}
+}
+
+// ListNotifications handles GET /api/v1/notifications
+// Optional: channel_id, alert_id, status (pending, sent, failed), limit.
+func ListNotifications(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			c.Abort()
+			return
+		}
+
+		filter := service.NotificationFilter{
+			Status: strings.ToLower(strings.TrimSpace(c.Query("status"))),
+		}
+		for key, target := range map[string]*uint{"channel_id": &filter.ChannelID, "alert_id": &filter.AlertID} {
+			if raw := strings.TrimSpace(c.Query(key)); raw != "" {
+				parsed, err := strconv.ParseUint(raw, 10, 64)
+				if err != nil || parsed == 0 {
+					response.BadRequest(c, "Invalid "+key+" value")
+					return
+				}
+				*target = uint(parsed)
+			}
+		}
+		if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				response.BadRequest(c, "Invalid limit value")
+				return
+			}
+			filter.Limit = parsed
+		}
+
+		notifications, err := service.NewNotificationService(db, cfg).ListNotifications(filter)
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, notifications, "")
+	}
+}
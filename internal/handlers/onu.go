@@ -45,9 +45,21 @@ func GetONUs(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 			return
 		}
 
-		// Optional filter (online, offline, etc.)
+		// Optional filters: status (online, offline, etc.) and tag (comma-separated, all must match).
+		// Range, pattern, sort, paging and fields parameters are read by parseONUQuery.
 		filter := c.Query("filter")
 
+		query, err := parseONUQuery(c)
+		if err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+
+		if strings.EqualFold(ponID, "all") {
+			respondAllONUs(c, db, cfg, deviceID, filter, query)
+			return
+		}
+
 		// Helper: If ponID is just a single number (e.g. "1"), convert to "0/1"
 		if _, err := strconv.Atoi(ponID); err == nil {
 			ponID = "0/" + ponID
@@ -61,9 +73,74 @@ func GetONUs(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 			response.InternalError(c, err.Error())
 			return
 		}
+		onus = service.FilterONUsByTags(onus, service.ParseTagList(c.Query("tag")))
 
-		response.Success(c, onus, deviceID)
+		page, total := query.Apply(onus)
+		data, err := query.Project(page)
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		if query.Page > 0 {
+			response.Paginated(c, data, total, query.Page, query.PageSize, deviceID)
+			return
+		}
+		response.Success(c, data, deviceID)
+	}
+}
+
+// GetAllONUs handles GET /api/v1/devices/:id/onus/all
+func GetAllONUs(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		if deviceID == "" {
+			response.BadRequest(c, "Device ID is required")
+			return
+		}
+
+		query, err := parseONUQuery(c)
+		if err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+
+		respondAllONUs(c, db, cfg, deviceID, c.Query("filter"), query)
+	}
+}
+
+// respondAllONUs lists the ONUs of every PON together with the per-PON fetch
+// results. Failed PONs are reported instead of silently shrinking the list.
+func respondAllONUs(c *gin.Context, db *gorm.DB, cfg *config.Config, deviceID, filter string, query *service.ONUQuery) {
+	deviceSvc := service.NewDeviceService(db, cfg)
+	onuSvc := service.NewONUService(db, cfg, deviceSvc)
+
+	result, err := onuSvc.GetAllONUsWithResults(deviceID, filter)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+	onus := service.FilterONUsByTags(result.ONUs, service.ParseTagList(c.Query("tag")))
+
+	page, total := query.Apply(onus)
+	data, err := query.Project(page)
+	if err != nil {
+		response.InternalError(c, err.Error())
+		return
+	}
+
+	payload := map[string]interface{}{
+		"complete":    result.Complete,
+		"failed_pons": result.FailedPONs,
+		"pons":        result.PONs,
+		"onus":        data,
+	}
+	if query.Page > 0 {
+		response.Paginated(c, payload, total, query.Page, query.PageSize, deviceID)
+		return
 	}
+	payload["total"] = total
+	response.Success(c, payload, deviceID)
 }
 
 // GetONUDetail handles GET /api/v1/devices/:device_id/onus/:onu_id
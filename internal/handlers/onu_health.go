@@ -0,0 +1,43 @@
+package handlers
+
+import (
+	"errors"
+	"strings"
+
+	"olt-api/internal/config"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetONUHealth handles GET /api/v1/onus/health
+// Lists online ONUs in warning or critical health across every device, from
+// the collector's last run. Optional: status (warning, critical), device_id.
+func GetONUHealth(db *gorm.DB, cfg *config.Config, collector *service.Collector) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := service.HealthFilter{
+			DeviceID: strings.TrimSpace(c.Query("device_id")),
+			Status:   strings.ToLower(strings.TrimSpace(c.Query("status"))),
+		}
+
+		deviceSvc := service.NewDeviceService(db, cfg)
+		report, err := service.NewHealthService(db, cfg, deviceSvc).Fleet(filter, collector)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidInput):
+				response.BadRequest(c, err.Error())
+			case errors.Is(err, service.ErrNotFound):
+				response.NotFound(c, err.Error())
+			case errors.Is(err, service.ErrNotConfigured):
+				response.Error(c, 501, err.Error())
+			default:
+				response.InternalError(c, err.Error())
+			}
+			return
+		}
+
+		response.Success(c, report, filter.DeviceID)
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"olt-api/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultONUPageSize = 50
+	maxONUPageSize     = 500
+)
+
+// parseONUQuery reads the ONU list filter, sort, paging and projection
+// parameters. Paging is only enabled when page or page_size is given.
+func parseONUQuery(c *gin.Context) (*service.ONUQuery, error) {
+	q := &service.ONUQuery{}
+	var err error
+
+	floats := []struct {
+		key    string
+		target **float64
+	}{
+		{"rx_min", &q.RxMin}, {"rx_max", &q.RxMax},
+		{"tx_min", &q.TxMin}, {"tx_max", &q.TxMax},
+		{"temp_min", &q.TempMin}, {"temp_max", &q.TempMax},
+	}
+	for _, f := range floats {
+		if *f.target, err = queryFloat(c, f.key); err != nil {
+			return nil, err
+		}
+	}
+	if q.DistanceMin, err = queryInt(c, "distance_min"); err != nil {
+		return nil, err
+	}
+	if q.DistanceMax, err = queryInt(c, "distance_max"); err != nil {
+		return nil, err
+	}
+
+	q.Status = queryList(c, "status")
+	q.FwVersions = queryList(c, "fw")
+	q.ChipIDs = queryList(c, "chip")
+
+	if raw := strings.TrimSpace(c.Query("activated")); raw != "" {
+		activated, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid activated value: %s", raw)
+		}
+		q.Activated = &activated
+	}
+	if raw := strings.TrimSpace(c.Query("name")); raw != "" {
+		pattern, err := regexp.Compile("(?i)" + raw)
+		if err != nil {
+			return nil, fmt.Errorf("invalid name pattern: %w", err)
+		}
+		q.NamePattern = pattern
+	}
+	if raw := strings.TrimSpace(c.Query("mac")); raw != "" {
+		q.MACPattern = service.NormalizeMAC(raw)
+	}
+
+	if q.Sort, err = service.ParseONUSort(c.Query("sort")); err != nil {
+		return nil, err
+	}
+
+	page, err := queryInt(c, "page")
+	if err != nil {
+		return nil, err
+	}
+	pageSize, err := queryInt(c, "page_size")
+	if err != nil {
+		return nil, err
+	}
+	if page != nil || pageSize != nil {
+		q.Page, q.PageSize = 1, defaultONUPageSize
+		if page != nil {
+			if *page <= 0 {
+				return nil, fmt.Errorf("page must be positive")
+			}
+			q.Page = *page
+		}
+		if pageSize != nil {
+			if *pageSize <= 0 || *pageSize > maxONUPageSize {
+				return nil, fmt.Errorf("page_size must be between 1 and %d", maxONUPageSize)
+			}
+			q.PageSize = *pageSize
+		}
+	}
+
+	for _, field := range strings.Split(c.Query("fields"), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			q.Fields = append(q.Fields, field)
+		}
+	}
+
+	return q, nil
+}
+
+func queryFloat(c *gin.Context, key string) (*float64, error) {
+	raw := strings.TrimSpace(c.Query(key))
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.ParseFloat(raw, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value: %s", key, raw)
+	}
+	return &value, nil
+}
+
+func queryInt(c *gin.Context, key string) (*int, error) {
+	raw := strings.TrimSpace(c.Query(key))
+	if raw == "" {
+		return nil, nil
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s value: %s", key, raw)
+	}
+	return &value, nil
+}
+
+// queryList splits a comma-separated, case-insensitive query value
+func queryList(c *gin.Context, key string) []string {
+	var values []string
+	for _, part := range strings.Split(c.Query(key), ",") {
+		if part = strings.ToLower(strings.TrimSpace(part)); part != "" {
+			values = append(values, part)
+		}
+	}
+	return values
+}
@@ -0,0 +1,76 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetOpticalTrends handles GET /api/v1/optics/trends
+// Optional: device_id, pon_id, all=true (include ONUs that are not flagged),
+// rate (dB per day), days (projection horizon), limit.
+func GetOpticalTrends(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := service.OpticalTrendFilter{
+			DeviceID: strings.TrimSpace(c.Query("device_id")),
+			PONID:    normalizePONID(c.Query("pon_id")),
+			All:      queryBool(c, "all"),
+		}
+		for key, target := range map[string]*float64{"rate": &filter.Rate, "days": &filter.Days} {
+			if raw := strings.TrimSpace(c.Query(key)); raw != "" {
+				parsed, err := strconv.ParseFloat(raw, 64)
+				if err != nil || parsed <= 0 {
+					response.BadRequest(c, "Invalid "+key+" value")
+					return
+				}
+				*target = parsed
+			}
+		}
+		if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				response.BadRequest(c, "Invalid limit value")
+				return
+			}
+			filter.Limit = parsed
+		}
+
+		report, err := service.NewOpticalTrendService(db, cfg).List(filter)
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, report, filter.DeviceID)
+	}
+}
+
+// AnalyzeOpticalTrends handles POST /api/v1/optics/trends/analyze
+// Recomputes the trends now instead of waiting for the next periodic run.
+func AnalyzeOpticalTrends(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !requireAdmin(c) {
+			c.Abort()
+			return
+		}
+
+		started := time.Now()
+		count, err := service.NewOpticalTrendService(db, cfg).Analyze(started)
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.SuccessWithMessage(c, "Optical trends updated", map[string]interface{}{
+			"onus":        count,
+			"duration_ms": time.Since(started).Milliseconds(),
+		})
+	}
+}
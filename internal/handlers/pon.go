@@ -1,6 +1,11 @@
 package handlers
 
 import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
 	"olt-api/internal/config"
 	"olt-api/internal/service"
 	"olt-api/pkg/response"
@@ -10,6 +15,8 @@ import (
 )
 
 // GetPONs handles GET /api/v1/devices/:device_id/pons
+// Optional: stats=true adds per-PON ONU aggregates, rx_threshold overrides the
+// low Rx threshold and sort=worst orders PONs by severity.
 func GetPONs(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		deviceID := c.Param("id")
@@ -21,12 +28,126 @@ func GetPONs(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 		deviceSvc := service.NewDeviceService(db, cfg)
 		ponSvc := service.NewPONService(db, cfg, deviceSvc)
 
-		pons, err := ponSvc.GetPONList(deviceID)
+		if !queryBool(c, "stats") {
+			pons, err := ponSvc.GetPONList(deviceID)
+			if err != nil {
+				response.InternalError(c, err.Error())
+				return
+			}
+			response.Success(c, pons, deviceID)
+			return
+		}
+
+		threshold := cfg.Optics.LowRxThreshold
+		if raw := strings.TrimSpace(c.Query("rx_threshold")); raw != "" {
+			parsed, err := strconv.ParseFloat(raw, 64)
+			if err != nil {
+				response.BadRequest(c, "Invalid rx_threshold value")
+				return
+			}
+			threshold = parsed
+		}
+
+		pons, err := ponSvc.GetPONListWithStats(deviceID, threshold)
 		if err != nil {
 			response.InternalError(c, err.Error())
 			return
 		}
+		if c.Query("sort") == "worst" {
+			sort.SliceStable(pons, func(i, j int) bool {
+				return service.PONSeverity(pons[i].Stats) > service.PONSeverity(pons[j].Stats)
+			})
+		}
 
 		response.Success(c, pons, deviceID)
 	}
 }
+
+// PONAction handles POST /api/v1/devices/:id/pons/:pon_id/action
+func PONAction(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		ponID := c.Param("pon_id")
+		if deviceID == "" || ponID == "" {
+			response.BadRequest(c, "Device ID and PON ID are required")
+			return
+		}
+		ponID = normalizePONID(ponID)
+
+		var req service.PONActionRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+
+		deviceSvc := service.NewDeviceService(db, cfg)
+		ponSvc := service.NewPONService(db, cfg, deviceSvc)
+
+		if err := ponSvc.PerformAction(deviceID, ponID, req.Action); err != nil {
+			switch {
+			case errors.Is(err, service.ErrNotConfigured):
+				response.Error(c, 501, err.Error())
+			case errors.Is(err, service.ErrInvalidInput):
+				response.BadRequest(c, err.Error())
+			case errors.Is(err, service.ErrNotFound):
+				response.NotFound(c, err.Error())
+			case errors.Is(err, service.ErrOLTRejected):
+				response.Error(c, 502, err.Error())
+			default:
+				response.InternalError(c, err.Error())
+			}
+			return
+		}
+
+		writeAuditLog(c, db, "pon.action."+strings.ToLower(req.Action), "pon", ponID, map[string]interface{}{
+			"device_id": deviceID,
+			"action":    req.Action,
+		})
+		response.SuccessWithMessage(c, "Action '"+req.Action+"' performed successfully", map[string]string{
+			"device_id": deviceID,
+			"pon_id":    ponID,
+			"action":    req.Action,
+		})
+	}
+}
+
+// GetPONCapacity handles GET /api/v1/devices/:id/pons/capacity
+func GetPONCapacity(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		if deviceID == "" {
+			response.BadRequest(c, "Device ID is required")
+			return
+		}
+
+		maxONUs := cfg.PON.MaxONUs
+		if raw := strings.TrimSpace(c.Query("max_onus")); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				response.BadRequest(c, "Invalid max_onus value")
+				return
+			}
+			maxONUs = parsed
+		}
+		threshold := cfg.PON.CapacityThreshold
+		if raw := strings.TrimSpace(c.Query("threshold")); raw != "" {
+			parsed, err := strconv.ParseFloat(raw, 64)
+			if err != nil || parsed < 0 || parsed > 100 {
+				response.BadRequest(c, "Invalid threshold value")
+				return
+			}
+			threshold = parsed
+		}
+
+		deviceSvc := service.NewDeviceService(db, cfg)
+		ponSvc := service.NewPONService(db, cfg, deviceSvc)
+
+		report, err := ponSvc.GetCapacity(deviceID, maxONUs, threshold)
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, report, deviceID)
+	}
+}
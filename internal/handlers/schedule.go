@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"strconv"
+	"strings"
+
+	"olt-api/internal/config"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func parseScheduleID(c *gin.Context) (uint, bool) {
+	idValue, err := strconv.ParseUint(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || idValue == 0 {
+		response.BadRequest(c, "Invalid schedule ID")
+		return 0, false
+	}
+	return uint(idValue), true
+}
+
+// ListSchedules handles GET /api/v1/schedules
+func ListSchedules(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		svc := service.NewScheduleService(db, cfg, service.NewDeviceService(db, cfg))
+		schedules, err := svc.List()
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, schedules, "")
+	}
+}
+
+// CreateSchedule handles POST /api/v1/schedules
+func CreateSchedule(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req service.ScheduleRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+		if req.ONUAction != nil {
+			normalizeBulkRequest(req.ONUAction)
+		}
+
+		svc := service.NewScheduleService(db, cfg, service.NewDeviceService(db, cfg))
+		schedule, err := svc.Create(&req, jobActorFromContext(c))
+		if err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "schedule.created", "schedule", strconv.FormatUint(uint64(schedule.ID), 10), map[string]interface{}{
+			"name":        schedule.Name,
+			"kind":        schedule.Kind,
+			"device_id":   schedule.DeviceID,
+			"cron":        schedule.Cron,
+			"next_run_at": schedule.NextRunAt,
+		})
+		response.Created(c, "Schedule created successfully", schedule)
+	}
+}
+
+// GetSchedule handles GET /api/v1/schedules/:id
+func GetSchedule(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseScheduleID(c)
+		if !ok {
+			return
+		}
+
+		svc := service.NewScheduleService(db, cfg, service.NewDeviceService(db, cfg))
+		schedule, err := svc.Get(id)
+		if err != nil {
+			response.NotFound(c, err.Error())
+			return
+		}
+
+		response.Success(c, schedule, schedule.DeviceID)
+	}
+}
+
+// DeleteSchedule handles DELETE /api/v1/schedules/:id
+// Only the creator or an admin can delete a schedule.
+func DeleteSchedule(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseScheduleID(c)
+		if !ok {
+			return
+		}
+
+		svc := service.NewScheduleService(db, cfg, service.NewDeviceService(db, cfg))
+		schedule, err := svc.Get(id)
+		if err != nil {
+			response.NotFound(c, err.Error())
+			return
+		}
+
+		actorID, _, role := actorFromContext(c)
+		if !strings.EqualFold(role, "admin") && schedule.CreatedByID != actorID {
+			response.Error(c, 403, "only the creator or an admin can delete this schedule")
+			return
+		}
+
+		if err := svc.Delete(id); err != nil {
+			response.NotFound(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "schedule.deleted", "schedule", strconv.FormatUint(uint64(id), 10), map[string]interface{}{
+			"name": schedule.Name,
+			"kind": schedule.Kind,
+		})
+		response.SuccessWithMessage(c, "Schedule deleted successfully", nil)
+	}
+}
+
+// ListScheduleRuns handles GET /api/v1/schedules/:id/runs
+func ListScheduleRuns(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseScheduleID(c)
+		if !ok {
+			return
+		}
+
+		limit := 50
+		if l := c.Query("limit"); l != "" {
+			if parsed, err := strconv.Atoi(l); err == nil && parsed > 0 {
+				limit = parsed
+			}
+		}
+
+		svc := service.NewScheduleService(db, cfg, service.NewDeviceService(db, cfg))
+		if _, err := svc.Get(id); err != nil {
+			response.NotFound(c, err.Error())
+			return
+		}
+
+		runs, err := svc.Runs(id, limit)
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, runs, "")
+	}
+}
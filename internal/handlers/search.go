@@ -0,0 +1,68 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"olt-api/internal/config"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// SearchONUs handles GET /api/v1/onus/search
+func SearchONUs(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		query := strings.TrimSpace(c.Query("q"))
+		if query == "" {
+			response.BadRequest(c, "Query parameter q is required")
+			return
+		}
+
+		req := service.ONUSearchRequest{
+			Query:    query,
+			DeviceID: c.Query("device_id"),
+			Regex:    queryBool(c, "regex"),
+			Live:     queryBool(c, "live"),
+			Tags:     service.ParseTagList(c.Query("tag")),
+		}
+		if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				response.BadRequest(c, "Invalid limit value")
+				return
+			}
+			req.Limit = parsed
+		}
+
+		deviceSvc := service.NewDeviceService(db, cfg)
+		searchSvc := service.NewSearchService(db, cfg, deviceSvc)
+
+		result, err := searchSvc.Search(req)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrNotFound):
+				response.NotFound(c, err.Error())
+			case errors.Is(err, service.ErrInvalidInput):
+				response.BadRequest(c, err.Error())
+			default:
+				response.InternalError(c, err.Error())
+			}
+			return
+		}
+
+		response.Success(c, result, req.DeviceID)
+	}
+}
+
+// queryBool reports whether a query flag is set to a true value (1, true, yes)
+func queryBool(c *gin.Context, key string) bool {
+	switch strings.ToLower(strings.TrimSpace(c.Query(key))) {
+	case "1", "true", "yes":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,164 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func parseSubscriberID(c *gin.Context) (uint, bool) {
+	idValue, err := strconv.ParseUint(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || idValue == 0 {
+		response.BadRequest(c, "Invalid subscriber ID")
+		return 0, false
+	}
+	return uint(idValue), true
+}
+
+// ListSubscribers handles GET /api/v1/subscribers
+func ListSubscribers(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := service.SubscriberFilter{
+			Query:    c.Query("q"),
+			Plan:     c.Query("plan"),
+			DeviceID: c.Query("device_id"),
+		}
+		if raw := strings.TrimSpace(c.Query("limit")); raw != "" {
+			parsed, err := strconv.Atoi(raw)
+			if err != nil || parsed <= 0 {
+				response.BadRequest(c, "Invalid limit value")
+				return
+			}
+			filter.Limit = parsed
+		}
+
+		svc := service.NewSubscriberService(db, cfg, service.NewDeviceService(db, cfg))
+		subscribers, err := svc.List(filter)
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, subscribers, "")
+	}
+}
+
+// CreateSubscriber handles POST /api/v1/subscribers
+func CreateSubscriber(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req database.SubscriberRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+
+		svc := service.NewSubscriberService(db, cfg, service.NewDeviceService(db, cfg))
+		subscriber, err := svc.Create(&req)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidInput) {
+				response.BadRequest(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "subscriber.created", "subscriber", strconv.FormatUint(uint64(subscriber.ID), 10), map[string]interface{}{
+			"account_number": subscriber.AccountNumber,
+			"device_id":      subscriber.DeviceID,
+			"mac":            subscriber.MAC,
+		})
+		response.Created(c, "Subscriber created successfully", subscriber)
+	}
+}
+
+// GetSubscriber handles GET /api/v1/subscribers/:id
+func GetSubscriber(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseSubscriberID(c)
+		if !ok {
+			return
+		}
+
+		svc := service.NewSubscriberService(db, cfg, service.NewDeviceService(db, cfg))
+		subscriber, err := svc.Get(id)
+		if err != nil {
+			if errors.Is(err, service.ErrNotFound) {
+				response.NotFound(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, subscriber, subscriber.DeviceID)
+	}
+}
+
+// UpdateSubscriber handles PUT /api/v1/subscribers/:id
+func UpdateSubscriber(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseSubscriberID(c)
+		if !ok {
+			return
+		}
+
+		var req database.SubscriberUpdateRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+
+		svc := service.NewSubscriberService(db, cfg, service.NewDeviceService(db, cfg))
+		subscriber, err := svc.Update(id, &req)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrNotFound):
+				response.NotFound(c, err.Error())
+			case errors.Is(err, service.ErrInvalidInput):
+				response.BadRequest(c, err.Error())
+			default:
+				response.InternalError(c, err.Error())
+			}
+			return
+		}
+
+		writeAuditLog(c, db, "subscriber.updated", "subscriber", strconv.FormatUint(uint64(subscriber.ID), 10), map[string]interface{}{
+			"account_number": subscriber.AccountNumber,
+			"device_id":      subscriber.DeviceID,
+			"mac":            subscriber.MAC,
+		})
+		response.SuccessWithMessage(c, "Subscriber updated successfully", subscriber)
+	}
+}
+
+// DeleteSubscriber handles DELETE /api/v1/subscribers/:id
+func DeleteSubscriber(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseSubscriberID(c)
+		if !ok {
+			return
+		}
+
+		svc := service.NewSubscriberService(db, cfg, service.NewDeviceService(db, cfg))
+		if err := svc.Delete(id); err != nil {
+			if errors.Is(err, service.ErrNotFound) {
+				response.NotFound(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "subscriber.deleted", "subscriber", strconv.FormatUint(uint64(id), 10), nil)
+		response.SuccessWithMessage(c, "Subscriber deleted successfully", nil)
+	}
+}
@@ -1,6 +1,8 @@
 package handlers
 
 import (
+	"errors"
+
 	"olt-api/internal/config"
 	"olt-api/internal/service"
 	"olt-api/pkg/response"
@@ -28,3 +30,42 @@ func GetSystemInfo(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
 		response.Success(c, sysInfo, id)
 	}
 }
+
+// GetSystemHistory handles GET /api/v1/devices/:id/system/history
+// Optional from, to (default: last 24 hours) and step (auto, raw, hour, day).
+func GetSystemHistory(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.Param("id")
+		if id == "" {
+			response.BadRequest(c, "Device ID is required")
+			return
+		}
+
+		from, to, ok := parseTimeRange(c)
+		if !ok {
+			return
+		}
+
+		step, err := service.ParseHistoryStep(c.Query("step"))
+		if err != nil {
+			response.BadRequest(c, err.Error())
+			return
+		}
+
+		svc := service.NewDeviceService(db, cfg)
+		history, err := service.NewSystemMetricsService(db, cfg, svc).History(id, from, to, step)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrInvalidRange):
+				response.BadRequest(c, err.Error())
+			case errors.Is(err, service.ErrNotFound):
+				response.NotFound(c, err.Error())
+			default:
+				response.InternalError(c, err.Error())
+			}
+			return
+		}
+
+		response.Success(c, history, id)
+	}
+}
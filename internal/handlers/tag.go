@@ -0,0 +1,282 @@
+package handlers
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+func parseTagID(c *gin.Context) (uint, bool) {
+	idValue, err := strconv.ParseUint(strings.TrimSpace(c.Param("id")), 10, 64)
+	if err != nil || idValue == 0 {
+		response.BadRequest(c, "Invalid tag ID")
+		return 0, false
+	}
+	return uint(idValue), true
+}
+
+// ListTags handles GET /api/v1/tags
+func ListTags(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tags, err := service.NewTagService(db).List()
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, tags, "")
+	}
+}
+
+// CreateTag handles POST /api/v1/tags
+func CreateTag(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		var req database.TagRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+
+		tag, err := service.NewTagService(db).Create(&req)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidInput) {
+				response.BadRequest(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "tag.created", "tag", strconv.FormatUint(uint64(tag.ID), 10), map[string]interface{}{
+			"name": tag.Name,
+		})
+		response.Created(c, "Tag created successfully", tag)
+	}
+}
+
+// UpdateTag handles PUT /api/v1/tags/:id
+func UpdateTag(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseTagID(c)
+		if !ok {
+			return
+		}
+
+		var req database.TagRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+
+		tag, err := service.NewTagService(db).Update(id, &req)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrNotFound):
+				response.NotFound(c, err.Error())
+			case errors.Is(err, service.ErrInvalidInput):
+				response.BadRequest(c, err.Error())
+			default:
+				response.InternalError(c, err.Error())
+			}
+			return
+		}
+
+		writeAuditLog(c, db, "tag.updated", "tag", strconv.FormatUint(uint64(tag.ID), 10), map[string]interface{}{
+			"name": tag.Name,
+		})
+		response.SuccessWithMessage(c, "Tag updated successfully", tag)
+	}
+}
+
+// DeleteTag handles DELETE /api/v1/tags/:id
+func DeleteTag(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id, ok := parseTagID(c)
+		if !ok {
+			return
+		}
+
+		tagSvc := service.NewTagService(db)
+		tag, err := tagSvc.Get(id)
+		if err != nil {
+			if errors.Is(err, service.ErrNotFound) {
+				response.NotFound(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+		if err := tagSvc.Delete(id); err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "tag.deleted", "tag", strconv.FormatUint(uint64(id), 10), map[string]interface{}{
+			"name": tag.Name,
+		})
+		response.SuccessWithMessage(c, "Tag deleted successfully", nil)
+	}
+}
+
+// AddDeviceTags handles POST /api/v1/devices/:id/tags
+func AddDeviceTags(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		if deviceID == "" {
+			response.BadRequest(c, "Device ID is required")
+			return
+		}
+
+		var req database.TagAssignRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+
+		tags, err := service.NewTagService(db).AddDeviceTags(deviceID, req.Tags)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrNotFound):
+				response.NotFound(c, err.Error())
+			case errors.Is(err, service.ErrInvalidInput):
+				response.BadRequest(c, err.Error())
+			default:
+				response.InternalError(c, err.Error())
+			}
+			return
+		}
+
+		writeAuditLog(c, db, "device.tag.added", "device", deviceID, map[string]interface{}{
+			"tags": req.Tags,
+		})
+		response.SuccessWithMessage(c, "Device tagged successfully", tags)
+	}
+}
+
+// RemoveDeviceTag handles DELETE /api/v1/devices/:id/tags/:tag
+func RemoveDeviceTag(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		tag := c.Param("tag")
+		if deviceID == "" || tag == "" {
+			response.BadRequest(c, "Device ID and tag are required")
+			return
+		}
+
+		if err := service.NewTagService(db).RemoveDeviceTag(deviceID, tag); err != nil {
+			if errors.Is(err, service.ErrNotFound) {
+				response.NotFound(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "device.tag.removed", "device", deviceID, map[string]interface{}{
+			"tag": service.NormalizeTagName(tag),
+		})
+		response.SuccessWithMessage(c, "Tag removed successfully", nil)
+	}
+}
+
+// AddONUTags handles POST /api/v1/devices/:id/onus/:onu_id/tags
+func AddONUTags(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		onuID := c.Param("onu_id")
+		if deviceID == "" || onuID == "" {
+			response.BadRequest(c, "Device ID and ONU ID are required")
+			return
+		}
+		onuID = normalizeONUID(onuID)
+
+		var req database.TagAssignRequest
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.BadRequest(c, "Invalid request: "+err.Error())
+			return
+		}
+
+		mac, err := service.NewInventoryService(db).MACForONU(deviceID, onuID)
+		if err != nil {
+			if errors.Is(err, service.ErrNotFound) {
+				response.NotFound(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		tags, err := service.NewTagService(db).AddONUTags(deviceID, mac, req.Tags)
+		if err != nil {
+			switch {
+			case errors.Is(err, service.ErrNotFound):
+				response.NotFound(c, err.Error())
+			case errors.Is(err, service.ErrInvalidInput):
+				response.BadRequest(c, err.Error())
+			default:
+				response.InternalError(c, err.Error())
+			}
+			return
+		}
+
+		writeAuditLog(c, db, "onu.tag.added", "onu", onuID, map[string]interface{}{
+			"device_id": deviceID,
+			"mac":       mac,
+			"tags":      req.Tags,
+		})
+		response.SuccessWithMessage(c, "ONU tagged successfully", map[string]interface{}{
+			"device_id": deviceID,
+			"onu_id":    onuID,
+			"mac":       mac,
+			"tags":      tags,
+		})
+	}
+}
+
+// RemoveONUTag handles DELETE /api/v1/devices/:id/onus/:onu_id/tags/:tag
+func RemoveONUTag(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		onuID := c.Param("onu_id")
+		tag := c.Param("tag")
+		if deviceID == "" || onuID == "" || tag == "" {
+			response.BadRequest(c, "Device ID, ONU ID and tag are required")
+			return
+		}
+		onuID = normalizeONUID(onuID)
+
+		mac, err := service.NewInventoryService(db).MACForONU(deviceID, onuID)
+		if err != nil {
+			if errors.Is(err, service.ErrNotFound) {
+				response.NotFound(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		if err := service.NewTagService(db).RemoveONUTag(deviceID, mac, tag); err != nil {
+			if errors.Is(err, service.ErrNotFound) {
+				response.NotFound(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		writeAuditLog(c, db, "onu.tag.removed", "onu", onuID, map[string]interface{}{
+			"device_id": deviceID,
+			"mac":       mac,
+			"tag":       service.NormalizeTagName(tag),
+		})
+		response.SuccessWithMessage(c, "Tag removed successfully", nil)
+	}
+}
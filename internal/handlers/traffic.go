@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+
+	"olt-api/internal/config"
+	"olt-api/internal/service"
+	"olt-api/pkg/response"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetONUTrafficRate handles GET /api/v1/devices/:id/onus/:onu_id/traffic/rate
+func GetONUTrafficRate(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		onuID := c.Param("onu_id")
+		if deviceID == "" || onuID == "" {
+			response.BadRequest(c, "Device ID and ONU ID are required")
+			return
+		}
+		onuID = normalizeONUID(onuID)
+
+		trafficSvc := service.NewTrafficService(db, cfg, service.NewDeviceService(db, cfg))
+		rate, err := trafficSvc.CurrentRate(deviceID, onuID)
+		if err != nil {
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, rate, deviceID)
+	}
+}
+
+// GetONUTrafficHistory handles GET /api/v1/devices/:id/onus/:onu_id/traffic/history
+func GetONUTrafficHistory(db *gorm.DB, cfg *config.Config) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		deviceID := c.Param("id")
+		onuID := c.Param("onu_id")
+		if deviceID == "" || onuID == "" {
+			response.BadRequest(c, "Device ID and ONU ID are required")
+			return
+		}
+		onuID = normalizeONUID(onuID)
+
+		from, to, ok := parseTimeRange(c)
+		if !ok {
+			return
+		}
+
+		trafficSvc := service.NewTrafficService(db, cfg, service.NewDeviceService(db, cfg))
+		history, err := trafficSvc.History(deviceID, onuID, from, to)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidRange) {
+				response.BadRequest(c, err.Error())
+				return
+			}
+			response.InternalError(c, err.Error())
+			return
+		}
+
+		response.Success(c, history, deviceID)
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"regexp"
 	"strings"
+	"time"
 )
 
 var (
@@ -30,15 +31,56 @@ type ONUResponse struct {
 	CTCVersion  string     `json:"ctc_version"`
 	IsActivated bool       `json:"is_activated"`
 	Metrics     ONUMetrics `json:"metrics"`
+
+	// Subscriber, Tags and Health are filled in by the service layer
+	Subscriber *ONUSubscriber `json:"subscriber,omitempty"`
+	Tags       []string       `json:"tags,omitempty"`
+	Health     *ONUHealth     `json:"health,omitempty"`
 }
 
-// ONUMetrics contains optical module metrics
+// ONUMetrics contains optical module metrics. Unreported lists the metrics
+// the OLT left empty, which read as 0.
 type ONUMetrics struct {
-	Temperature float64 `json:"temperature"`
-	Voltage     float64 `json:"voltage"`
-	Current     float64 `json:"current"`
-	TxPower     float64 `json:"tx_power"`
-	RxPower     float64 `json:"rx_power"`
+	Temperature float64  `json:"temperature"`
+	Voltage     float64  `json:"voltage"`
+	Current     float64  `json:"current"`
+	TxPower     float64  `json:"tx_power"`
+	RxPower     float64  `json:"rx_power"`
+	Unreported  []string `json:"unreported,omitempty"`
+}
+
+// ONUSubscriber is the customer record linked to an ONU by MAC
+type ONUSubscriber struct {
+	ID            uint                   `json:"id"`
+	AccountNumber string                 `json:"account_number"`
+	Name          string                 `json:"name"`
+	Address       string                 `json:"address,omitempty"`
+	Phone         string                 `json:"phone,omitempty"`
+	Plan          string                 `json:"plan,omitempty"`
+	Notes         string                 `json:"notes,omitempty"`
+	Attributes    map[string]interface{} `json:"attributes,omitempty"`
+	DeviceID      string                 `json:"device_id,omitempty"`
+	MAC           string                 `json:"mac,omitempty"`
+	CreatedAt     time.Time              `json:"created_at"`
+	UpdatedAt     time.Time              `json:"updated_at"`
+}
+
+// ONUHealth is the optical health score (0-100) and status (good, warning,
+// critical) of an online ONU
+type ONUHealth struct {
+	Score   int              `json:"score"`
+	Status  string           `json:"status"`
+	Profile string           `json:"profile,omitempty"` // threshold profile applied, empty for the defaults
+	Issues  []ONUHealthIssue `json:"issues,omitempty"`
+}
+
+// ONUHealthIssue is one metric outside its thresholds
+type ONUHealthIssue struct {
+	Metric    string  `json:"metric"`
+	Severity  string  `json:"severity"`
+	Value     float64 `json:"value"`
+	Threshold float64 `json:"threshold"`
+	Message   string  `json:"message"`
 }
 
 // ParseONUList parses /onuOverview.asp?oltponno=X
@@ -59,6 +101,7 @@ func (p *Parser) ParseONUList(html string) ([]ONUResponse, error) {
 
 	var best []ONUResponse
 	var errs []error
+	emptyTable := false
 
 	for _, c := range candidates {
 		data, err := p.ExtractJSArray(html, c.varName)
@@ -66,6 +109,11 @@ func (p *Parser) ParseONUList(html string) ([]ONUResponse, error) {
 			errs = append(errs, err)
 			continue
 		}
+		if len(data) == 0 {
+			// The table is present but the PON has no ONUs
+			emptyTable = true
+			continue
+		}
 		if len(data) < c.minFields {
 			errs = append(errs, fmt.Errorf("variable '%s' has insufficient fields: %d", c.varName, len(data)))
 			continue
@@ -86,6 +134,9 @@ func (p *Parser) ParseONUList(html string) ([]ONUResponse, error) {
 	if len(best) > 0 {
 		return best, nil
 	}
+	if emptyTable {
+		return []ONUResponse{}, nil
+	}
 	if len(errs) > 0 {
 		return nil, fmt.Errorf("unable to parse ONU list: %v", errs)
 	}
@@ -113,13 +164,7 @@ func (p *Parser) parseONUList16(data []string) []ONUResponse {
 			CTCVersion:  chunk[8],
 			IsActivated: p.MapActivateStatus(chunk[9]),
 			Distance:    p.CalculateDistance(chunk[10]),
-			Metrics: ONUMetrics{
-				Temperature: p.ParseFloat(chunk[11]),
-				Voltage:     p.ParseFloat(chunk[12]),
-				Current:     p.ParseFloat(chunk[13]),
-				TxPower:     p.ParseFloat(chunk[14]),
-				RxPower:     p.ParseFloat(chunk[15]),
-			},
+			Metrics:     p.parseMetrics(chunk[11], chunk[12], chunk[13], chunk[14], chunk[15]),
 		})
 		i += 16
 	}
@@ -144,13 +189,7 @@ func (p *Parser) parseONUList13(data []string) []ONUResponse {
 			ChipID:     chunk[5],
 			Ports:      p.ParseInt(chunk[6]),
 			Distance:   p.ParseInt(chunk[12]),
-			Metrics: ONUMetrics{
-				Temperature: p.ParseFloat(chunk[7]),
-				Voltage:     p.ParseFloat(chunk[8]),
-				Current:     p.ParseFloat(chunk[9]),
-				TxPower:     p.ParseFloat(chunk[10]),
-				RxPower:     p.ParseFloat(chunk[11]),
-			},
+			Metrics:    p.parseMetrics(chunk[7], chunk[8], chunk[9], chunk[10], chunk[11]),
 			// Fields not present in this format
 			CTCStatus:   "",
 			CTCVersion:  "",
@@ -161,6 +200,40 @@ func (p *Parser) parseONUList13(data []string) []ONUResponse {
 	return onus
 }
 
+// parseMetrics parses the optical readings of an ONU list row
+func (p *Parser) parseMetrics(temperature, voltage, current, txPower, rxPower string) ONUMetrics {
+	var metrics ONUMetrics
+	metrics.Unreported = p.parseReadings([]reading{
+		{"temperature", temperature, &metrics.Temperature},
+		{"voltage", voltage, &metrics.Voltage},
+		{"current", current, &metrics.Current},
+		{"tx_power", txPower, &metrics.TxPower},
+		{"rx_power", rxPower, &metrics.RxPower},
+	})
+	return metrics
+}
+
+// reading is a raw value parsed into target
+type reading struct {
+	Name   string
+	Raw    string
+	Target *float64
+}
+
+// parseReadings parses every reading and returns the names of those that
+// were not reported
+func (p *Parser) parseReadings(readings []reading) []string {
+	var unreported []string
+	for _, r := range readings {
+		value, ok := p.ParseReading(r.Raw)
+		*r.Target = value
+		if !ok {
+			unreported = append(unreported, r.Name)
+		}
+	}
+	return unreported
+}
+
 // ONUDetailResponse for detailed ONU info
 type ONUDetailResponse struct {
 	ONUID         string             `json:"onu_id"`
@@ -175,15 +248,24 @@ type ONUDetailResponse struct {
 	LastOfftime   string             `json:"last_offtime"`
 	IsActivated   bool               `json:"is_activated"`
 	OpticalModule *OpticalModuleInfo `json:"optical_module,omitempty"`
+
+	// Distance comes from the PON's ONU list, Subscriber is the linked
+	// customer record and Health the optical health, all filled in by the
+	// service layer
+	Distance   int            `json:"distance_meters,omitempty"`
+	Subscriber *ONUSubscriber `json:"subscriber,omitempty"`
+	Health     *ONUHealth     `json:"health,omitempty"`
 }
 
-// OpticalModuleInfo contains detailed optical module data
+// OpticalModuleInfo contains detailed optical module data. Unreported lists
+// the readings the OLT left empty, which read as 0.
 type OpticalModuleInfo struct {
-	Temperature float64 `json:"temperature"`
-	Voltage     float64 `json:"voltage"`
-	BiasCurrent float64 `json:"bias_current"`
-	TxPower     float64 `json:"tx_power"`
-	RxPower     float64 `json:"rx_power"`
+	Temperature float64  `json:"temperature"`
+	Voltage     float64  `json:"voltage"`
+	BiasCurrent float64  `json:"bias_current"`
+	TxPower     float64  `json:"tx_power"`
+	RxPower     float64  `json:"rx_power"`
+	Unreported  []string `json:"unreported,omitempty"`
 }
 
 // ParseONUDetail parses /onuConfig.asp?onuno=X&oltponno=Y
@@ -231,13 +313,15 @@ func (p *Parser) ParseONUDetail(html string) (*ONUDetailResponse, error) {
 		if onuOpm[0] != "" {
 			detail.ONUID = onuOpm[0]
 		}
-		detail.OpticalModule = &OpticalModuleInfo{
-			Temperature: p.ParseFloat(onuOpm[1]),
-			Voltage:     p.ParseFloat(onuOpm[2]),
-			BiasCurrent: p.ParseFloat(onuOpm[3]),
-			TxPower:     p.ParseFloat(onuOpm[4]),
-			RxPower:     p.ParseFloat(onuOpm[5]),
-		}
+		module := &OpticalModuleInfo{}
+		module.Unreported = p.parseReadings([]reading{
+			{"temperature", onuOpm[1], &module.Temperature},
+			{"voltage", onuOpm[2], &module.Voltage},
+			{"bias_current", onuOpm[3], &module.BiasCurrent},
+			{"tx_power", onuOpm[4], &module.TxPower},
+			{"rx_power", onuOpm[5], &module.RxPower},
+		})
+		detail.OpticalModule = module
 	}
 
 	return detail, nil
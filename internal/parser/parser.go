@@ -263,6 +263,20 @@ func (p *Parser) ParseFloat(s string) float64 {
 	return f
 }
 
+// ParseReading converts a metric reading to float64 and reports whether the
+// OLT reported it. Empty, N/A, -- and unparsable values are not reported.
+func (p *Parser) ParseReading(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "N/A" || s == "--" {
+		return 0, false
+	}
+	f, err := strconv.ParseFloat(strings.ReplaceAll(s, ",", ""), 64)
+	if err != nil {
+		return 0, false
+	}
+	return f, true
+}
+
 // ParseInt safely converts string to int
 func (p *Parser) ParseInt(s string) int {
 	s = strings.TrimSpace(s)
@@ -7,6 +7,26 @@ type PONResponse struct {
 	PONID  string `json:"pon_id"`
 	FullID string `json:"full_id"` // Original format (e.g., "0/1")
 	Info   string `json:"info"`
+
+	// Stats is filled in by the service layer when requested
+	Stats *PONStats `json:"stats,omitempty"`
+}
+
+// PONStats aggregates the ONUs of one PON. Rx power figures only consider
+// online ONUs and are nil when none reported a reading.
+type PONStats struct {
+	Total       int      `json:"total"`
+	Online      int      `json:"online"`
+	Offline     int      `json:"offline"`
+	LOS         int      `json:"los"`
+	PowerOff    int      `json:"poweroff"`
+	RxMin       *float64 `json:"rx_min"`
+	RxAvg       *float64 `json:"rx_avg"`
+	RxMax       *float64 `json:"rx_max"`
+	RxThreshold float64  `json:"rx_threshold"`
+	LowRx       int      `json:"low_rx"`
+	MaxDistance int      `json:"max_distance_meters"`
+	Error       string   `json:"error,omitempty"`
 }
 
 // ParsePONList parses /onuOverviewPonList.asp
@@ -1,6 +1,18 @@
 package parser
 
-import "fmt"
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	runTimeClock = regexp.MustCompile(`(\d+):(\d{1,2})(?::(\d{1,2}))?`)
+	runTimeUnit  = regexp.MustCompile(`(\d+)\s*(days?|d|hours?|hrs?|h|minutes?|mins?|m|seconds?|secs?|s|天|小时|时|分钟|分|秒)`)
+)
 
 // SystemInfoResponse represents parsed system information
 type SystemInfoResponse struct {
@@ -13,6 +25,7 @@ type SystemInfoResponse struct {
 	MACAddress        string  `json:"mac_address"`
 	IPAddress         string  `json:"ip_address"`
 	RunTime           string  `json:"run_time"`
+	UptimeSeconds     *int64  `json:"uptime_seconds,omitempty"` // RunTime in seconds, when it could be parsed
 	HardwareVersion   string  `json:"hardware_version"`
 	SerialNumber      string  `json:"serial_number"`
 	CPUUsage          float64 `json:"cpu_usage"`
@@ -34,7 +47,7 @@ func (p *Parser) ParseSystemInfo(html string) (*SystemInfoResponse, error) {
 		return nil, fmt.Errorf("incomplete sysInfo data: got %d fields, expected 13", len(data))
 	}
 
-	return &SystemInfoResponse{
+	info := &SystemInfoResponse{
 		SystemName:        data[0],
 		SystemDescription: data[1],
 		SystemLocation:    data[2],
@@ -48,5 +61,71 @@ func (p *Parser) ParseSystemInfo(html string) (*SystemInfoResponse, error) {
 		SerialNumber:      data[10],
 		CPUUsage:          p.ParseFloat(data[11]),
 		MemoryUsage:       p.ParseFloat(data[12]),
-	}, nil
+	}
+	if uptime, ok := ParseRunTime(info.RunTime); ok {
+		seconds := int64(uptime.Seconds())
+		info.UptimeSeconds = &seconds
+	}
+	return info, nil
+}
+
+// ParseRunTime converts an OLT run time such as "12 days 03:04:05",
+// "3d 4h 5m", "1 Day 2 Hour 3 Min 4 Sec" or a plain number of seconds.
+// Clock minutes or seconds of 60 and above, and totals that overflow a
+// Duration, are rejected.
+func ParseRunTime(raw string) (time.Duration, bool) {
+	s := strings.ToLower(strings.TrimSpace(raw))
+	if s == "" {
+		return 0, false
+	}
+
+	var total time.Duration
+	add := func(digits string, unit time.Duration) bool {
+		if digits == "" {
+			return true
+		}
+		value, err := strconv.ParseInt(digits, 10, 64)
+		if err != nil || value > int64(math.MaxInt64-total)/int64(unit) {
+			return false
+		}
+		total += time.Duration(value) * unit
+		return true
+	}
+
+	if strings.Trim(s, "0123456789") == "" {
+		if !add(s, time.Second) {
+			return 0, false
+		}
+		return total, true
+	}
+
+	found := false
+	if match := runTimeClock.FindStringSubmatch(s); match != nil {
+		minutes, _ := strconv.Atoi(match[2])
+		seconds, _ := strconv.Atoi(match[3])
+		if minutes >= 60 || seconds >= 60 {
+			return 0, false
+		}
+		if !add(match[1], time.Hour) || !add(match[2], time.Minute) || !add(match[3], time.Second) {
+			return 0, false
+		}
+		s = strings.Replace(s, match[0], " ", 1)
+		found = true
+	}
+	for _, match := range runTimeUnit.FindAllStringSubmatch(s, -1) {
+		unit := time.Second
+		switch {
+		case strings.HasPrefix(match[2], "d"), match[2] == "天":
+			unit = 24 * time.Hour
+		case strings.HasPrefix(match[2], "h"), match[2] == "小时", match[2] == "时":
+			unit = time.Hour
+		case strings.HasPrefix(match[2], "m"), strings.HasPrefix(match[2], "分"):
+			unit = time.Minute
+		}
+		if !add(match[1], unit) {
+			return 0, false
+		}
+		found = true
+	}
+	return total, found
 }
@@ -0,0 +1,41 @@
+package parser
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseRunTime(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want time.Duration
+		ok   bool
+	}{
+		{"12 days 03:04:05", 12*24*time.Hour + 3*time.Hour + 4*time.Minute + 5*time.Second, true},
+		{"1 day 00:00:01", 24*time.Hour + time.Second, true},
+		{"0 days 00:10:00", 10 * time.Minute, true},
+		{"03:04:05", 3*time.Hour + 4*time.Minute + 5*time.Second, true},
+		{"00:00:00", 0, true},
+		{"100:30:00", 100*time.Hour + 30*time.Minute, true},
+		{"3d 4h 5m", 3*24*time.Hour + 4*time.Hour + 5*time.Minute, true},
+		{"1 Day 2 Hour 3 Min 4 Sec", 26*time.Hour + 3*time.Minute + 4*time.Second, true},
+		{"3600", time.Hour, true},
+		{"  7 days 01:00:00  ", 7*24*time.Hour + time.Hour, true},
+		{"", 0, false},
+		{"unknown", 0, false},
+		{"N/A", 0, false},
+		{"-5", 0, false},
+		{"12 days 03:61:05", 0, false},
+		{"03:04:75", 0, false},
+		{"99999999999999999999", 0, false},
+		{"999999999999 days", 0, false},
+		{"106751 days 23:00:00", 106751*24*time.Hour + 23*time.Hour, true},
+		{"106751 days 23:59:59", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := ParseRunTime(tt.raw)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("ParseRunTime(%q) = %v, %v; want %v, %v", tt.raw, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -15,6 +15,7 @@ type Client struct {
 	baseURL    string
 	username   string
 	password   string
+	limiter    *DeviceLimiter
 }
 
 // NewClient creates HTTP client with connection pooling
@@ -38,8 +39,24 @@ func NewClient(baseURL, username, password string, timeout time.Duration) *Clien
 	}
 }
 
+// SetLimiter attaches a per-device rate limiter to the client
+func (c *Client) SetLimiter(limiter *DeviceLimiter) {
+	c.limiter = limiter
+}
+
+// acquire takes a slot of the device limiter and returns its release func
+func (c *Client) acquire() func() {
+	if c.limiter == nil {
+		return func() {}
+	}
+	c.limiter.Acquire()
+	return c.limiter.Release
+}
+
 // Get performs GET request to the OLT device
 func (c *Client) Get(endpoint string, params map[string]string) (string, error) {
+	defer c.acquire()()
+
 	fullURL := c.baseURL + endpoint
 
 	req, err := http.NewRequest("GET", fullURL, nil)
@@ -80,6 +97,8 @@ func (c *Client) Get(endpoint string, params map[string]string) (string, error)
 
 // Post performs POST request to the OLT device
 func (c *Client) Post(endpoint string, formData map[string]string) (string, error) {
+	defer c.acquire()()
+
 	fullURL := c.baseURL + endpoint
 
 	// Build form data
@@ -114,6 +133,8 @@ func (c *Client) Post(endpoint string, formData map[string]string) (string, erro
 
 // CheckConnection tests if the OLT device is reachable
 func (c *Client) CheckConnection() error {
+	defer c.acquire()()
+
 	req, err := http.NewRequest("GET", c.baseURL, nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
@@ -0,0 +1,68 @@
+package scraper
+
+import (
+	"sync"
+	"time"
+)
+
+// DeviceLimiter bounds concurrent requests and request spacing for one OLT.
+// Embedded OLT web servers fall over quickly when hammered, so every client
+// built for the same device shares a single limiter.
+type DeviceLimiter struct {
+	slots    chan struct{}
+	interval time.Duration
+	mu       sync.Mutex
+	next     time.Time
+}
+
+var (
+	limitersMu sync.Mutex
+	limiters   = map[string]*DeviceLimiter{}
+)
+
+// LimiterFor returns the shared limiter for a device key, creating it on first use
+func LimiterFor(key string, concurrency int, interval time.Duration) *DeviceLimiter {
+	limitersMu.Lock()
+	defer limitersMu.Unlock()
+
+	if limiter, ok := limiters[key]; ok {
+		return limiter
+	}
+
+	if concurrency <= 0 {
+		concurrency = 4 // default
+	}
+	limiter := &DeviceLimiter{
+		slots:    make(chan struct{}, concurrency),
+		interval: interval,
+	}
+	limiters[key] = limiter
+	return limiter
+}
+
+// Acquire blocks until a request slot is free and the minimum spacing has elapsed
+func (l *DeviceLimiter) Acquire() {
+	l.slots <- struct{}{}
+
+	if l.interval <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	wait := l.next.Sub(now)
+	if wait < 0 {
+		wait = 0
+	}
+	l.next = now.Add(wait + l.interval)
+	l.mu.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+}
+
+// Release frees a request slot
+func (l *DeviceLimiter) Release() {
+	<-l.slots
+}
@@ -0,0 +1,786 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+	"olt-api/internal/parser"
+
+	"gorm.io/gorm"
+)
+
+// Alert rule types
+const (
+	AlertONUOffline        = "onu_offline"
+	AlertONURxLow          = "onu_rx_low"
+	AlertONUTempHigh       = "onu_temp_high"
+	AlertONUFlapping       = "onu_flapping"
+	AlertPONONUsDown       = "pon_onus_down"
+	AlertDeviceUnreachable = "device_unreachable"
+	AlertDeviceCPUHigh     = "device_cpu_high"
+	AlertDeviceMemoryHigh  = "device_memory_high"
+)
+
+// Alert statuses
+const (
+	AlertStatusPending  = "pending"
+	AlertStatusFiring   = "firing"
+	AlertStatusResolved = "resolved"
+)
+
+var alertSeverities = []string{"info", "warning", "critical"}
+
+// defaultFlapWindow is the look-back of onu_flapping rules without a window
+const defaultFlapWindow = "6h"
+
+// AlertFilter narrows an alert listing. Status "active" (the default) matches
+// pending and firing alerts; "all" matches everything.
+type AlertFilter struct {
+	Status   string
+	DeviceID string
+	Severity string
+	RuleID   uint
+	Limit    int
+}
+
+// AlertSnapshot is the data collected from one device that rules are
+// evaluated against. ONUs is only meaningful when ONUsKnown is set; ONUs on
+// FailedPONs are treated as unknown. Flaps is filled in by Evaluate.
+type AlertSnapshot struct {
+	DeviceID   string
+	Reachable  bool
+	ReachError string
+	System     *parser.SystemInfoResponse
+	ONUs       []parser.ONUResponse
+	ONUsKnown  bool
+	FailedPONs map[string]bool
+	Flaps      map[string]map[string]int // flap counts per ONU, keyed by rule window
+}
+
+// alertTransition is an alert that fired or resolved during an evaluation
+type alertTransition struct {
+	Alert database.Alert
+	Event string
+}
+
+// alertViolation is one subject matching a rule condition
+type alertViolation struct {
+	PONID   string
+	ONUID   string
+	Value   float64
+	Message string
+}
+
+// AlertService manages alert rules and evaluates them into alerts
+type AlertService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+// NewAlertService creates a new AlertService
+func NewAlertService(db *gorm.DB, cfg *config.Config) *AlertService {
+	return &AlertService{db: db, cfg: cfg}
+}
+
+// defaultThreshold returns the threshold used when a rule omits one
+func (s *AlertService) defaultThreshold(ruleType string) float64 {
+	switch ruleType {
+	case AlertONURxLow:
+		return s.cfg.Optics.LowRxThreshold
+	case AlertONUTempHigh:
+		return 70
+	case AlertONUFlapping:
+		return 3
+	case AlertPONONUsDown:
+		return 5
+	case AlertDeviceCPUHigh, AlertDeviceMemoryHigh:
+		return 90
+	}
+	return 0
+}
+
+// NeedsSystemInfo reports whether any enabled rule for the device uses
+// system information, so callers can skip fetching it otherwise
+func (s *AlertService) NeedsSystemInfo(deviceID string) bool {
+	var count int64
+	s.db.Model(&database.AlertRule{}).
+		Where("enabled = ? AND type IN ? AND (device_id = '' OR device_id = ?)",
+			true, []string{AlertDeviceCPUHigh, AlertDeviceMemoryHigh}, deviceID).
+		Count(&count)
+	return count > 0
+}
+
+// ListRules returns all alert rules
+func (s *AlertService) ListRules() ([]database.AlertRule, error) {
+	var rules []database.AlertRule
+	if err := s.db.Order("id ASC").Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch alert rules: %w", err)
+	}
+	return rules, nil
+}
+
+// GetRule returns an alert rule by ID
+func (s *AlertService) GetRule(id uint) (*database.AlertRule, error) {
+	var rule database.AlertRule
+	if err := s.db.First(&rule, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("alert rule '%d' %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch alert rule: %w", err)
+	}
+	return &rule, nil
+}
+
+// CreateRule validates and stores a new alert rule
+func (s *AlertService) CreateRule(req *database.AlertRuleRequest, createdBy string) (*database.AlertRule, error) {
+	rule := &database.AlertRule{Enabled: true, CreatedBy: createdBy}
+	if err := s.applyRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	rule.CreatedAt = now
+	rule.UpdatedAt = now
+	if err := s.db.Create(rule).Error; err != nil {
+		return nil, fmt.Errorf("failed to create alert rule: %w", err)
+	}
+	return rule, nil
+}
+
+// UpdateRule replaces a rule's definition. Open alerts of the rule are
+// closed, since they were raised under the old definition.
+func (s *AlertService) UpdateRule(id uint, req *database.AlertRuleRequest) (*database.AlertRule, error) {
+	rule, err := s.GetRule(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRuleRequest(rule, req); err != nil {
+		return nil, err
+	}
+
+	rule.UpdatedAt = time.Now()
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(rule).Error; err != nil {
+			return fmt.Errorf("failed to update alert rule: %w", err)
+		}
+		return closeRuleAlerts(tx, rule.ID, rule.UpdatedAt)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// DeleteRule removes a rule and closes its open alerts
+func (s *AlertService) DeleteRule(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := closeRuleAlerts(tx, id, time.Now()); err != nil {
+			return err
+		}
+		if err := tx.Delete(&database.AlertRule{}, id).Error; err != nil {
+			return fmt.Errorf("failed to delete alert rule: %w", err)
+		}
+		return nil
+	})
+}
+
+func (s *AlertService) applyRuleRequest(rule *database.AlertRule, req *database.AlertRuleRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return invalidf("name is required")
+	}
+	ruleType := strings.ToLower(strings.TrimSpace(req.Type))
+	switch ruleType {
+	case AlertONUOffline, AlertONURxLow, AlertONUTempHigh, AlertONUFlapping, AlertPONONUsDown,
+		AlertDeviceUnreachable, AlertDeviceCPUHigh, AlertDeviceMemoryHigh:
+	default:
+		return invalidf("unsupported rule type '%s'", req.Type)
+	}
+
+	severity := strings.ToLower(strings.TrimSpace(req.Severity))
+	if severity == "" {
+		severity = "warning"
+	}
+	if !containsString(alertSeverities, severity) {
+		return invalidf("invalid severity '%s' (use info, warning or critical)", req.Severity)
+	}
+
+	forValue := strings.TrimSpace(req.For)
+	if forValue != "" {
+		duration, err := time.ParseDuration(forValue)
+		if err != nil || duration < 0 {
+			return invalidf("invalid for duration '%s'", req.For)
+		}
+	}
+
+	window := strings.TrimSpace(req.Window)
+	if window != "" && ruleType != AlertONUFlapping {
+		return invalidf("window only applies to onu_flapping rules")
+	}
+	if ruleType == AlertONUFlapping {
+		if window == "" {
+			window = defaultFlapWindow
+		}
+		duration, err := time.ParseDuration(window)
+		if err != nil || duration <= 0 {
+			return invalidf("invalid window duration '%s'", req.Window)
+		}
+		if limit := NewFlapService(s.db, s.cfg).MaxWindow(); duration > limit {
+			return invalidf("window cannot exceed raw ONU log retention (%s)", limit)
+		}
+	}
+
+	deviceID := strings.TrimSpace(req.DeviceID)
+	if deviceID != "" {
+		var count int64
+		if err := s.db.Model(&database.Device{}).Where("id = ?", deviceID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check device: %w", err)
+		}
+		if count == 0 {
+			return invalidf("device '%s' not found", deviceID)
+		}
+	}
+	ponID := strings.TrimSpace(req.PONID)
+	if _, err := strconv.Atoi(ponID); err == nil {
+		ponID = "0/" + ponID
+	}
+	if ponID != "" && !strings.HasPrefix(ruleType, "onu_") && ruleType != AlertPONONUsDown {
+		return invalidf("pon_id only applies to ONU and PON rules")
+	}
+
+	threshold := s.defaultThreshold(ruleType)
+	if req.Threshold != nil {
+		threshold = *req.Threshold
+	}
+
+	escalateAfter := strings.TrimSpace(req.EscalateAfter)
+	if (escalateAfter == "") != (req.EscalateChannelID == 0) {
+		return invalidf("escalate_after and escalate_channel_id must be set together")
+	}
+	if escalateAfter != "" {
+		duration, err := time.ParseDuration(escalateAfter)
+		if err != nil || duration <= 0 {
+			return invalidf("invalid escalate_after duration '%s'", req.EscalateAfter)
+		}
+		var count int64
+		if err := s.db.Model(&database.NotificationChannel{}).Where("id = ?", req.EscalateChannelID).Count(&count).Error; err != nil {
+			return fmt.Errorf("failed to check escalation channel: %w", err)
+		}
+		if count == 0 {
+			return invalidf("escalation channel '%d' not found", req.EscalateChannelID)
+		}
+	}
+
+	rule.Name = name
+	rule.Type = ruleType
+	rule.DeviceID = deviceID
+	rule.PONID = ponID
+	rule.Threshold = threshold
+	rule.For = forValue
+	rule.Window = window
+	rule.Severity = severity
+	rule.Description = strings.TrimSpace(req.Description)
+	rule.EscalateAfter = escalateAfter
+	rule.EscalateChannelID = req.EscalateChannelID
+	if req.Enabled != nil {
+		rule.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+// closeRuleAlerts resolves firing alerts of a rule and drops pending ones
+func closeRuleAlerts(tx *gorm.DB, ruleID uint, now time.Time) error {
+	if err := tx.Where("rule_id = ? AND status = ?", ruleID, AlertStatusPending).
+		Delete(&database.Alert{}).Error; err != nil {
+		return fmt.Errorf("failed to drop pending alerts: %w", err)
+	}
+	if err := tx.Model(&database.Alert{}).
+		Where("rule_id = ? AND status = ?", ruleID, AlertStatusFiring).
+		Updates(map[string]interface{}{"status": AlertStatusResolved, "resolved_at": now, "updated_at": now}).Error; err != nil {
+		return fmt.Errorf("failed to resolve alerts: %w", err)
+	}
+	return nil
+}
+
+// ListAlerts returns alerts, newest first
+func (s *AlertService) ListAlerts(filter AlertFilter) ([]database.Alert, error) {
+	query := s.db.Model(&database.Alert{})
+	switch filter.Status {
+	case "", "active":
+		query = query.Where("status IN ?", []string{AlertStatusPending, AlertStatusFiring})
+	case "all":
+	case AlertStatusPending, AlertStatusFiring, AlertStatusResolved:
+		query = query.Where("status = ?", filter.Status)
+	default:
+		return nil, invalidf("invalid status '%s'", filter.Status)
+	}
+	if filter.DeviceID != "" {
+		query = query.Where("device_id = ?", filter.DeviceID)
+	}
+	if filter.Severity != "" {
+		query = query.Where("severity = ?", filter.Severity)
+	}
+	if filter.RuleID > 0 {
+		query = query.Where("rule_id = ?", filter.RuleID)
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+
+	var alerts []database.Alert
+	if err := query.Order("started_at DESC, id DESC").Limit(limit).Find(&alerts).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch alerts: %w", err)
+	}
+
+	silences, err := s.activeSilences(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	for i := range alerts {
+		alerts[i].Silenced = alerts[i].Status != AlertStatusResolved && silenced(silences, &alerts[i])
+	}
+	return alerts, nil
+}
+
+// GetAlert returns an alert by ID
+func (s *AlertService) GetAlert(id uint) (*database.Alert, error) {
+	var alert database.Alert
+	if err := s.db.First(&alert, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("alert '%d' %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch alert: %w", err)
+	}
+
+	if alert.Status != AlertStatusResolved {
+		silences, err := s.activeSilences(time.Now())
+		if err != nil {
+			return nil, err
+		}
+		alert.Silenced = silenced(silences, &alert)
+	}
+	return &alert, nil
+}
+
+// Acknowledge marks an open alert as handled, which stops its escalation
+func (s *AlertService) Acknowledge(id uint, by, comment string) (*database.Alert, error) {
+	alert, err := s.GetAlert(id)
+	if err != nil {
+		return nil, err
+	}
+	if alert.Status == AlertStatusResolved {
+		return nil, conflictf("alert '%d' is already resolved", id)
+	}
+	if alert.AckedAt != nil {
+		return nil, conflictf("alert '%d' was already acknowledged by %s", id, alert.AckedBy)
+	}
+
+	now := time.Now()
+	alert.AckedAt = &now
+	alert.AckedBy = by
+	alert.AckComment = strings.TrimSpace(comment)
+	if err := s.db.Model(alert).Updates(map[string]interface{}{
+		"acked_at":    alert.AckedAt,
+		"acked_by":    alert.AckedBy,
+		"ack_comment": alert.AckComment,
+	}).Error; err != nil {
+		return nil, fmt.Errorf("failed to acknowledge alert: %w", err)
+	}
+	return alert, nil
+}
+
+// Escalate queues an escalation for every firing alert left unacknowledged
+// longer than its rule's escalate_after. Each alert escalates once; silenced
+// alerts are skipped.
+func (s *AlertService) Escalate(now time.Time) error {
+	var rules []database.AlertRule
+	if err := s.db.Where("escalate_channel_id > 0 AND escalate_after <> ''").Find(&rules).Error; err != nil {
+		return fmt.Errorf("failed to load escalation rules: %w", err)
+	}
+	if len(rules) == 0 {
+		return nil
+	}
+	byID := make(map[uint]*database.AlertRule, len(rules))
+	ruleIDs := make([]uint, 0, len(rules))
+	for i := range rules {
+		byID[rules[i].ID] = &rules[i]
+		ruleIDs = append(ruleIDs, rules[i].ID)
+	}
+
+	var alerts []database.Alert
+	if err := s.db.Where("status = ? AND acked_at IS NULL AND escalated_at IS NULL AND rule_id IN ?",
+		AlertStatusFiring, ruleIDs).Find(&alerts).Error; err != nil {
+		return fmt.Errorf("failed to load unacknowledged alerts: %w", err)
+	}
+	if len(alerts) == 0 {
+		return nil
+	}
+	silences, err := s.activeSilences(now)
+	if err != nil {
+		return err
+	}
+
+	notifySvc := NewNotificationService(s.db, s.cfg)
+	for i := range alerts {
+		alert := &alerts[i]
+		rule := byID[alert.RuleID]
+		after, err := time.ParseDuration(rule.EscalateAfter)
+		if err != nil || alert.FiredAt == nil || now.Sub(*alert.FiredAt) < after || silenced(silences, alert) {
+			continue
+		}
+
+		channel, err := notifySvc.GetChannel(rule.EscalateChannelID)
+		if err != nil {
+			log.Printf("[ALERT] Cannot escalate alert %d: %v", alert.ID, err)
+			continue
+		}
+		if !channel.Enabled {
+			continue
+		}
+		notification, err := buildNotification(channel, alert, "escalated", now)
+		if err != nil {
+			log.Printf("[ALERT] Cannot escalate alert %d: channel %d: %v", alert.ID, channel.ID, err)
+			continue
+		}
+
+		err = s.db.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Create(notification).Error; err != nil {
+				return err
+			}
+			return tx.Model(alert).Update("escalated_at", now).Error
+		})
+		if err != nil {
+			return fmt.Errorf("failed to escalate alert %d: %w", alert.ID, err)
+		}
+		log.Printf("[ALERT] Escalated alert %d to channel %d", alert.ID, channel.ID)
+	}
+	return nil
+}
+
+// Evaluate correlates outages into incidents, runs every enabled rule
+// matching the snapshot's device and updates the alert lifecycle: new
+// conditions open a pending alert, which fires once the rule's "for" duration
+// has passed; conditions that cleared resolve firing alerts and drop pending
+// ones. Subjects without data (a failed PON, an unreachable device) keep
+// their alerts unchanged.
+func (s *AlertService) Evaluate(snapshot *AlertSnapshot, now time.Time) error {
+	var rules []database.AlertRule
+	if err := s.db.Where("enabled = ? AND (device_id = '' OR device_id = ?)", true, snapshot.DeviceID).
+		Find(&rules).Error; err != nil {
+		return fmt.Errorf("failed to load alert rules: %w", err)
+	}
+
+	var errs []string
+	var transitions []alertTransition
+	if s.cfg.Incidents.Enabled {
+		changed, err := NewIncidentService(s.db, s.cfg).Correlate(snapshot, now)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("incidents: %v", err))
+		}
+		transitions = append(transitions, changed...)
+	}
+	if err := s.loadFlaps(rules, snapshot, now); err != nil {
+		errs = append(errs, err.Error())
+	}
+	for i := range rules {
+		rule := &rules[i]
+		violations, known, ok := evaluateRule(rule, snapshot)
+		if !ok {
+			continue
+		}
+		changed, err := s.reconcile(rule, snapshot.DeviceID, violations, known, now)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("rule %d: %v", rule.ID, err))
+			continue
+		}
+		transitions = append(transitions, changed...)
+	}
+
+	errs = append(errs, s.notify(transitions, now)...)
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// loadFlaps counts ONU flaps over the window of every flapping rule
+func (s *AlertService) loadFlaps(rules []database.AlertRule, snapshot *AlertSnapshot, now time.Time) error {
+	if !snapshot.ONUsKnown {
+		return nil
+	}
+	flapSvc := NewFlapService(s.db, s.cfg)
+	for _, rule := range rules {
+		if rule.Type != AlertONUFlapping || snapshot.Flaps[rule.Window] != nil {
+			continue
+		}
+		window, err := time.ParseDuration(rule.Window)
+		if err != nil {
+			continue
+		}
+		counts, err := flapSvc.Counts(snapshot.DeviceID, now.Add(-window), now)
+		if err != nil {
+			return fmt.Errorf("rule %d: %w", rule.ID, err)
+		}
+		if snapshot.Flaps == nil {
+			snapshot.Flaps = map[string]map[string]int{}
+		}
+		snapshot.Flaps[rule.Window] = counts
+	}
+	return nil
+}
+
+// notify queues notifications for alert transitions. Silenced alerts and ONU
+// alerts covered by an incident are skipped.
+func (s *AlertService) notify(transitions []alertTransition, now time.Time) []string {
+	if !s.cfg.Notifications.Enabled || len(transitions) == 0 {
+		return nil
+	}
+	silences, err := s.activeSilences(now)
+	if err != nil {
+		return []string{err.Error()}
+	}
+
+	var errs []string
+	notifySvc := NewNotificationService(s.db, s.cfg)
+	incidentSvc := NewIncidentService(s.db, s.cfg)
+	for i := range transitions {
+		alert := &transitions[i].Alert
+		if silenced(silences, alert) {
+			continue
+		}
+		if s.cfg.Incidents.Enabled {
+			covered, err := incidentSvc.Covers(alert)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("alert %d: %v", alert.ID, err))
+				continue
+			}
+			if covered {
+				continue
+			}
+		}
+		if err := notifySvc.Enqueue(alert, transitions[i].Event); err != nil {
+			errs = append(errs, fmt.Sprintf("alert %d: %v", alert.ID, err))
+		}
+	}
+	return errs
+}
+
+// reconcile applies one rule's violations to its open alerts on a device and
+// returns the alerts that fired or resolved
+func (s *AlertService) reconcile(rule *database.AlertRule, deviceID string, violations map[string]alertViolation, known func(ponID string) bool, now time.Time) ([]alertTransition, error) {
+	var forDuration time.Duration
+	if rule.For != "" {
+		forDuration, _ = time.ParseDuration(rule.For)
+	}
+
+	var transitions []alertTransition
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		transitions = nil
+		var open []database.Alert
+		if err := tx.Where("rule_id = ? AND device_id = ? AND status IN ?",
+			rule.ID, deviceID, []string{AlertStatusPending, AlertStatusFiring}).
+			Find(&open).Error; err != nil {
+			return fmt.Errorf("failed to load open alerts: %w", err)
+		}
+
+		seen := map[string]bool{}
+		for i := range open {
+			alert := &open[i]
+			violation, active := violations[alert.Fingerprint]
+			if seen[alert.Fingerprint] {
+				// Duplicate open alert for one subject; keep the first
+				active = false
+			}
+			seen[alert.Fingerprint] = true
+
+			switch {
+			case active:
+				alert.Value = violation.Value
+				alert.Message = violation.Message
+				alert.Severity = rule.Severity
+				alert.LastEvaluatedAt = now
+				fired := false
+				if alert.Status == AlertStatusPending && now.Sub(alert.StartedAt) >= forDuration {
+					alert.Status = AlertStatusFiring
+					alert.FiredAt = &now
+					fired = true
+				}
+				alert.UpdatedAt = now
+				if err := tx.Save(alert).Error; err != nil {
+					return fmt.Errorf("failed to update alert: %w", err)
+				}
+				if fired {
+					transitions = append(transitions, alertTransition{Alert: *alert, Event: AlertStatusFiring})
+				}
+			case !known(alert.PONID):
+				continue
+			case alert.Status == AlertStatusPending:
+				if err := tx.Delete(alert).Error; err != nil {
+					return fmt.Errorf("failed to drop pending alert: %w", err)
+				}
+			default:
+				alert.Status = AlertStatusResolved
+				alert.ResolvedAt = &now
+				alert.LastEvaluatedAt = now
+				alert.UpdatedAt = now
+				if err := tx.Save(alert).Error; err != nil {
+					return fmt.Errorf("failed to resolve alert: %w", err)
+				}
+				transitions = append(transitions, alertTransition{Alert: *alert, Event: AlertStatusResolved})
+			}
+		}
+
+		fingerprints := make([]string, 0, len(violations))
+		for fingerprint := range violations {
+			if !seen[fingerprint] {
+				fingerprints = append(fingerprints, fingerprint)
+			}
+		}
+		sort.Strings(fingerprints)
+		for _, fingerprint := range fingerprints {
+			violation := violations[fingerprint]
+			alert := &database.Alert{
+				RuleID:          rule.ID,
+				RuleName:        rule.Name,
+				Type:            rule.Type,
+				Severity:        rule.Severity,
+				Fingerprint:     fingerprint,
+				Status:          AlertStatusPending,
+				DeviceID:        deviceID,
+				PONID:           violation.PONID,
+				ONUID:           violation.ONUID,
+				Value:           violation.Value,
+				Message:         violation.Message,
+				StartedAt:       now,
+				LastEvaluatedAt: now,
+				UpdatedAt:       now,
+			}
+			if forDuration <= 0 {
+				alert.Status = AlertStatusFiring
+				alert.FiredAt = &now
+			}
+			if err := tx.Create(alert).Error; err != nil {
+				return fmt.Errorf("failed to create alert: %w", err)
+			}
+			if alert.Status == AlertStatusFiring {
+				transitions = append(transitions, alertTransition{Alert: *alert, Event: AlertStatusFiring})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transitions, nil
+}
+
+// alertFingerprint identifies the subject of an alert
+func alertFingerprint(ruleID uint, deviceID, ponID, onuID string) string {
+	return fmt.Sprintf("%d|%s|%s|%s", ruleID, deviceID, ponID, onuID)
+}
+
+// evaluateRule returns the subjects violating a rule, a function telling
+// whether a PON had data (so alerts on it may be resolved), and false when
+// the snapshot has nothing to evaluate the rule against.
+func evaluateRule(rule *database.AlertRule, snapshot *AlertSnapshot) (map[string]alertViolation, func(string) bool, bool) {
+	violations := map[string]alertViolation{}
+	add := func(ponID, onuID string, value float64, message string) {
+		violations[alertFingerprint(rule.ID, snapshot.DeviceID, ponID, onuID)] = alertViolation{
+			PONID: ponID, ONUID: onuID, Value: value, Message: message,
+		}
+	}
+	allKnown := func(string) bool { return true }
+
+	switch rule.Type {
+	case AlertDeviceUnreachable:
+		if !snapshot.Reachable {
+			message := fmt.Sprintf("Device %s is unreachable", snapshot.DeviceID)
+			if snapshot.ReachError != "" {
+				message += ": " + snapshot.ReachError
+			}
+			add("", "", 0, message)
+		}
+		return violations, allKnown, true
+
+	case AlertDeviceCPUHigh, AlertDeviceMemoryHigh:
+		if snapshot.System == nil {
+			return nil, nil, false
+		}
+		value, label := snapshot.System.CPUUsage, "CPU"
+		if rule.Type == AlertDeviceMemoryHigh {
+			value, label = snapshot.System.MemoryUsage, "Memory"
+		}
+		if value > rule.Threshold {
+			add("", "", value, fmt.Sprintf("Device %s %s usage %.1f%% above %.1f%%", snapshot.DeviceID, label, value, rule.Threshold))
+		}
+		return violations, allKnown, true
+	}
+
+	if !snapshot.ONUsKnown {
+		return nil, nil, false
+	}
+	known := func(ponID string) bool { return !snapshot.FailedPONs[ponID] }
+
+	if rule.Type == AlertPONONUsDown {
+		down := map[string]int{}
+		for _, onu := range snapshot.ONUs {
+			ponID := ponOfONU(onu.ONUID)
+			if rule.PONID != "" && ponID != rule.PONID {
+				continue
+			}
+			if onu.Status != "online" {
+				down[ponID]++
+			}
+		}
+		for ponID, count := range down {
+			if float64(count) > rule.Threshold {
+				add(ponID, "", float64(count), fmt.Sprintf("%d ONUs down on PON %s of device %s", count, ponID, snapshot.DeviceID))
+			}
+		}
+		return violations, known, true
+	}
+
+	for _, onu := range snapshot.ONUs {
+		ponID := ponOfONU(onu.ONUID)
+		if rule.PONID != "" && ponID != rule.PONID {
+			continue
+		}
+		label := onu.ONUID
+		if onu.Name != "" {
+			label = fmt.Sprintf("%s (%s)", onu.ONUID, onu.Name)
+		}
+
+		switch rule.Type {
+		case AlertONUOffline:
+			if onu.Status != "online" {
+				add(ponID, onu.ONUID, 0, fmt.Sprintf("ONU %s on device %s is %s", label, snapshot.DeviceID, onu.Status))
+			}
+		case AlertONURxLow:
+			rx := onu.Metrics.RxPower
+			if onu.Status == "online" && metricReported(onu, "rx_power") && rx < rule.Threshold {
+				add(ponID, onu.ONUID, rx, fmt.Sprintf("ONU %s on device %s Rx power %.2f dBm below %.2f dBm", label, snapshot.DeviceID, rx, rule.Threshold))
+			}
+		case AlertONUFlapping:
+			counts, ok := snapshot.Flaps[rule.Window]
+			if !ok {
+				return nil, nil, false
+			}
+			if flaps := counts[onu.ONUID]; float64(flaps) > rule.Threshold {
+				add(ponID, onu.ONUID, float64(flaps), fmt.Sprintf("ONU %s on device %s flapped %d times in %s", label, snapshot.DeviceID, flaps, rule.Window))
+			}
+		case AlertONUTempHigh:
+			temp := onu.Metrics.Temperature
+			if onu.Status == "online" && metricReported(onu, "temperature") && temp > rule.Threshold {
+				add(ponID, onu.ONUID, temp, fmt.Sprintf("ONU %s on device %s temperature %.1f°C above %.1f°C", label, snapshot.DeviceID, temp, rule.Threshold))
+			}
+		}
+	}
+	return violations, known, true
+}
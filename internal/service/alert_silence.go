@@ -0,0 +1,145 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"olt-api/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// ListSilences returns silences that have not ended, or all of them when
+// includeExpired is set
+func (s *AlertService) ListSilences(includeExpired bool) ([]database.AlertSilence, error) {
+	query := s.db.Model(&database.AlertSilence{})
+	if !includeExpired {
+		query = query.Where("ends_at > ?", time.Now())
+	}
+
+	var silences []database.AlertSilence
+	if err := query.Order("starts_at DESC, id DESC").Find(&silences).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch alert silences: %w", err)
+	}
+	return silences, nil
+}
+
+// GetSilence returns a silence by ID
+func (s *AlertService) GetSilence(id uint) (*database.AlertSilence, error) {
+	var silence database.AlertSilence
+	if err := s.db.First(&silence, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("alert silence '%d' %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch alert silence: %w", err)
+	}
+	return &silence, nil
+}
+
+// CreateSilence validates and stores a silence
+func (s *AlertService) CreateSilence(req *database.AlertSilenceRequest, createdBy string) (*database.AlertSilence, error) {
+	silence := &database.AlertSilence{
+		DeviceID:  strings.TrimSpace(req.DeviceID),
+		PONID:     strings.TrimSpace(req.PONID),
+		ONUID:     strings.TrimSpace(req.ONUID),
+		RuleID:    req.RuleID,
+		Comment:   strings.TrimSpace(req.Comment),
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+	if silence.DeviceID == "" && silence.PONID == "" && silence.ONUID == "" && silence.RuleID == 0 {
+		return nil, invalidf("at least one of device_id, pon_id, onu_id or rule_id is required")
+	}
+	// PON and ONU IDs repeat across OLTs
+	if silence.DeviceID == "" && (silence.PONID != "" || silence.ONUID != "") {
+		return nil, invalidf("device_id is required with pon_id or onu_id")
+	}
+	if silence.Comment == "" {
+		return nil, invalidf("comment is required")
+	}
+	if silence.RuleID > 0 {
+		if _, err := s.GetRule(silence.RuleID); err != nil {
+			return nil, err
+		}
+	}
+
+	silence.StartsAt = silence.CreatedAt
+	if req.StartsAt != nil {
+		silence.StartsAt = req.StartsAt.Local()
+	}
+	switch {
+	case req.EndsAt != nil && req.Duration != "":
+		return nil, invalidf("set either ends_at or duration, not both")
+	case req.EndsAt != nil:
+		silence.EndsAt = req.EndsAt.Local()
+	case req.Duration != "":
+		duration, err := time.ParseDuration(req.Duration)
+		if err != nil || duration <= 0 {
+			return nil, invalidf("invalid duration '%s'", req.Duration)
+		}
+		silence.EndsAt = silence.StartsAt.Add(duration)
+	default:
+		return nil, invalidf("either ends_at or duration is required")
+	}
+	if !silence.EndsAt.After(silence.StartsAt) {
+		return nil, invalidf("ends_at must be after starts_at")
+	}
+	if !silence.EndsAt.After(silence.CreatedAt) {
+		return nil, invalidf("ends_at must be in the future")
+	}
+
+	if err := s.db.Create(silence).Error; err != nil {
+		return nil, fmt.Errorf("failed to create alert silence: %w", err)
+	}
+	return silence, nil
+}
+
+// ExpireSilence ends a silence now; the record is kept for reference
+func (s *AlertService) ExpireSilence(id uint) (*database.AlertSilence, error) {
+	silence, err := s.GetSilence(id)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !silence.EndsAt.After(now) {
+		return silence, nil
+	}
+
+	silence.EndsAt = now
+	if silence.StartsAt.After(now) {
+		silence.StartsAt = now
+	}
+	if err := s.db.Save(silence).Error; err != nil {
+		return nil, fmt.Errorf("failed to expire alert silence: %w", err)
+	}
+	return silence, nil
+}
+
+func (s *AlertService) activeSilences(now time.Time) ([]database.AlertSilence, error) {
+	var silences []database.AlertSilence
+	if err := s.db.Where("starts_at <= ? AND ends_at > ?", now, now).Find(&silences).Error; err != nil {
+		return nil, fmt.Errorf("failed to load alert silences: %w", err)
+	}
+	return silences, nil
+}
+
+// silenced reports whether any silence matches the alert
+func silenced(silences []database.AlertSilence, alert *database.Alert) bool {
+	for _, silence := range silences {
+		if silence.DeviceID != "" && silence.DeviceID != alert.DeviceID {
+			continue
+		}
+		if silence.PONID != "" && silence.PONID != alert.PONID {
+			continue
+		}
+		if silence.ONUID != "" && silence.ONUID != alert.ONUID {
+			continue
+		}
+		if silence.RuleID > 0 && silence.RuleID != alert.RuleID {
+			continue
+		}
+		return true
+	}
+	return false
+}
@@ -0,0 +1,777 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// Availability interval states. Partial intervals are hourly rollups with
+// both online and offline samples.
+const (
+	IntervalOnline  = "online"
+	IntervalOffline = "offline"
+	IntervalPartial = "partial"
+	IntervalNoData  = "no_data"
+)
+
+// oltTimeLayouts are the timestamp formats OLTs use for ONU up/down times
+var oltTimeLayouts = []string{
+	"2006-01-02 15:04:05",
+	"2006/01/02 15:04:05",
+	"2006-01-02T15:04:05",
+	"2006/1/2 15:04:05",
+}
+
+// AvailabilityInterval is one stretch of an ONU timeline
+type AvailabilityInterval struct {
+	Status          string    `json:"status"`
+	DownStatus      string    `json:"down_status,omitempty"` // first logged status of an outage, e.g. los
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+	DurationSeconds int64     `json:"duration_seconds"`
+	OnlineSeconds   int64     `json:"online_seconds,omitempty"` // partial intervals only
+	Source          string    `json:"source"`                   // log, hourly, olt when corrected with OLT times, or device
+}
+
+// OLTUptimeInfo holds the last up and down times reported by the OLT
+type OLTUptimeInfo struct {
+	LastUptime  string `json:"last_uptime,omitempty"`
+	LastOfftime string `json:"last_offtime,omitempty"`
+	Applied     bool   `json:"applied"` // whether they corrected the timeline
+	Error       string `json:"error,omitempty"`
+}
+
+// ONUAvailability is the availability of one ONU over a range. Availability
+// is online time over monitored time; stretches without data are excluded
+// and reported through CoveragePct, except while the device was down, which
+// counts as offline.
+type ONUAvailability struct {
+	DeviceID             string                 `json:"device_id"`
+	ONUID                string                 `json:"onu_id"`
+	Name                 string                 `json:"name,omitempty"`
+	MacAddress           string                 `json:"mac_address,omitempty"`
+	From                 time.Time              `json:"from"`
+	To                   time.Time              `json:"to"`
+	AvailabilityPct      *float64               `json:"availability_pct"`
+	CoveragePct          float64                `json:"coverage_pct"`
+	OnlineSeconds        int64                  `json:"online_seconds"`
+	OfflineSeconds       int64                  `json:"offline_seconds"`
+	NoDataSeconds        int64                  `json:"no_data_seconds"`
+	Outages              int                    `json:"outages"`
+	LongestOutageSeconds int64                  `json:"longest_outage_seconds"`
+	MeetsTarget          *bool                  `json:"meets_target,omitempty"`
+	RawFrom              *time.Time             `json:"raw_from,omitempty"` // earlier intervals come from hourly rollups
+	OLT                  *OLTUptimeInfo         `json:"olt,omitempty"`
+	Subscriber           *database.Subscriber   `json:"subscriber,omitempty"`
+	Intervals            []AvailabilityInterval `json:"intervals,omitempty"`
+}
+
+// AvailabilityFilter selects the ONUs of a group availability report. At
+// least one of Tag, Plan and SubscriberID is required.
+type AvailabilityFilter struct {
+	Tag          string  // ONU tag
+	Plan         string  // subscriber plan
+	SubscriberID uint    // a single subscriber
+	DeviceID     string  // optional
+	Target       float64 // availability percentage an ONU must reach; 0 disables
+}
+
+// AvailabilityReport is the availability of a group of ONUs. Unresolved
+// lists members whose MAC is not in the ONU inventory.
+type AvailabilityReport struct {
+	From            time.Time         `json:"from"`
+	To              time.Time         `json:"to"`
+	Tag             string            `json:"tag,omitempty"`
+	Plan            string            `json:"plan,omitempty"`
+	SubscriberID    uint              `json:"subscriber_id,omitempty"`
+	Target          float64           `json:"target,omitempty"`
+	Total           int               `json:"total"`
+	AvailabilityPct *float64          `json:"availability_pct"` // over the monitored time of all ONUs
+	BelowTarget     int               `json:"below_target"`
+	ONUs            []ONUAvailability `json:"onus"`
+	Unresolved      []string          `json:"unresolved,omitempty"` // device_id/mac
+}
+
+// deviceDownStatus is the down_status of offline intervals where the device
+// was down and its ONUs could not be read
+const deviceDownStatus = "device_down"
+
+// deviceTimeline is what the timelines of all ONUs of a device share: the
+// time of its oldest raw log and the stretches it was down
+type deviceTimeline struct {
+	OldestLog *time.Time
+	Down      [][2]time.Time
+}
+
+// availabilityLogRow is one ONU log row read for availability
+type availabilityLogRow struct {
+	Name       string    `gorm:"column:name"`
+	Status     string    `gorm:"column:status"`
+	RecordedAt time.Time `gorm:"column:recorded_at"`
+}
+
+// AvailabilityService derives ONU online/offline timelines from the ONU
+// history log, falling back to hourly rollups once raw logs have expired
+type AvailabilityService struct {
+	db            *gorm.DB
+	cfg           *config.Config
+	deviceService *DeviceService
+}
+
+// NewAvailabilityService creates a new AvailabilityService
+func NewAvailabilityService(db *gorm.DB, cfg *config.Config, deviceService *DeviceService) *AvailabilityService {
+	return &AvailabilityService{
+		db:            db,
+		cfg:           cfg,
+		deviceService: deviceService,
+	}
+}
+
+// ONU returns the availability and timeline of one ONU between from and to.
+// With useOLT the last outage is corrected with the up and down times the
+// OLT reports, which also reveals outages shorter than the collector
+// interval.
+func (s *AvailabilityService) ONU(deviceID, onuID string, from, to time.Time, useOLT bool) (*ONUAvailability, error) {
+	if !to.After(from) {
+		return nil, ErrInvalidRange
+	}
+	if _, err := s.deviceService.GetByID(deviceID); err != nil {
+		return nil, err
+	}
+
+	device, err := s.deviceTimeline(deviceID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	result, err := s.compute(deviceID, onuID, from, to, device)
+	if err != nil {
+		return nil, err
+	}
+
+	if useOLT {
+		result.OLT = &OLTUptimeInfo{}
+		detail, err := NewONUService(s.db, s.cfg, s.deviceService).GetONUDetail(deviceID, onuID)
+		if err != nil {
+			result.OLT.Error = err.Error()
+		} else {
+			result.OLT.LastUptime = detail.LastUptime
+			result.OLT.LastOfftime = detail.LastOfftime
+			if result.Name == "" {
+				result.Name = detail.Name
+			}
+			if result.MacAddress == "" {
+				result.MacAddress = detail.MacAddress
+			}
+			result.Intervals, result.OLT.Applied = s.applyOLTTimes(result.Intervals, detail.LastOfftime, detail.LastUptime, result.From, result.To)
+		}
+	}
+
+	if result.MacAddress != "" {
+		result.Subscriber = NewSubscriberService(s.db, s.cfg, s.deviceService).ForONU(deviceID, result.MacAddress)
+	}
+	summarizeAvailability(result, 0)
+	return result, nil
+}
+
+// Report computes the availability of every ONU tagged Tag or linked to a
+// subscriber on Plan (or SubscriberID), lowest availability first
+func (s *AvailabilityService) Report(filter AvailabilityFilter, from, to time.Time) (*AvailabilityReport, error) {
+	if !to.After(from) {
+		return nil, ErrInvalidRange
+	}
+	if filter.Tag == "" && filter.Plan == "" && filter.SubscriberID == 0 {
+		return nil, invalidf("tag, plan or subscriber_id is required")
+	}
+	if filter.Target < 0 || filter.Target > 100 {
+		return nil, invalidf("target must be between 0 and 100")
+	}
+
+	members, err := s.members(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &AvailabilityReport{
+		From:         from.Local(),
+		To:           to.Local(),
+		Tag:          filter.Tag,
+		Plan:         filter.Plan,
+		SubscriberID: filter.SubscriberID,
+		Target:       filter.Target,
+		ONUs:         []ONUAvailability{},
+	}
+
+	subscribers := map[string]map[string]*database.Subscriber{}
+	devices := map[string]*deviceTimeline{}
+	var online, monitored int64
+	for _, member := range members {
+		var item database.ONUInventory
+		if err := s.db.Where("device_id = ? AND mac = ? AND present = ?", member.DeviceID, member.MAC, true).
+			First(&item).Error; err != nil {
+			report.Unresolved = append(report.Unresolved, member.DeviceID+"/"+member.MAC)
+			continue
+		}
+
+		if _, ok := devices[item.DeviceID]; !ok {
+			if devices[item.DeviceID], err = s.deviceTimeline(item.DeviceID, from, to); err != nil {
+				return nil, err
+			}
+		}
+		result, err := s.compute(item.DeviceID, item.ONUID, from, to, devices[item.DeviceID])
+		if err != nil {
+			return nil, err
+		}
+		result.Name = item.Name
+		result.MacAddress = item.MacAddress
+		if _, ok := subscribers[item.DeviceID]; !ok {
+			subscribers[item.DeviceID], _ = NewSubscriberService(s.db, s.cfg, s.deviceService).ForDevice(item.DeviceID)
+		}
+		result.Subscriber = subscribers[item.DeviceID][item.MAC]
+		summarizeAvailability(result, filter.Target)
+		result.Intervals = nil
+
+		online += result.OnlineSeconds
+		monitored += result.OnlineSeconds + result.OfflineSeconds
+		if result.MeetsTarget != nil && !*result.MeetsTarget {
+			report.BelowTarget++
+		}
+		report.ONUs = append(report.ONUs, *result)
+	}
+
+	sort.SliceStable(report.ONUs, func(i, j int) bool {
+		a, b := report.ONUs[i].AvailabilityPct, report.ONUs[j].AvailabilityPct
+		if (a == nil) != (b == nil) {
+			return b == nil
+		}
+		if a != nil && *a != *b {
+			return *a < *b
+		}
+		if report.ONUs[i].DeviceID != report.ONUs[j].DeviceID {
+			return report.ONUs[i].DeviceID < report.ONUs[j].DeviceID
+		}
+		return compareONUIDs(report.ONUs[i].ONUID, report.ONUs[j].ONUID) < 0
+	})
+	report.Total = len(report.ONUs)
+	report.AvailabilityPct = availabilityPct(online, monitored)
+	return report, nil
+}
+
+// availabilityMember is an ONU of a report group, keyed like tags and
+// subscribers by device and normalized MAC
+type availabilityMember struct {
+	DeviceID string
+	MAC      string
+}
+
+// members resolves the filter to (device, MAC) pairs. Criteria combine with
+// AND.
+func (s *AvailabilityService) members(filter AvailabilityFilter) ([]availabilityMember, error) {
+	var sets [][]availabilityMember
+
+	if filter.Tag != "" {
+		var tagged []availabilityMember
+		query := s.db.Table("onu_tags").
+			Select("onu_tags.device_id AS device_id, onu_tags.mac AS mac").
+			Joins("JOIN tags ON tags.id = onu_tags.tag_id").
+			Where("tags.name = ?", NormalizeTagName(filter.Tag))
+		if filter.DeviceID != "" {
+			query = query.Where("onu_tags.device_id = ?", filter.DeviceID)
+		}
+		if err := query.Scan(&tagged).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch tagged ONUs: %w", err)
+		}
+		sets = append(sets, tagged)
+	}
+
+	if filter.Plan != "" || filter.SubscriberID != 0 {
+		var linked []availabilityMember
+		query := s.db.Model(&database.Subscriber{}).
+			Select("device_id, mac").
+			Where("device_id <> '' AND mac <> ''")
+		if filter.Plan != "" {
+			query = query.Where("LOWER(plan) = ?", strings.ToLower(filter.Plan))
+		}
+		if filter.SubscriberID != 0 {
+			query = query.Where("id = ?", filter.SubscriberID)
+		}
+		if filter.DeviceID != "" {
+			query = query.Where("device_id = ?", filter.DeviceID)
+		}
+		if err := query.Scan(&linked).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch subscribers: %w", err)
+		}
+		sets = append(sets, linked)
+	}
+
+	members := sets[0]
+	for _, set := range sets[1:] {
+		keep := map[availabilityMember]bool{}
+		for _, member := range set {
+			keep[member] = true
+		}
+		var both []availabilityMember
+		for _, member := range members {
+			if keep[member] {
+				both = append(both, member)
+			}
+		}
+		members = both
+	}
+	return members, nil
+}
+
+// deviceTimeline reads the oldest raw log of a device and the stretches
+// between from and to where its polled status was down
+func (s *AvailabilityService) deviceTimeline(deviceID string, from, to time.Time) (*deviceTimeline, error) {
+	from, to = from.Local(), to.Local()
+	timeline := &deviceTimeline{}
+
+	var first database.ONULog
+	if err := s.db.Where("device_id = ?", deviceID).Order("recorded_at ASC").First(&first).Error; err == nil {
+		oldest := first.RecordedAt.Local()
+		timeline.OldestLog = &oldest
+	}
+
+	// The status at from is the last change before it
+	var events []database.DeviceStatusEvent
+	var previous database.DeviceStatusEvent
+	if err := s.db.Where("device_id = ? AND changed_at <= ?", deviceID, from).
+		Order("changed_at DESC").First(&previous).Error; err == nil {
+		events = append(events, previous)
+	}
+	var changes []database.DeviceStatusEvent
+	if err := s.db.Where("device_id = ? AND changed_at > ? AND changed_at < ?", deviceID, from, to).
+		Order("changed_at ASC").Find(&changes).Error; err != nil {
+		return nil, fmt.Errorf("failed to read device status events: %w", err)
+	}
+	events = append(events, changes...)
+
+	for i, event := range events {
+		if event.Status != DeviceDown {
+			continue
+		}
+		start, end := event.ChangedAt.Local(), to
+		if i+1 < len(events) {
+			end = events[i+1].ChangedAt.Local()
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(start) {
+			timeline.Down = append(timeline.Down, [2]time.Time{start, end})
+		}
+	}
+	return timeline, nil
+}
+
+// compute builds the timeline of one ONU from raw logs, and from hourly
+// rollups for the part of the range before the oldest raw log of the device.
+// Stretches without data while the device was down count as offline, from
+// the time the ONU was first seen in the inventory.
+func (s *AvailabilityService) compute(deviceID, onuID string, from, to time.Time, device *deviceTimeline) (*ONUAvailability, error) {
+	// Stored timestamps are in local time; compare like with like
+	from, to = from.Local(), to.Local()
+	result := &ONUAvailability{DeviceID: deviceID, ONUID: onuID, From: from, To: to}
+
+	// Raw logs take over from the oldest one kept; the rollup of the hour it
+	// falls in is trimmed to the part before it
+	rawFrom := from
+	if device.OldestLog != nil {
+		if cutoff := *device.OldestLog; cutoff.After(from) {
+			if cutoff.After(to) {
+				cutoff = to
+			}
+			rawFrom = cutoff
+			result.RawFrom = &cutoff
+		}
+	}
+
+	var intervals []AvailabilityInterval
+	if rawFrom.After(from) {
+		hourly, err := s.rollupIntervals(deviceID, onuID, from, rawFrom)
+		if err != nil {
+			return nil, err
+		}
+		intervals = hourly
+	}
+	if to.After(rawFrom) {
+		logged, name, err := s.logIntervals(deviceID, onuID, rawFrom, to)
+		if err != nil {
+			return nil, err
+		}
+		result.Name = name
+		for _, interval := range logged {
+			intervals = appendInterval(intervals, interval)
+		}
+	}
+
+	// An outage before the ONU was installed is not downtime of the ONU.
+	// Without an inventory row there is nothing to clip to.
+	var firstSeen time.Time
+	var item database.ONUInventory
+	if err := s.db.Where("device_id = ? AND onu_id = ?", deviceID, onuID).
+		Order("last_seen_at DESC").First(&item).Error; err == nil {
+		firstSeen = item.FirstSeenAt.Local()
+		if result.MacAddress == "" {
+			result.MacAddress = item.MacAddress
+		}
+		if result.Name == "" {
+			result.Name = item.Name
+		}
+	}
+	result.Intervals = overlayDeviceDown(intervals, device.Down, firstSeen)
+	return result, nil
+}
+
+// logIntervals turns the logged statuses of an ONU into intervals. A status
+// holds until the next row, but no longer than the gap after which the
+// collector is considered to have missed a cycle.
+func (s *AvailabilityService) logIntervals(deviceID, onuID string, from, to time.Time) ([]AvailabilityInterval, string, error) {
+	maxGap := s.maxGap()
+	var rows []availabilityLogRow
+	if err := s.db.Model(&database.ONULog{}).
+		Select("name, status, recorded_at").
+		Where("device_id = ? AND on_uid = ? AND recorded_at >= ? AND recorded_at < ?", deviceID, onuID, from.Add(-maxGap), to).
+		Order("recorded_at ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, "", fmt.Errorf("failed to read ONU logs: %w", err)
+	}
+
+	var intervals []AvailabilityInterval
+	name := ""
+	cursor := from
+	for i, row := range rows {
+		name = row.Name
+		start := row.RecordedAt.Local()
+		end := start.Add(maxGap)
+		if i+1 < len(rows) && rows[i+1].RecordedAt.Local().Before(end) {
+			end = rows[i+1].RecordedAt.Local()
+		}
+		if end.After(to) {
+			end = to
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if !end.After(start) {
+			continue
+		}
+		if start.After(cursor) {
+			intervals = appendInterval(intervals, newInterval(IntervalNoData, "", cursor, start, "log"))
+		}
+		status, downStatus := IntervalOnline, ""
+		if !isOnline(row.Status) {
+			status, downStatus = IntervalOffline, row.Status
+		}
+		intervals = appendInterval(intervals, newInterval(status, downStatus, start, end, "log"))
+		cursor = end
+	}
+	if to.After(cursor) {
+		intervals = appendInterval(intervals, newInterval(IntervalNoData, "", cursor, to, "log"))
+	}
+	return intervals, name, nil
+}
+
+// rollupIntervals turns the hourly rollups of an ONU into intervals
+func (s *AvailabilityService) rollupIntervals(deviceID, onuID string, from, to time.Time) ([]AvailabilityInterval, error) {
+	var rollups []database.ONUMetricRollup
+	if err := s.db.Where("device_id = ? AND onu_id = ? AND tier = ? AND bucket_start >= ? AND bucket_start < ?",
+		deviceID, onuID, HistoryStepHour, bucketStart(from, HistoryStepHour), to).
+		Order("bucket_start ASC").
+		Find(&rollups).Error; err != nil {
+		return nil, fmt.Errorf("failed to read ONU rollups: %w", err)
+	}
+
+	var intervals []AvailabilityInterval
+	cursor := from
+	for _, rollup := range rollups {
+		start := rollup.BucketStart.Local()
+		end := nextBucket(start, HistoryStepHour)
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		if !end.After(start) || rollup.Samples == 0 {
+			continue
+		}
+		if start.After(cursor) {
+			intervals = appendInterval(intervals, newInterval(IntervalNoData, "", cursor, start, "hourly"))
+		}
+		interval := newInterval(IntervalPartial, "", start, end, "hourly")
+		switch rollup.OnlineSamples {
+		case rollup.Samples:
+			interval.Status = IntervalOnline
+		case 0:
+			interval.Status = IntervalOffline
+		default:
+			// A trimmed bucket keeps the bucket's online share
+			interval.OnlineSeconds = int64(math.Round(float64(interval.DurationSeconds) * float64(rollup.OnlineSamples) / float64(rollup.Samples)))
+		}
+		intervals = appendInterval(intervals, interval)
+		cursor = end
+	}
+	if to.After(cursor) {
+		intervals = appendInterval(intervals, newInterval(IntervalNoData, "", cursor, to, "hourly"))
+	}
+	return intervals, nil
+}
+
+// applyOLTTimes corrects the timeline with the last outage the OLT reports:
+// the ONU was down from lastOfftime until lastUptime (or is still down when
+// it has not come back since). Logged edges are only moved when they are
+// within one collector gap of the OLT times, so a wrong OLT clock cannot
+// rewrite the timeline.
+func (s *AvailabilityService) applyOLTTimes(intervals []AvailabilityInterval, lastOfftime, lastUptime string, from, to time.Time) ([]AvailabilityInterval, bool) {
+	down, downOK := parseOLTTime(lastOfftime)
+	up, upOK := parseOLTTime(lastUptime)
+	if !downOK || down.Before(from) || !down.Before(to) {
+		return intervals, false
+	}
+	maxGap := s.maxGap()
+
+	if upOK && up.After(down) {
+		if up.Sub(down) > maxGap && !overlapsStatus(intervals, down, up, IntervalOffline, IntervalNoData) {
+			// A long outage the log saw the ONU online through; the clocks disagree
+			return intervals, false
+		}
+		end := up
+		if end.After(to) {
+			end = to
+		}
+		intervals = overlayInterval(intervals, newInterval(IntervalOffline, "", down, end, "olt"))
+		// The first online sample came after the ONU was already back
+		if up.Before(to) {
+			for _, interval := range intervals {
+				if interval.Status == IntervalOffline && interval.Source == "log" &&
+					!interval.Start.After(up) && interval.End.After(up) && interval.End.Sub(up) <= maxGap {
+					intervals = overlayInterval(intervals, newInterval(IntervalOnline, "", up, interval.End, "olt"))
+					break
+				}
+			}
+		}
+		return intervals, true
+	}
+
+	// Still down: move the start of the current outage back to lastOfftime
+	for _, interval := range intervals {
+		if interval.Status == IntervalOffline && !interval.Start.Before(down) && interval.Start.Sub(down) <= maxGap {
+			if interval.Start.After(down) {
+				intervals = overlayInterval(intervals, newInterval(IntervalOffline, "", down, interval.Start, "olt"))
+			}
+			return intervals, true
+		}
+	}
+	return intervals, false
+}
+
+func (s *AvailabilityService) maxGap() time.Duration {
+	if s.cfg.Collector.Interval > 0 {
+		return 2 * s.cfg.Collector.Interval
+	}
+	return 10 * time.Minute
+}
+
+// parseOLTTime parses an OLT timestamp in local time. Empty and zero
+// values ("0000-00-00 00:00:00") are not valid.
+func parseOLTTime(raw string) (time.Time, bool) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" || strings.HasPrefix(raw, "0000") {
+		return time.Time{}, false
+	}
+	for _, layout := range oltTimeLayouts {
+		if parsed, err := time.ParseInLocation(layout, raw, time.Local); err == nil {
+			return parsed, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func newInterval(status, downStatus string, start, end time.Time, source string) AvailabilityInterval {
+	interval := AvailabilityInterval{
+		Status:          status,
+		DownStatus:      downStatus,
+		Start:           start,
+		End:             end,
+		DurationSeconds: int64(end.Sub(start).Round(time.Second).Seconds()),
+		Source:          source,
+	}
+	return interval
+}
+
+// appendInterval appends an interval, extending the last one when it
+// continues the same status. An interval extended with OLT times is marked
+// as coming from the OLT.
+func appendInterval(intervals []AvailabilityInterval, interval AvailabilityInterval) []AvailabilityInterval {
+	if n := len(intervals); n > 0 {
+		last := &intervals[n-1]
+		if last.Status == interval.Status && last.End.Equal(interval.Start) {
+			if interval.Source == "olt" {
+				last.Source = interval.Source
+			}
+			last.End = interval.End
+			last.DurationSeconds = int64(last.End.Sub(last.Start).Round(time.Second).Seconds())
+			last.OnlineSeconds += interval.OnlineSeconds
+			if last.DownStatus == "" {
+				last.DownStatus = interval.DownStatus
+			}
+			return intervals
+		}
+	}
+	return append(intervals, interval)
+}
+
+// overlayInterval replaces whatever the timeline holds between the start
+// and end of an interval with it
+func overlayInterval(intervals []AvailabilityInterval, overlay AvailabilityInterval) []AvailabilityInterval {
+	var result []AvailabilityInterval
+	inserted := false
+	for _, interval := range intervals {
+		if !interval.End.After(overlay.Start) || !interval.Start.Before(overlay.End) {
+			if !inserted && !interval.Start.Before(overlay.End) {
+				result = appendInterval(result, overlay)
+				inserted = true
+			}
+			result = appendInterval(result, interval)
+			continue
+		}
+		if interval.Start.Before(overlay.Start) {
+			result = appendInterval(result, trimInterval(interval, interval.Start, overlay.Start))
+		}
+		if !inserted {
+			if overlay.DownStatus == "" && overlay.Status == interval.Status {
+				overlay.DownStatus = interval.DownStatus
+			}
+			result = appendInterval(result, overlay)
+			inserted = true
+		}
+		if interval.End.After(overlay.End) {
+			result = appendInterval(result, trimInterval(interval, overlay.End, interval.End))
+		}
+	}
+	if !inserted {
+		result = appendInterval(result, overlay)
+	}
+	return result
+}
+
+// trimInterval returns the part of an interval between start and end,
+// scaling the online time of partial intervals
+func trimInterval(interval AvailabilityInterval, start, end time.Time) AvailabilityInterval {
+	trimmed := newInterval(interval.Status, interval.DownStatus, start, end, interval.Source)
+	if interval.Status == IntervalPartial && interval.DurationSeconds > 0 {
+		trimmed.OnlineSeconds = interval.OnlineSeconds * trimmed.DurationSeconds / interval.DurationSeconds
+	}
+	return trimmed
+}
+
+// overlayDeviceDown marks the no_data stretches that fall in a device outage
+// as offline. Outages are clipped to start no earlier than since; a zero
+// since leaves them whole.
+func overlayDeviceDown(intervals []AvailabilityInterval, down [][2]time.Time, since time.Time) []AvailabilityInterval {
+	for _, outage := range down {
+		if outage[0].Before(since) {
+			outage[0] = since
+		}
+		if !outage[1].After(outage[0]) {
+			continue
+		}
+		var gaps []AvailabilityInterval
+		for _, interval := range intervals {
+			if interval.Status != IntervalNoData || !interval.Start.Before(outage[1]) || !interval.End.After(outage[0]) {
+				continue
+			}
+			start, end := interval.Start, interval.End
+			if start.Before(outage[0]) {
+				start = outage[0]
+			}
+			if end.After(outage[1]) {
+				end = outage[1]
+			}
+			gaps = append(gaps, newInterval(IntervalOffline, deviceDownStatus, start, end, "device"))
+		}
+		for _, gap := range gaps {
+			intervals = overlayInterval(intervals, gap)
+		}
+	}
+	return intervals
+}
+
+// overlapsStatus reports whether any interval with one of the statuses
+// overlaps start to end
+func overlapsStatus(intervals []AvailabilityInterval, start, end time.Time, statuses ...string) bool {
+	for _, interval := range intervals {
+		if containsString(statuses, interval.Status) && interval.Start.Before(end) && interval.End.After(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// summarizeAvailability totals the timeline. Consecutive offline and
+// partial intervals count as one outage.
+func summarizeAvailability(result *ONUAvailability, target float64) {
+	result.OnlineSeconds, result.OfflineSeconds, result.NoDataSeconds = 0, 0, 0
+	result.Outages, result.LongestOutageSeconds = 0, 0
+
+	var outage int64
+	inOutage := false
+	for _, interval := range result.Intervals {
+		down := int64(0)
+		switch interval.Status {
+		case IntervalOnline:
+			result.OnlineSeconds += interval.DurationSeconds
+		case IntervalOffline:
+			down = interval.DurationSeconds
+		case IntervalPartial:
+			result.OnlineSeconds += interval.OnlineSeconds
+			down = interval.DurationSeconds - interval.OnlineSeconds
+		case IntervalNoData:
+			result.NoDataSeconds += interval.DurationSeconds
+		}
+		result.OfflineSeconds += down
+
+		if interval.Status == IntervalOffline || interval.Status == IntervalPartial {
+			if !inOutage {
+				result.Outages++
+				inOutage, outage = true, 0
+			}
+			outage += down
+			if outage > result.LongestOutageSeconds {
+				result.LongestOutageSeconds = outage
+			}
+		} else {
+			inOutage = false
+		}
+	}
+
+	monitored := result.OnlineSeconds + result.OfflineSeconds
+	result.AvailabilityPct = availabilityPct(result.OnlineSeconds, monitored)
+	if span := result.To.Sub(result.From).Seconds(); span > 0 {
+		result.CoveragePct = math.Round(float64(monitored)/span*10000) / 100
+	}
+	result.MeetsTarget = nil
+	if target > 0 && result.AvailabilityPct != nil {
+		meets := *result.AvailabilityPct >= target
+		result.MeetsTarget = &meets
+	}
+}
+
+// availabilityPct returns online over monitored seconds as a percentage
+// with three decimals, or nil when nothing was monitored
+func availabilityPct(online, monitored int64) *float64 {
+	if monitored <= 0 {
+		return nil
+	}
+	pct := math.Round(float64(online)/float64(monitored)*100000) / 1000
+	return &pct
+}
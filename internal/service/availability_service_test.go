@@ -0,0 +1,297 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+)
+
+var availabilityBase = time.Date(2026, 5, 10, 0, 0, 0, 0, time.Local)
+
+// atMinute returns the time m minutes after availabilityBase
+func atMinute(m int) time.Time {
+	return availabilityBase.Add(time.Duration(m) * time.Minute)
+}
+
+// span builds an interval from minute start to minute end
+func span(status, downStatus string, start, end int, source string) AvailabilityInterval {
+	return newInterval(status, downStatus, atMinute(start), atMinute(end), source)
+}
+
+func partialSpan(start, end int, onlineSeconds int64) AvailabilityInterval {
+	interval := span(IntervalPartial, "", start, end, "hourly")
+	interval.OnlineSeconds = onlineSeconds
+	return interval
+}
+
+// formatIntervals renders intervals as "status/down_status start-end source
+// online_seconds" with times in minutes after availabilityBase
+func formatIntervals(intervals []AvailabilityInterval) []string {
+	lines := make([]string, len(intervals))
+	for i, interval := range intervals {
+		start := int(interval.Start.Sub(availabilityBase) / time.Minute)
+		end := int(interval.End.Sub(availabilityBase) / time.Minute)
+		if want := int64(interval.End.Sub(interval.Start).Seconds()); interval.DurationSeconds != want {
+			lines[i] = fmt.Sprintf("bad duration %d, want %d", interval.DurationSeconds, want)
+			continue
+		}
+		lines[i] = fmt.Sprintf("%s/%s %d-%d %s %d", interval.Status, interval.DownStatus, start, end, interval.Source, interval.OnlineSeconds)
+	}
+	return lines
+}
+
+func checkIntervals(t *testing.T, got []AvailabilityInterval, want []string) {
+	t.Helper()
+	lines := formatIntervals(got)
+	if fmt.Sprint(lines) != fmt.Sprint(want) {
+		t.Fatalf("intervals\n got %q\nwant %q", lines, want)
+	}
+}
+
+func TestTrimInterval(t *testing.T) {
+	tests := []struct {
+		name       string
+		interval   AvailabilityInterval
+		start, end int
+		want       string
+	}{
+		{"online", span(IntervalOnline, "", 0, 60, "log"), 10, 40, "online/ 10-40 log 0"},
+		{"offline keeps down status", span(IntervalOffline, "los", 0, 60, "log"), 0, 15, "offline/los 0-15 log 0"},
+		{"partial scales online time", partialSpan(0, 60, 1800), 0, 20, "partial/ 0-20 hourly 600"},
+		{"partial tail", partialSpan(0, 60, 3600), 45, 60, "partial/ 45-60 hourly 900"},
+		{"whole", partialSpan(0, 60, 1200), 0, 60, "partial/ 0-60 hourly 1200"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := trimInterval(tt.interval, atMinute(tt.start), atMinute(tt.end))
+			checkIntervals(t, []AvailabilityInterval{got}, []string{tt.want})
+		})
+	}
+}
+
+func TestOverlayInterval(t *testing.T) {
+	timeline := func() []AvailabilityInterval {
+		return []AvailabilityInterval{
+			span(IntervalOnline, "", 0, 60, "log"),
+			span(IntervalOffline, "los", 60, 90, "log"),
+			span(IntervalOnline, "", 90, 120, "log"),
+		}
+	}
+	tests := []struct {
+		name      string
+		intervals []AvailabilityInterval
+		overlay   AvailabilityInterval
+		want      []string
+	}{
+		{
+			name:      "inside one interval",
+			intervals: timeline(),
+			overlay:   span(IntervalOffline, "", 20, 30, "olt"),
+			want: []string{
+				"online/ 0-20 log 0",
+				"offline/ 20-30 olt 0",
+				"online/ 30-60 log 0",
+				"offline/los 60-90 log 0",
+				"online/ 90-120 log 0",
+			},
+		},
+		{
+			name:      "extends an outage backwards and takes its down status",
+			intervals: timeline(),
+			overlay:   span(IntervalOffline, "", 50, 70, "olt"),
+			want: []string{
+				"online/ 0-50 log 0",
+				"offline/los 50-90 olt 0",
+				"online/ 90-120 log 0",
+			},
+		},
+		{
+			name:      "covers several intervals",
+			intervals: timeline(),
+			overlay:   span(IntervalOnline, "", 40, 100, "olt"),
+			want:      []string{"online/ 0-120 olt 0"},
+		},
+		{
+			name:      "before the timeline",
+			intervals: timeline()[1:],
+			overlay:   span(IntervalOnline, "", 30, 60, "olt"),
+			want: []string{
+				"online/ 30-60 olt 0",
+				"offline/los 60-90 log 0",
+				"online/ 90-120 log 0",
+			},
+		},
+		{
+			name:      "after the timeline",
+			intervals: timeline()[:2],
+			overlay:   span(IntervalOffline, "", 90, 100, "olt"),
+			want: []string{
+				"online/ 0-60 log 0",
+				"offline/los 60-100 olt 0",
+			},
+		},
+		{
+			name:    "empty timeline",
+			overlay: span(IntervalOffline, "", 0, 10, "olt"),
+			want:    []string{"offline/ 0-10 olt 0"},
+		},
+		{
+			name:      "trims a partial hour",
+			intervals: []AvailabilityInterval{partialSpan(0, 60, 1800)},
+			overlay:   span(IntervalOffline, "", 30, 60, "olt"),
+			want: []string{
+				"partial/ 0-30 hourly 900",
+				"offline/ 30-60 olt 0",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkIntervals(t, overlayInterval(tt.intervals, tt.overlay), tt.want)
+		})
+	}
+}
+
+func TestOverlayDeviceDown(t *testing.T) {
+	timeline := func() []AvailabilityInterval {
+		return []AvailabilityInterval{
+			span(IntervalOnline, "", 0, 60, "log"),
+			span(IntervalNoData, "", 60, 120, "log"),
+			span(IntervalOnline, "", 120, 180, "log"),
+			span(IntervalNoData, "", 180, 240, "log"),
+		}
+	}
+	down := func(start, end int) [2]time.Time { return [2]time.Time{atMinute(start), atMinute(end)} }
+
+	tests := []struct {
+		name  string
+		down  [][2]time.Time
+		since time.Time
+		want  []string
+	}{
+		{
+			name: "no outage",
+			want: []string{
+				"online/ 0-60 log 0",
+				"no_data/ 60-120 log 0",
+				"online/ 120-180 log 0",
+				"no_data/ 180-240 log 0",
+			},
+		},
+		{
+			name: "outage covering a gap",
+			down: [][2]time.Time{down(50, 130)},
+			want: []string{
+				"online/ 0-60 log 0",
+				"offline/device_down 60-120 device 0",
+				"online/ 120-180 log 0",
+				"no_data/ 180-240 log 0",
+			},
+		},
+		{
+			name: "outage inside a gap",
+			down: [][2]time.Time{down(70, 90), down(200, 240)},
+			want: []string{
+				"online/ 0-60 log 0",
+				"no_data/ 60-70 log 0",
+				"offline/device_down 70-90 device 0",
+				"no_data/ 90-120 log 0",
+				"online/ 120-180 log 0",
+				"no_data/ 180-200 log 0",
+				"offline/device_down 200-240 device 0",
+			},
+		},
+		{
+			name: "outage while data was logged",
+			down: [][2]time.Time{down(10, 50)},
+			want: []string{
+				"online/ 0-60 log 0",
+				"no_data/ 60-120 log 0",
+				"online/ 120-180 log 0",
+				"no_data/ 180-240 log 0",
+			},
+		},
+		{
+			name:  "clipped to first seen",
+			down:  [][2]time.Time{down(60, 120), down(180, 240)},
+			since: atMinute(90),
+			want: []string{
+				"online/ 0-60 log 0",
+				"no_data/ 60-90 log 0",
+				"offline/device_down 90-120 device 0",
+				"online/ 120-180 log 0",
+				"offline/device_down 180-240 device 0",
+			},
+		},
+		{
+			name:  "outage before first seen",
+			down:  [][2]time.Time{down(60, 120)},
+			since: atMinute(150),
+			want: []string{
+				"online/ 0-60 log 0",
+				"no_data/ 60-120 log 0",
+				"online/ 120-180 log 0",
+				"no_data/ 180-240 log 0",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkIntervals(t, overlayDeviceDown(timeline(), tt.down, tt.since), tt.want)
+		})
+	}
+}
+
+func TestAvailabilityDeviceDownBeforeFirstSeen(t *testing.T) {
+	db := testDB(t)
+	createTestDevice(t, db, "olt1")
+	cfg := &config.Config{}
+	cfg.Collector.Interval = 5 * time.Minute
+
+	// The device is down from minute 0 to 120; the ONU is installed at
+	// minute 60 and logged online from minute 120
+	for _, event := range []database.DeviceStatusEvent{
+		{DeviceID: "olt1", Status: DeviceDown, Previous: DeviceUp, ChangedAt: atMinute(0)},
+		{DeviceID: "olt1", Status: DeviceUp, Previous: DeviceDown, ChangedAt: atMinute(120)},
+	} {
+		if err := db.Create(&event).Error; err != nil {
+			t.Fatalf("create status event: %v", err)
+		}
+	}
+	for m := 120; m < 180; m += 5 {
+		row := database.ONULog{DeviceID: "olt1", ONUID: "0/1:1", Name: "alice", Status: "Online", RecordedAt: atMinute(m)}
+		if err := db.Create(&row).Error; err != nil {
+			t.Fatalf("create log: %v", err)
+		}
+	}
+	// Older logs of another ONU keep the raw log window open from minute 0
+	if err := db.Create(&database.ONULog{DeviceID: "olt1", ONUID: "0/1:2", Status: "Online", RecordedAt: atMinute(0)}).Error; err != nil {
+		t.Fatalf("create log: %v", err)
+	}
+	item := database.ONUInventory{DeviceID: "olt1", MAC: "aabbcc000001", MacAddress: "AA:BB:CC:00:00:01", ONUID: "0/1:1",
+		PONID: "0/1", Name: "alice", Present: true, FirstSeenAt: atMinute(60), LastSeenAt: atMinute(175)}
+	if err := db.Create(&item).Error; err != nil {
+		t.Fatalf("create inventory: %v", err)
+	}
+
+	svc := NewAvailabilityService(db, cfg, NewDeviceService(db, cfg))
+	device, err := svc.deviceTimeline("olt1", atMinute(0), atMinute(180))
+	if err != nil {
+		t.Fatalf("device timeline: %v", err)
+	}
+	result, err := svc.compute("olt1", "0/1:1", atMinute(0), atMinute(180), device)
+	if err != nil {
+		t.Fatalf("compute: %v", err)
+	}
+	checkIntervals(t, result.Intervals, []string{
+		"no_data/ 0-60 log 0",
+		"offline/device_down 60-120 device 0",
+		"online/ 120-180 log 0",
+	})
+	if result.MacAddress != "AA:BB:CC:00:00:01" || result.Name != "alice" {
+		t.Errorf("mac %q name %q, want the inventory values", result.MacAddress, result.Name)
+	}
+}
@@ -0,0 +1,301 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+	"olt-api/internal/parser"
+	"olt-api/internal/scraper"
+
+	"gorm.io/gorm"
+)
+
+// JobTypeONUBulkAction is the job type for bulk ONU operations
+const JobTypeONUBulkAction = "onu.bulk_action"
+
+// BulkONUFilter selects ONUs by PON, status and name pattern
+type BulkONUFilter struct {
+	PONID       string `json:"pon_id"`
+	Status      string `json:"status"`
+	NamePattern string `json:"name_pattern"` // case-insensitive regular expression
+}
+
+// BulkONUActionRequest is used for running one operation against many ONUs
+type BulkONUActionRequest struct {
+	Action string            `json:"action" binding:"required"` // reboot, activate, deactivate, factory, cleanloop, rename, delete
+	ONUIDs []string          `json:"onu_ids"`
+	Filter *BulkONUFilter    `json:"filter"`
+	Name   string            `json:"name"`  // rename: same name for every ONU
+	Names  map[string]string `json:"names"` // rename: per-ONU names keyed by ONU ID
+}
+
+// BulkService resolves bulk ONU targets and executes bulk action jobs
+type BulkService struct {
+	db         *gorm.DB
+	cfg        *config.Config
+	onuService *ONUService
+	jobService *JobService
+}
+
+// NewBulkService creates a new BulkService
+func NewBulkService(db *gorm.DB, cfg *config.Config, deviceService *DeviceService) *BulkService {
+	return &BulkService{
+		db:         db,
+		cfg:        cfg,
+		onuService: NewONUService(db, cfg, deviceService),
+		jobService: NewJobService(db),
+	}
+}
+
+// ValidateBulkAction checks the action of a bulk request and normalizes its name
+func ValidateBulkAction(req *BulkONUActionRequest) error {
+	action := strings.ToLower(strings.TrimSpace(req.Action))
+	switch action {
+	case "rename":
+		if strings.TrimSpace(req.Name) == "" && len(req.Names) == 0 {
+			return fmt.Errorf("rename requires 'name' or 'names'")
+		}
+	case "delete":
+	default:
+		if _, err := onuOperationFor(action); err != nil {
+			return err
+		}
+	}
+	req.Action = action
+	return nil
+}
+
+// Prepare validates the request and returns the explicitly listed target
+// ONU IDs. Requests with a filter return no targets; they need a full ONU
+// list from the OLT, so they are resolved when the job runs.
+func (s *BulkService) Prepare(deviceID string, req *BulkONUActionRequest) ([]string, error) {
+	if err := ValidateBulkAction(req); err != nil {
+		return nil, err
+	}
+
+	if req.Filter != nil {
+		if _, err := compileNamePattern(req.Filter.NamePattern); err != nil {
+			return nil, err
+		}
+		return nil, nil
+	}
+
+	targets, err := s.resolveTargets(deviceID, req)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) == 0 {
+		return nil, fmt.Errorf("no ONUs matched the request")
+	}
+	return targets, nil
+}
+
+// compileNamePattern compiles a case-insensitive name pattern; nil when empty
+func compileNamePattern(pattern string) (*regexp.Regexp, error) {
+	pattern = strings.TrimSpace(pattern)
+	if pattern == "" {
+		return nil, nil
+	}
+	compiled, err := regexp.Compile("(?i)" + pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid name_pattern: %w", err)
+	}
+	return compiled, nil
+}
+
+func (s *BulkService) resolveTargets(deviceID string, req *BulkONUActionRequest) ([]string, error) {
+	seen := map[string]bool{}
+	var targets []string
+	add := func(onuID string) {
+		onuID = strings.TrimSpace(onuID)
+		if onuID == "" || seen[onuID] {
+			return
+		}
+		seen[onuID] = true
+		targets = append(targets, onuID)
+	}
+
+	for _, onuID := range req.ONUIDs {
+		add(onuID)
+	}
+	if len(req.ONUIDs) == 0 && req.Filter == nil {
+		for onuID := range req.Names {
+			add(onuID)
+		}
+	}
+
+	if req.Filter == nil {
+		return targets, nil
+	}
+
+	namePattern, err := compileNamePattern(req.Filter.NamePattern)
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []string
+	ponID := strings.TrimSpace(req.Filter.PONID)
+	onus, err := s.listONUs(deviceID, ponID, req.Filter.Status)
+	if err != nil {
+		return nil, err
+	}
+	for _, onu := range onus {
+		if namePattern != nil && !namePattern.MatchString(onu.Name) {
+			continue
+		}
+		candidates = append(candidates, onu.ONUID)
+	}
+
+	// An explicit ID list combined with a filter narrows the list to the filter.
+	if len(targets) > 0 {
+		matched := map[string]bool{}
+		for _, onuID := range candidates {
+			matched[onuID] = true
+		}
+		narrowed := targets[:0]
+		for _, onuID := range targets {
+			if matched[onuID] {
+				narrowed = append(narrowed, onuID)
+			}
+		}
+		return narrowed, nil
+	}
+
+	for _, onuID := range candidates {
+		add(onuID)
+	}
+	return targets, nil
+}
+
+func (s *BulkService) listONUs(deviceID, ponID, status string) ([]parser.ONUResponse, error) {
+	if ponID != "" {
+		return s.onuService.GetONUsByPON(deviceID, ponID, status)
+	}
+	return s.onuService.GetAllONUs(deviceID, status)
+}
+
+// Execute runs a claimed bulk action job. Filter targets are resolved first
+// when the job has no items yet. Each pending ONU is processed through the
+// worker pool; per-device request limits are enforced by the scraper client.
+// Items left pending when ctx is cancelled are skipped.
+func (s *BulkService) Execute(ctx context.Context, job *database.Job) (interface{}, error) {
+	var req BulkONUActionRequest
+	if err := json.Unmarshal([]byte(job.Payload), &req); err != nil {
+		return nil, fmt.Errorf("invalid job payload: %w", err)
+	}
+	if len(job.Items) == 0 {
+		if err := s.addFilterTargets(job, &req); err != nil {
+			return nil, err
+		}
+	}
+
+	actor := JobActor{
+		UserID:    job.UserID,
+		Username:  job.Username,
+		Role:      job.Role,
+		IPAddress: job.IPAddress,
+		UserAgent: job.UserAgent,
+	}
+	auditSvc := NewAuditService(s.db)
+
+	pool := scraper.NewWorkerPool(s.cfg.Scraper.MaxWorkers)
+	defer pool.Close()
+
+	for _, item := range job.Items {
+		if item.Status != JobItemPending {
+			continue
+		}
+		onuID := item.Target
+		pool.Submit(func() {
+			if ctx.Err() != nil {
+				return
+			}
+			started, err := s.jobService.StartItem(job.ID, onuID)
+			if err != nil {
+				log.Printf("[JOB] Failed to start item %s of job %d: %v", onuID, job.ID, err)
+				return
+			}
+			if !started {
+				return
+			}
+
+			action, metadata, itemErr := s.runItem(job.DeviceID, onuID, &req)
+			if err := s.jobService.RecordItem(job.ID, onuID, itemErr); err != nil {
+				log.Printf("[JOB] Failed to record item %s of job %d: %v", onuID, job.ID, err)
+			}
+
+			metadata["device_id"] = job.DeviceID
+			metadata["job_id"] = job.ID
+			metadata["success"] = itemErr == nil
+			if itemErr != nil {
+				metadata["error"] = itemErr.Error()
+			}
+			if err := auditSvc.Log(AuditLogEntry{
+				UserID:     actor.UserID,
+				Username:   actor.Username,
+				Role:       actor.Role,
+				Action:     action,
+				Resource:   "onu",
+				ResourceID: onuID,
+				Metadata:   metadata,
+				IPAddress:  actor.IPAddress,
+				UserAgent:  actor.UserAgent,
+			}); err != nil {
+				log.Printf("[AUDIT] failed to write log action=%s resource=onu resource_id=%s: %v", action, onuID, err)
+			}
+		})
+	}
+	pool.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	log.Printf("[JOB] Bulk %s job %d finished on device %s (%d ONUs)", req.Action, job.ID, job.DeviceID, job.Total)
+	return nil, nil
+}
+
+// addFilterTargets resolves the targets of a filter request against the OLT
+// and stores them as job items
+func (s *BulkService) addFilterTargets(job *database.Job, req *BulkONUActionRequest) error {
+	targets, err := s.resolveTargets(job.DeviceID, req)
+	if err != nil {
+		return err
+	}
+	if len(targets) == 0 {
+		return fmt.Errorf("no ONUs matched the request")
+	}
+	if err := s.jobService.AddItems(job.ID, targets); err != nil {
+		return fmt.Errorf("failed to create job items: %w", err)
+	}
+	if err := s.db.Where("job_id = ?", job.ID).Order("id ASC").Find(&job.Items).Error; err != nil {
+		return fmt.Errorf("failed to load job items: %w", err)
+	}
+	job.Total = len(job.Items)
+	return nil
+}
+
+// runItem applies the bulk action to one ONU and returns the audit action name
+func (s *BulkService) runItem(deviceID, onuID string, req *BulkONUActionRequest) (string, map[string]interface{}, error) {
+	switch req.Action {
+	case "rename":
+		name := req.Name
+		if perONU, ok := req.Names[onuID]; ok && strings.TrimSpace(perONU) != "" {
+			name = perONU
+		}
+		err := s.onuService.UpdateONUName(deviceID, onuID, name)
+		return "onu.name.updated", map[string]interface{}{"name": name}, err
+	case "delete":
+		err := s.onuService.DeleteONU(deviceID, onuID)
+		return "onu.deleted", map[string]interface{}{}, err
+	default:
+		err := s.onuService.PerformAction(deviceID, onuID, req.Action)
+		return "onu.action." + req.Action, map[string]interface{}{"action": req.Action}, err
+	}
+}
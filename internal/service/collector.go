@@ -0,0 +1,350 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/parser"
+
+	"gorm.io/gorm"
+)
+
+// CollectorDeviceStatus reports the last collection of one device
+type CollectorDeviceStatus struct {
+	DeviceID      string            `json:"device_id"`
+	Running       bool              `json:"running"`
+	LastRunAt     *time.Time        `json:"last_run_at,omitempty"`
+	LastSuccessAt *time.Time        `json:"last_success_at,omitempty"`
+	DurationMs    int64             `json:"duration_ms"`
+	ONUs          int               `json:"onus"`
+	PONs          int               `json:"pons"`
+	FailedPONs    int               `json:"failed_pons"`
+	Error         string            `json:"error,omitempty"`
+	PONErrors     map[string]string `json:"pon_errors,omitempty"`
+	TrafficErrors int               `json:"traffic_errors,omitempty"`
+	Runs          int               `json:"runs"`
+	Failures      int               `json:"failures"`
+}
+
+// CollectorStatus reports the state of the telemetry collector
+type CollectorStatus struct {
+	Enabled        bool                    `json:"enabled"`
+	Interval       string                  `json:"interval"`
+	Stagger        string                  `json:"stagger"`
+	Cycles         int                     `json:"cycles"`
+	LastCycleAt    *time.Time              `json:"last_cycle_at,omitempty"`
+	NextCycleAt    *time.Time              `json:"next_cycle_at,omitempty"`
+	LastCycleError string                  `json:"last_cycle_error,omitempty"`
+	Devices        []CollectorDeviceStatus `json:"devices"`
+}
+
+// Collector polls the ONUs of every device on a fixed interval and writes
+// them to the ONU history log, so history does not depend on page views.
+type Collector struct {
+	db  *gorm.DB
+	cfg *config.Config
+
+	mu      sync.Mutex
+	status  CollectorStatus
+	devices map[string]*CollectorDeviceStatus
+	health  map[string]*DeviceHealth // ONU health of each device's last successful run
+}
+
+// NewCollector creates a new Collector
+func NewCollector(db *gorm.DB, cfg *config.Config) *Collector {
+	return &Collector{
+		db:      db,
+		cfg:     cfg,
+		devices: map[string]*CollectorDeviceStatus{},
+		health:  map[string]*DeviceHealth{},
+	}
+}
+
+// Start launches the collection loop
+func (c *Collector) Start() {
+	interval := c.interval()
+	c.status = CollectorStatus{
+		Enabled:  c.cfg.Collector.Enabled,
+		Interval: interval.String(),
+		Stagger:  c.stagger(interval).String(),
+	}
+	if !c.cfg.Collector.Enabled {
+		log.Printf("[COLLECTOR] Disabled by configuration")
+		return
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			c.runCycle(interval)
+			<-ticker.C
+		}
+	}()
+	log.Printf("[COLLECTOR] Started (interval %s)", interval)
+}
+
+// Health returns the ONU health of a device from its last successful run,
+// nil when it has none, and the error of its last run
+func (c *Collector) Health(deviceID string) (*DeviceHealth, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	lastError := ""
+	if status, ok := c.devices[deviceID]; ok {
+		lastError = status.Error
+	}
+	return c.health[deviceID], lastError
+}
+
+// Status returns a snapshot of the collector state
+func (c *Collector) Status() CollectorStatus {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	status := c.status
+	status.Devices = make([]CollectorDeviceStatus, 0, len(c.devices))
+	for _, device := range c.devices {
+		status.Devices = append(status.Devices, *device)
+	}
+	sort.Slice(status.Devices, func(i, j int) bool {
+		return status.Devices[i].DeviceID < status.Devices[j].DeviceID
+	})
+	return status
+}
+
+func (c *Collector) interval() time.Duration {
+	if c.cfg.Collector.Interval <= 0 {
+		return 5 * time.Minute
+	}
+	return c.cfg.Collector.Interval
+}
+
+// stagger never exceeds half the interval so a cycle finishes starting
+// devices well before the next one begins
+func (c *Collector) stagger(interval time.Duration) time.Duration {
+	stagger := c.cfg.Collector.Stagger
+	if stagger < 0 {
+		stagger = 0
+	}
+	if stagger > interval/2 {
+		stagger = interval / 2
+	}
+	return stagger
+}
+
+// runCycle starts one collection per device, spreading start times over the
+// stagger window. A device still being collected from the previous cycle is
+// skipped.
+func (c *Collector) runCycle(interval time.Duration) {
+	now := time.Now()
+	next := now.Add(interval)
+
+	devices, err := NewDeviceService(c.db, c.cfg).GetAll()
+
+	c.mu.Lock()
+	c.status.Cycles++
+	c.status.LastCycleAt = &now
+	c.status.NextCycleAt = &next
+	c.status.LastCycleError = ""
+	if err != nil {
+		c.status.LastCycleError = err.Error()
+		c.mu.Unlock()
+		log.Printf("[COLLECTOR] Failed to list devices: %v", err)
+		return
+	}
+
+	// Forget devices that were deleted
+	known := make(map[string]bool, len(devices))
+	for _, device := range devices {
+		known[device.ID] = true
+	}
+	for id := range c.devices {
+		if !known[id] {
+			delete(c.devices, id)
+			delete(c.health, id)
+		}
+	}
+	c.mu.Unlock()
+
+	if len(devices) == 0 {
+		return
+	}
+	step := c.stagger(interval) / time.Duration(len(devices))
+	for i, device := range devices {
+		deviceID := device.ID
+		delay := step * time.Duration(i)
+		go func() {
+			if delay > 0 {
+				time.Sleep(delay)
+			}
+			c.collectDevice(deviceID)
+		}()
+	}
+}
+
+func (c *Collector) collectDevice(deviceID string) {
+	c.mu.Lock()
+	status, ok := c.devices[deviceID]
+	if !ok {
+		status = &CollectorDeviceStatus{DeviceID: deviceID}
+		c.devices[deviceID] = status
+	}
+	if status.Running {
+		c.mu.Unlock()
+		log.Printf("[COLLECTOR] Device %s is still being collected, skipping", deviceID)
+		return
+	}
+	status.Running = true
+	c.mu.Unlock()
+
+	started := time.Now()
+	onuSvc := NewONUService(c.db, c.cfg, NewDeviceService(c.db, c.cfg))
+	result, err := onuSvc.GetAllONUsWithResults(deviceID, "")
+	if err == nil {
+		err = WriteONULogs(c.db, deviceID, result.ONUs, started, c.cfg.Collector.BatchSize)
+	}
+	trafficErrors := 0
+	if err == nil && c.cfg.Traffic.Collect {
+		trafficErrors = c.sampleTraffic(deviceID, result.ONUs)
+	}
+	if c.cfg.Alerts.Enabled {
+		c.evaluateAlerts(deviceID, result, err)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	status.Running = false
+	status.LastRunAt = &started
+	status.DurationMs = time.Since(started).Milliseconds()
+	status.Runs++
+	if err != nil {
+		status.Error = err.Error()
+		status.Failures++
+		log.Printf("[COLLECTOR] Device %s failed: %v", deviceID, err)
+		return
+	}
+
+	status.ONUs = len(result.ONUs)
+	status.PONs = len(result.PONs)
+	status.FailedPONs = result.FailedPONs
+	status.Error = ""
+	status.PONErrors = nil
+	status.TrafficErrors = trafficErrors
+	if result.FailedPONs > 0 {
+		status.Error = fmt.Sprintf("%d of %d PONs could not be read", result.FailedPONs, len(result.PONs))
+		status.PONErrors = map[string]string{}
+		for _, pon := range result.PONs {
+			if !pon.Success {
+				status.PONErrors[pon.PONID] = pon.Error
+			}
+		}
+	}
+	status.LastSuccessAt = &started
+	c.health[deviceID] = SummarizeHealth(result.ONUs, started)
+}
+
+// sampleTraffic records a traffic sample for every online ONU and returns the
+// number of ONUs that could not be sampled
+func (c *Collector) sampleTraffic(deviceID string, onus []parser.ONUResponse) int {
+	trafficSvc := NewTrafficService(c.db, c.cfg, NewDeviceService(c.db, c.cfg))
+	failed := 0
+	for _, onu := range onus {
+		if onu.Status != "online" {
+			continue
+		}
+		if _, err := trafficSvc.Sample(deviceID, onu.ONUID); err != nil {
+			failed++
+		}
+	}
+	if failed > 0 {
+		log.Printf("[COLLECTOR] Device %s: %d ONU traffic samples failed", deviceID, failed)
+	}
+	return failed
+}
+
+// evaluateAlerts runs the alert rules against the data of one collection.
+// Reachability is only checked when no ONU data could be read.
+func (c *Collector) evaluateAlerts(deviceID string, result *DeviceONUsResult, collectErr error) {
+	deviceSvc := NewDeviceService(c.db, c.cfg)
+	alertSvc := NewAlertService(c.db, c.cfg)
+
+	snapshot := &AlertSnapshot{DeviceID: deviceID, Reachable: true}
+	if collectErr == nil {
+		snapshot.ONUs = result.ONUs
+		snapshot.ONUsKnown = true
+		snapshot.FailedPONs = map[string]bool{}
+		for _, pon := range result.PONs {
+			if !pon.Success {
+				snapshot.FailedPONs[pon.PONID] = true
+			}
+		}
+	}
+	// The PON list may come from cache, so a device where every PON failed
+	// is checked as well
+	if collectErr != nil || (len(result.PONs) > 0 && result.FailedPONs == len(result.PONs)) {
+		snapshot.Reachable, snapshot.ReachError = c.reachability(deviceSvc, deviceID)
+	}
+
+	if snapshot.Reachable && alertSvc.NeedsSystemInfo(deviceID) {
+		snapshot.System = c.systemInfo(deviceSvc, deviceID)
+	}
+
+	if err := alertSvc.Evaluate(snapshot, time.Now()); err != nil {
+		log.Printf("[ALERT] Evaluation failed for device %s: %v", deviceID, err)
+	}
+}
+
+// reachability returns the live status kept by the reachability poller. The
+// device is only probed here when the poller is disabled.
+func (c *Collector) reachability(deviceSvc *DeviceService, deviceID string) (bool, string) {
+	if !c.cfg.Reachability.Enabled {
+		status, err := deviceSvc.CheckStatus(deviceID)
+		if err != nil {
+			return true, ""
+		}
+		reachable, _ := status["reachable"].(bool)
+		reachError, _ := status["error"].(string)
+		return reachable, reachError
+	}
+
+	device, err := deviceSvc.GetByID(deviceID)
+	if err != nil || device.LiveStatus != DeviceDown {
+		return true, ""
+	}
+	return false, device.LastError
+}
+
+// systemInfo returns the CPU and memory usage for alert rules from the
+// latest sample recorded by the reachability poller, or nil when there is no
+// recent one. The OLT is only queried here when the poller does not record
+// samples.
+func (c *Collector) systemInfo(deviceSvc *DeviceService, deviceID string) *parser.SystemInfoResponse {
+	if !c.cfg.Reachability.Enabled || !c.cfg.SystemMetrics.Collect {
+		info, err := deviceSvc.GetSystemInfo(deviceID)
+		if err != nil {
+			return nil
+		}
+		return info
+	}
+
+	interval := c.cfg.Reachability.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+	systemSvc := NewSystemMetricsService(c.db, c.cfg, deviceSvc)
+	sample, err := systemSvc.Latest(deviceID, time.Now().Add(-2*interval).Local())
+	if err != nil || sample == nil {
+		return nil
+	}
+	return &parser.SystemInfoResponse{
+		CPUUsage:      sample.CPUUsage,
+		MemoryUsage:   sample.MemoryUsage,
+		RunTime:       sample.RunTime,
+		UptimeSeconds: sample.UptimeSeconds,
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CronSchedule is a parsed standard 5-field cron expression
+// (minute hour day-of-month month day-of-week).
+type CronSchedule struct {
+	minute  uint64
+	hour    uint64
+	dom     uint64
+	month   uint64
+	dow     uint64
+	domStar bool
+	dowStar bool
+}
+
+var cronMacros = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseCron parses expressions such as "0 3 * * *", "*/15 * * * 1-5" or "@daily"
+func ParseCron(expr string) (*CronSchedule, error) {
+	expr = strings.TrimSpace(expr)
+	if macro, ok := cronMacros[strings.ToLower(expr)]; ok {
+		expr = macro
+	}
+
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields", expr)
+	}
+
+	type fieldDef struct {
+		name     string
+		min, max int
+	}
+	defs := []fieldDef{
+		{"minute", 0, 59},
+		{"hour", 0, 23},
+		{"day-of-month", 1, 31},
+		{"month", 1, 12},
+		{"day-of-week", 0, 7},
+	}
+
+	var bits [5]uint64
+	for i, field := range fields {
+		parsed, err := parseCronField(field, defs[i].min, defs[i].max)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron %s field %q: %w", defs[i].name, field, err)
+		}
+		bits[i] = parsed
+	}
+
+	// Sunday may be written as 0 or 7.
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	return &CronSchedule{
+		minute:  bits[0],
+		hour:    bits[1],
+		dom:     bits[2],
+		month:   bits[3],
+		dow:     bits[4],
+		domStar: fields[2] == "*" || fields[2] == "?",
+		dowStar: fields[4] == "*" || fields[4] == "?",
+	}, nil
+}
+
+func parseCronField(field string, min, max int) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if idx := strings.Index(part, "/"); idx >= 0 {
+			parsed, err := strconv.Atoi(part[idx+1:])
+			if err != nil || parsed <= 0 {
+				return 0, fmt.Errorf("invalid step %q", part[idx+1:])
+			}
+			step = parsed
+			part = part[:idx]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*" || part == "?":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			a, errA := strconv.Atoi(bounds[0])
+			b, errB := strconv.Atoi(bounds[1])
+			if errA != nil || errB != nil {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+			lo, hi = a, b
+		default:
+			value, err := strconv.Atoi(part)
+			if err != nil {
+				return 0, fmt.Errorf("invalid value %q", part)
+			}
+			lo, hi = value, value
+			if step > 1 {
+				hi = max
+			}
+		}
+
+		if lo < min || hi > max || lo > hi {
+			return 0, fmt.Errorf("value out of range %d-%d", min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// Next returns the first activation time strictly after t, or the zero time
+// when none exists within five years.
+//
+// The search runs on wall-clock time in t's location, so daylight saving
+// changes neither repeat nor drop a run: a time repeated when clocks go back
+// fires once, at its first occurrence, and a time skipped when clocks go
+// forward fires when the clocks reach it after the change.
+func (c *CronSchedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	wall := wallClock(t).Add(time.Minute)
+	limit := wall.AddDate(5, 0, 0)
+
+	for wall.Before(limit) {
+		if c.month&(1<<uint(wall.Month())) == 0 {
+			wall = time.Date(wall.Year(), wall.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if !c.dayMatches(wall) {
+			wall = time.Date(wall.Year(), wall.Month(), wall.Day()+1, 0, 0, 0, 0, time.UTC)
+			continue
+		}
+		if c.hour&(1<<uint(wall.Hour())) == 0 {
+			wall = time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour()+1, 0, 0, 0, time.UTC)
+			continue
+		}
+		if c.minute&(1<<uint(wall.Minute())) == 0 {
+			wall = wall.Add(time.Minute)
+			continue
+		}
+
+		next := time.Date(wall.Year(), wall.Month(), wall.Day(), wall.Hour(), wall.Minute(), 0, 0, loc)
+		// Inside a forward gap time.Date may return an instant whose wall
+		// clock is earlier than requested; move to the end of the gap.
+		for wallClock(next).Before(wall) {
+			next = next.Add(time.Minute)
+		}
+		if next.After(t) {
+			return next
+		}
+		wall = wall.Add(time.Minute)
+	}
+	return time.Time{}
+}
+
+// wallClock returns t's local date and time as the same fields in UTC
+func wallClock(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), 0, 0, time.UTC)
+}
+
+// dayMatches applies the cron rule that when both day fields are restricted,
+// a day matches if either one does.
+func (c *CronSchedule) dayMatches(t time.Time) bool {
+	domMatch := c.dom&(1<<uint(t.Day())) != 0
+	dowMatch := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domStar || c.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
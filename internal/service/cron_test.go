@@ -0,0 +1,164 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	tests := []struct {
+		expr    string
+		wantErr bool
+	}{
+		{"0 3 * * *", false},
+		{"*/15 * * * 1-5", false},
+		{"0,30 8-18/2 1,15 * *", false},
+		{"0 0 * * 7", false},
+		{"@daily", false},
+		{"@Weekly", false},
+		{"  5 4 * * ?  ", false},
+		{"", true},
+		{"0 3 * *", true},
+		{"0 3 * * * *", true},
+		{"60 * * * *", true},
+		{"* 24 * * *", true},
+		{"* * 0 * *", true},
+		{"* * 32 * *", true},
+		{"* * * 13 *", true},
+		{"* * * * 8", true},
+		{"5-1 * * * *", true},
+		{"*/0 * * * *", true},
+		{"a * * * *", true},
+		{"1-a * * * *", true},
+		{"@often", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			_, err := ParseCron(tt.expr)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseCron(%q) error = %v, wantErr %v", tt.expr, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	utc := func(year int, month time.Month, day, hour, min int) time.Time {
+		return time.Date(year, month, day, hour, min, 0, 0, time.UTC)
+	}
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want time.Time
+	}{
+		{"later today", "0 3 * * *", utc(2026, 5, 10, 1, 20), utc(2026, 5, 10, 3, 0)},
+		{"strictly after", "0 3 * * *", utc(2026, 5, 10, 3, 0), utc(2026, 5, 11, 3, 0)},
+		{"seconds are dropped", "* * * * *", time.Date(2026, 5, 10, 3, 0, 59, 0, time.UTC), utc(2026, 5, 10, 3, 1)},
+		{"step", "*/15 * * * *", utc(2026, 5, 10, 3, 16), utc(2026, 5, 10, 3, 30)},
+		{"hour rolls over the day", "30 * * * *", utc(2026, 5, 10, 23, 45), utc(2026, 5, 11, 0, 30)},
+		{"weekdays skip the weekend", "0 9 * * 1-5", utc(2026, 5, 8, 10, 0), utc(2026, 5, 11, 9, 0)},
+		{"sunday as 7", "0 0 * * 7", utc(2026, 5, 11, 0, 0), utc(2026, 5, 17, 0, 0)},
+		{"day 31 skips short months", "0 0 31 * *", utc(2026, 2, 1, 0, 0), utc(2026, 3, 31, 0, 0)},
+		{"day 31 after april", "0 0 31 * *", utc(2026, 3, 31, 0, 0), utc(2026, 5, 31, 0, 0)},
+		{"day 30 skips february", "0 12 30 * *", utc(2026, 1, 30, 12, 0), utc(2026, 3, 30, 12, 0)},
+		{"leap day", "0 0 29 2 *", utc(2026, 3, 1, 0, 0), utc(2028, 2, 29, 0, 0)},
+		{"month rolls over the year", "0 0 1 * *", utc(2026, 12, 15, 0, 0), utc(2027, 1, 1, 0, 0)},
+		{"yearly", "@yearly", utc(2026, 1, 1, 0, 0), utc(2027, 1, 1, 0, 0)},
+		{"day of month or day of week", "0 0 13 * 5", utc(2026, 5, 9, 0, 0), utc(2026, 5, 13, 0, 0)},
+		{"day of week or day of month", "0 0 13 * 5", utc(2026, 5, 13, 0, 0), utc(2026, 5, 15, 0, 0)},
+		{"never", "0 0 30 2 *", utc(2026, 1, 1, 0, 0), time.Time{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			if got := schedule.Next(tt.from); !got.Equal(tt.want) {
+				t.Fatalf("Next(%s) = %s, want %s", tt.from, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCronNextDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+	// Clocks go forward from 02:00 to 03:00 on 2026-03-08 and back from
+	// 02:00 to 01:00 on 2026-11-01.
+	est := time.FixedZone("EST", -5*3600)
+	edt := time.FixedZone("EDT", -4*3600)
+
+	tests := []struct {
+		name string
+		expr string
+		from time.Time
+		want []time.Time
+	}{
+		{
+			name: "skipped time runs after the jump",
+			expr: "30 2 * * *",
+			from: time.Date(2026, 3, 8, 0, 0, 0, 0, est),
+			want: []time.Time{
+				time.Date(2026, 3, 8, 3, 0, 0, 0, edt),
+				time.Date(2026, 3, 9, 2, 30, 0, 0, edt),
+			},
+		},
+		{
+			name: "time after the jump",
+			expr: "30 3 * * *",
+			from: time.Date(2026, 3, 8, 0, 0, 0, 0, est),
+			want: []time.Time{time.Date(2026, 3, 8, 3, 30, 0, 0, edt)},
+		},
+		{
+			name: "quarter hours across the jump",
+			expr: "*/15 * * * *",
+			from: time.Date(2026, 3, 8, 1, 40, 0, 0, est),
+			want: []time.Time{
+				time.Date(2026, 3, 8, 1, 45, 0, 0, est),
+				time.Date(2026, 3, 8, 3, 0, 0, 0, edt),
+				time.Date(2026, 3, 8, 3, 15, 0, 0, edt),
+			},
+		},
+		{
+			name: "repeated time runs once",
+			expr: "30 1 * * *",
+			from: time.Date(2026, 11, 1, 0, 0, 0, 0, edt),
+			want: []time.Time{
+				time.Date(2026, 11, 1, 1, 30, 0, 0, edt),
+				time.Date(2026, 11, 2, 1, 30, 0, 0, est),
+			},
+		},
+		{
+			name: "daily run keeps its wall clock",
+			expr: "0 3 * * *",
+			from: time.Date(2026, 10, 31, 12, 0, 0, 0, edt),
+			want: []time.Time{
+				time.Date(2026, 11, 1, 3, 0, 0, 0, est),
+				time.Date(2026, 11, 2, 3, 0, 0, 0, est),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCron(tt.expr)
+			if err != nil {
+				t.Fatalf("ParseCron(%q): %v", tt.expr, err)
+			}
+			from := tt.from.In(loc)
+			for _, want := range tt.want {
+				got := schedule.Next(from)
+				if !got.Equal(want) {
+					t.Fatalf("Next(%s) = %s, want %s", from, got, want.In(loc))
+				}
+				if got.Location() != loc {
+					t.Fatalf("Next(%s) returned location %s, want %s", from, got.Location(), loc)
+				}
+				from = got
+			}
+		})
+	}
+}
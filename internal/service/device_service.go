@@ -50,6 +50,11 @@ func (s *DeviceService) Create(req *database.DeviceRequest) (*database.Device, e
 		UpdatedAt: time.Now(),
 	}
 
+	// Keep the polled reachability of a device being re-saved
+	if existing, err := s.GetByID(req.ID); err == nil {
+		device.DeviceReachability = existing.DeviceReachability
+	}
+
 	// Upsert: Save will create or update based on primary key
 	if err := s.db.Save(device).Error; err != nil {
 		return nil, fmt.Errorf("failed to save device: %w", err)
@@ -67,12 +72,36 @@ func (s *DeviceService) GetAll() ([]database.Device, error) {
 	return devices, nil
 }
 
+// GetAllWithTags returns all devices with their tags, optionally limited to
+// devices carrying every tag in filterTags
+func (s *DeviceService) GetAllWithTags(filterTags []string) ([]database.Device, error) {
+	query := s.db.Preload("Tags", func(db *gorm.DB) *gorm.DB {
+		return db.Order("tags.name ASC")
+	})
+	if len(filterTags) > 0 {
+		ids, err := NewTagService(s.db).DeviceIDsWithTags(filterTags)
+		if err != nil {
+			return nil, err
+		}
+		if len(ids) == 0 {
+			return []database.Device{}, nil
+		}
+		query = query.Where("id IN ?", ids)
+	}
+
+	var devices []database.Device
+	if err := query.Find(&devices).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch devices: %w", err)
+	}
+	return devices, nil
+}
+
 // GetByID returns a device by ID
 func (s *DeviceService) GetByID(id string) (*database.Device, error) {
 	var device database.Device
 	if err := s.db.Where("id = ?", id).First(&device).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return nil, fmt.Errorf("device '%s' not found", id)
+			return nil, fmt.Errorf("device '%s' %w", id, ErrNotFound)
 		}
 		return nil, fmt.Errorf("failed to fetch device: %w", err)
 	}
@@ -81,54 +110,105 @@ func (s *DeviceService) GetByID(id string) (*database.Device, error) {
 
 // Update updates an existing device
 func (s *DeviceService) Update(id string, req *database.DeviceUpdateRequest) (*database.Device, error) {
-	device, err := s.GetByID(id)
-	if err != nil {
+	if _, err := s.GetByID(id); err != nil {
 		return nil, err
 	}
 
-	// Update fields if provided
+	// Update fields if provided. Only the editable columns are written, so
+	// the reachability poller's concurrent status updates are kept.
+	updates := map[string]interface{}{}
 	if req.Name != "" {
-		device.Name = req.Name
+		updates["name"] = req.Name
 	}
 	if req.BaseURL != "" {
-		device.BaseURL = req.BaseURL
+		updates["base_url"] = req.BaseURL
 	}
 	if req.Port != nil && *req.Port > 0 {
-		device.Port = *req.Port
+		updates["port"] = *req.Port
 	}
 	if req.Username != "" {
-		device.Username = req.Username
+		updates["username"] = req.Username
 	}
 	if req.Password != "" {
-		device.Password = req.Password
+		updates["password"] = req.Password
 	}
 	if req.Status != "" {
-		device.Status = req.Status
+		updates["status"] = req.Status
 	}
-	device.UpdatedAt = time.Now()
+	updates["updated_at"] = time.Now()
 
-	if err := s.db.Save(device).Error; err != nil {
+	if err := s.db.Model(&database.Device{}).Where("id = ?", id).Updates(updates).Error; err != nil {
 		return nil, fmt.Errorf("failed to update device: %w", err)
 	}
 
-	return device, nil
+	return s.GetByID(id)
 }
 
-// Delete removes a device
+// deviceOwnedModels are the tables whose rows belong to a single device and
+// are removed with it. Schedules, jobs and alert rules for all devices have
+// an empty device_id and are kept.
+var deviceOwnedModels = []interface{}{
+	&database.ONULog{}, &database.ONUMetricRollup{}, &database.ONUTrafficSample{},
+	&database.ONUOpticalTrend{}, &database.DeviceStatusEvent{}, &database.DeviceSystemSample{},
+	&database.DeviceSystemRollup{}, &database.DeviceReboot{}, &database.ONUInventory{},
+	&database.ONUInventoryEvent{}, &database.Subscriber{}, &database.ONUTag{},
+	&database.Alert{}, &database.AlertSilence{}, &database.AlertRule{}, &database.Incident{},
+	&database.MaintenanceWindow{}, &database.Schedule{}, &database.ScheduleRun{}, &database.Job{},
+}
+
+// Delete removes a device together with its tags, subscribers, inventory,
+// alerts and alert rules, schedules, jobs, history and cached OLT data
 func (s *DeviceService) Delete(id string) error {
-	result := s.db.Where("id = ?", id).Delete(&database.Device{})
-	if result.Error != nil {
-		return fmt.Errorf("failed to delete device: %w", result.Error)
-	}
-	if result.RowsAffected == 0 {
-		return fmt.Errorf("device '%s' not found", id)
-	}
-	return nil
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&database.Device{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete device: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("device '%s' %w", id, ErrNotFound)
+		}
+		return deleteDeviceData(tx, []string{id})
+	})
 }
 
-// DeleteAll removes all devices
+// DeleteAll removes all devices and their data
 func (s *DeviceService) DeleteAll() error {
-	return s.db.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&database.Device{}).Error
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var ids []string
+		if err := tx.Model(&database.Device{}).Pluck("id", &ids).Error; err != nil {
+			return fmt.Errorf("failed to list devices: %w", err)
+		}
+		if err := tx.Session(&gorm.Session{AllowGlobalUpdate: true}).Delete(&database.Device{}).Error; err != nil {
+			return fmt.Errorf("failed to delete devices: %w", err)
+		}
+		return deleteDeviceData(tx, ids)
+	})
+}
+
+// deleteDeviceData removes the rows owned by the given devices
+func deleteDeviceData(tx *gorm.DB, ids []string) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	if err := tx.Exec("DELETE FROM device_tags WHERE device_id IN ?", ids).Error; err != nil {
+		return fmt.Errorf("failed to delete device tags: %w", err)
+	}
+	if err := tx.Where("job_id IN (?)", tx.Model(&database.Job{}).Select("id").Where("device_id IN ?", ids)).
+		Delete(&database.JobItem{}).Error; err != nil {
+		return fmt.Errorf("failed to delete device job items: %w", err)
+	}
+	for _, model := range deviceOwnedModels {
+		if err := tx.Where("device_id IN ?", ids).Delete(model).Error; err != nil {
+			return fmt.Errorf("failed to delete device data: %w", err)
+		}
+	}
+	for _, id := range ids {
+		if err := tx.Where("key = ? OR key LIKE ? OR key LIKE ?", "pons:"+id, "onus:"+id+":%", "onu-detail:"+id+":%").
+			Delete(&database.CacheEntry{}).Error; err != nil {
+			return fmt.Errorf("failed to delete cached device data: %w", err)
+		}
+	}
+	return nil
 }
 
 // CheckStatus checks if a device is reachable
@@ -146,6 +226,26 @@ func (s *DeviceService) CheckStatus(id string) (map[string]interface{}, error) {
 		"checked_at": time.Now(),
 	}
 
+	address, err := dialAddress(device)
+	if err != nil {
+		status["error"] = err.Error()
+		return status, nil
+	}
+
+	conn, err := net.DialTimeout("tcp", address, s.cfg.Scraper.Timeout)
+	if err != nil {
+		status["error"] = err.Error()
+		return status, nil
+	}
+	_ = conn.Close()
+
+	status["reachable"] = true
+	return status, nil
+}
+
+// dialAddress returns the host:port of a device's web interface. A port in
+// the base URL takes precedence over the device port.
+func dialAddress(device *database.Device) (string, error) {
 	baseURL := strings.TrimRight(device.BaseURL, "/")
 	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
 		baseURL = "http://" + baseURL
@@ -153,11 +253,9 @@ func (s *DeviceService) CheckStatus(id string) (map[string]interface{}, error) {
 
 	parsed, err := url.Parse(baseURL)
 	if err != nil || parsed.Hostname() == "" {
-		status["error"] = "invalid base URL"
-		return status, nil
+		return "", fmt.Errorf("invalid base URL")
 	}
 
-	host := parsed.Hostname()
 	port := 0
 	if parsed.Port() != "" {
 		if parsedPort, parseErr := strconv.Atoi(parsed.Port()); parseErr == nil {
@@ -174,17 +272,7 @@ func (s *DeviceService) CheckStatus(id string) (map[string]interface{}, error) {
 			port = 80
 		}
 	}
-
-	address := fmt.Sprintf("%s:%d", host, port)
-	conn, err := net.DialTimeout("tcp", address, s.cfg.Scraper.Timeout)
-	if err != nil {
-		status["error"] = err.Error()
-		return status, nil
-	}
-	_ = conn.Close()
-
-	status["reachable"] = true
-	return status, nil
+	return net.JoinHostPort(parsed.Hostname(), strconv.Itoa(port)), nil
 }
 
 // CheckConnectionPayload checks OLT connectivity using payload fields (before save).
@@ -232,7 +320,7 @@ func (s *DeviceService) CheckConnectionPayload(req *database.DeviceConnectionChe
 	status["host"] = host
 	status["port"] = port
 
-	address := fmt.Sprintf("%s:%d", host, port)
+	address := net.JoinHostPort(host, strconv.Itoa(port))
 	conn, err := net.DialTimeout("tcp", address, s.cfg.Scraper.Timeout)
 	if err != nil {
 		status["error"] = err.Error()
@@ -270,7 +358,12 @@ func (s *DeviceService) GetClient(deviceID string) (*scraper.Client, error) {
 	if err != nil {
 		return nil, err
 	}
+	return s.clientFor(device, s.cfg.Scraper.Timeout), nil
+}
 
+// clientFor builds a rate-limited HTTP client for a device with the given
+// request timeout
+func (s *DeviceService) clientFor(device *database.Device, timeout time.Duration) *scraper.Client {
 	// Build base URL with port
 	baseURL := strings.TrimRight(device.BaseURL, "/")
 	if !strings.HasPrefix(baseURL, "http://") && !strings.HasPrefix(baseURL, "https://") {
@@ -290,7 +383,9 @@ func (s *DeviceService) GetClient(deviceID string) (*scraper.Client, error) {
 		}
 	}
 
-	return scraper.NewClient(baseURL, device.Username, device.Password, s.cfg.Scraper.Timeout), nil
+	client := scraper.NewClient(baseURL, device.Username, device.Password, timeout)
+	client.SetLimiter(scraper.LimiterFor(device.ID, s.cfg.Scraper.DeviceConcurrency, s.cfg.Scraper.DeviceMinInterval))
+	return client
 }
 
 // GetSystemInfo fetches system information from the OLT device
@@ -299,7 +394,11 @@ func (s *DeviceService) GetSystemInfo(deviceID string) (*parser.SystemInfoRespon
 	if err != nil {
 		return nil, err
 	}
+	return systemInfo(client)
+}
 
+// systemInfo reads and parses the system page, trying both known endpoints
+func systemInfo(client *scraper.Client) (*parser.SystemInfoResponse, error) {
 	p := parser.NewParser()
 	endpoints := []string{"/system.asp", "/syste.asp"}
 	var errors []string
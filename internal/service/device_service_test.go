@@ -0,0 +1,127 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// testDB opens a migrated database in a temporary directory
+func testDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := database.Init(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("init database: %v", err)
+	}
+	t.Cleanup(func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+	return db
+}
+
+func createTestDevice(t *testing.T, db *gorm.DB, id string) {
+	t.Helper()
+	device := database.Device{ID: id, Name: id, BaseURL: "127.0.0.1", Username: "admin", Password: "x"}
+	if err := db.Create(&device).Error; err != nil {
+		t.Fatalf("create device: %v", err)
+	}
+}
+
+func countRows(t *testing.T, db *gorm.DB, model interface{}, query string, args ...interface{}) int64 {
+	t.Helper()
+	var count int64
+	if err := db.Model(model).Where(query, args...).Count(&count).Error; err != nil {
+		t.Fatalf("count: %v", err)
+	}
+	return count
+}
+
+func TestDeleteDeviceRemovesSchedulesJobsAndRules(t *testing.T) {
+	db := testDB(t)
+	createTestDevice(t, db, "olt1")
+	createTestDevice(t, db, "olt2")
+
+	next := time.Now().Add(time.Hour)
+	schedules := []database.Schedule{
+		{Name: "reboot", Kind: ScheduleKindONUAction, DeviceID: "olt1", Cron: "0 3 * * *", Enabled: true, NextRunAt: &next},
+		{Name: "save olt2", Kind: ScheduleKindSaveConfig, DeviceID: "olt2", Cron: "0 4 * * *", Enabled: true, NextRunAt: &next},
+		{Name: "save all", Kind: ScheduleKindSaveConfig, Cron: "0 5 * * *", Enabled: true, NextRunAt: &next},
+	}
+	if err := db.Create(&schedules).Error; err != nil {
+		t.Fatalf("create schedules: %v", err)
+	}
+	runs := []database.ScheduleRun{
+		{ScheduleID: schedules[0].ID, DeviceID: "olt1", Status: "queued", ScheduledFor: time.Now()},
+		{ScheduleID: schedules[2].ID, DeviceID: "olt1", Status: "queued", ScheduledFor: time.Now()},
+		{ScheduleID: schedules[2].ID, DeviceID: "olt2", Status: "queued", ScheduledFor: time.Now()},
+	}
+	if err := db.Create(&runs).Error; err != nil {
+		t.Fatalf("create runs: %v", err)
+	}
+	jobs := []database.Job{
+		{Type: "onu.bulk_action", DeviceID: "olt1", Items: []database.JobItem{{Target: "0/1:1"}, {Target: "0/1:2"}}},
+		{Type: "onu.bulk_action", DeviceID: "olt2", Items: []database.JobItem{{Target: "0/1:1"}}},
+	}
+	if err := db.Create(&jobs).Error; err != nil {
+		t.Fatalf("create jobs: %v", err)
+	}
+	rules := []database.AlertRule{
+		{Name: "olt1 rx", Type: AlertONURxLow, DeviceID: "olt1", Enabled: true},
+		{Name: "all rx", Type: AlertONURxLow, Enabled: true},
+	}
+	if err := db.Create(&rules).Error; err != nil {
+		t.Fatalf("create rules: %v", err)
+	}
+
+	if err := NewDeviceService(db, &config.Config{}).Delete("olt1"); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+
+	checks := []struct {
+		name  string
+		model interface{}
+		query string
+		args  []interface{}
+		want  int64
+	}{
+		{"olt1 schedules", &database.Schedule{}, "device_id = ?", []interface{}{"olt1"}, 0},
+		{"other schedules", &database.Schedule{}, "device_id <> ?", []interface{}{"olt1"}, 2},
+		{"olt1 schedule runs", &database.ScheduleRun{}, "device_id = ?", []interface{}{"olt1"}, 0},
+		{"olt2 schedule runs", &database.ScheduleRun{}, "device_id = ?", []interface{}{"olt2"}, 1},
+		{"olt1 jobs", &database.Job{}, "device_id = ?", []interface{}{"olt1"}, 0},
+		{"olt1 job items", &database.JobItem{}, "job_id = ?", []interface{}{jobs[0].ID}, 0},
+		{"olt2 job items", &database.JobItem{}, "job_id = ?", []interface{}{jobs[1].ID}, 1},
+		{"olt1 rules", &database.AlertRule{}, "device_id = ?", []interface{}{"olt1"}, 0},
+		{"global rules", &database.AlertRule{}, "device_id = ?", []interface{}{""}, 1},
+	}
+	for _, check := range checks {
+		if got := countRows(t, db, check.model, check.query, check.args...); got != check.want {
+			t.Errorf("%s: got %d rows, want %d", check.name, got, check.want)
+		}
+	}
+
+	// The scheduler finds nothing left to fire for the deleted device
+	var due []database.Schedule
+	if err := db.Where("enabled = ? AND next_run_at IS NOT NULL", true).Find(&due).Error; err != nil {
+		t.Fatalf("due schedules: %v", err)
+	}
+	for _, schedule := range due {
+		if schedule.DeviceID == "olt1" {
+			t.Errorf("schedule %q of the deleted device is still due", schedule.Name)
+		}
+	}
+}
+
+func TestDeleteUnknownDevice(t *testing.T) {
+	db := testDB(t)
+	if err := NewDeviceService(db, &config.Config{}).Delete("missing"); err == nil {
+		t.Fatal("expected an error for an unknown device")
+	}
+}
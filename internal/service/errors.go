@@ -0,0 +1,52 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Error kinds handlers map to HTTP status codes with errors.Is. Services
+// wrap them with %w (e.g. "device 'x' not found") or build the error with
+// kindErrorf, invalidf or conflictf, so the message returned to clients is unchanged.
+var (
+	// ErrNotFound means the addressed record does not exist
+	ErrNotFound = errors.New("not found")
+	// ErrInvalidRange means a time range does not end after it starts
+	ErrInvalidRange = errors.New("from must be before to")
+	// ErrInvalidInput means the request failed validation
+	ErrInvalidInput = errors.New("invalid input")
+	// ErrConflict means the record is not in a state that allows the change
+	ErrConflict = errors.New("conflict")
+	// ErrNotConfigured means the feature is disabled or not set up in
+	// config.yaml
+	ErrNotConfigured = errors.New("not configured")
+	// ErrOLTRejected means the OLT answered a command with a login page or
+	// an error
+	ErrOLTRejected = errors.New("OLT rejected the request")
+)
+
+// kindError is an error of one of the kinds above that keeps its own
+// message
+type kindError struct {
+	kind error
+	msg  string
+}
+
+func (e *kindError) Error() string { return e.msg }
+
+func (e *kindError) Is(target error) bool { return target == e.kind }
+
+// kindErrorf formats an error that matches kind
+func kindErrorf(kind error, format string, args ...interface{}) error {
+	return &kindError{kind: kind, msg: fmt.Sprintf(format, args...)}
+}
+
+// invalidf formats a validation error
+func invalidf(format string, args ...interface{}) error {
+	return kindErrorf(ErrInvalidInput, format, args...)
+}
+
+// conflictf formats an error for a change the record's state does not allow
+func conflictf(format string, args ...interface{}) error {
+	return kindErrorf(ErrConflict, format, args...)
+}
@@ -0,0 +1,242 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// flapWindows are the sliding windows, ending at the report time, that flap
+// counts are reported for
+var flapWindows = []struct {
+	Label    string
+	Duration time.Duration
+}{
+	{"1h", time.Hour},
+	{"6h", 6 * time.Hour},
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+}
+
+// ONUFlapStat summarizes the status changes of one ONU. A flap is a drop
+// from online to any other status.
+type ONUFlapStat struct {
+	ONUID          string         `json:"onu_id"`
+	Name           string         `json:"name,omitempty"`
+	Status         string         `json:"status"` // latest logged status
+	Flaps          int            `json:"flaps"`
+	Transitions    int            `json:"transitions"`
+	Windows        map[string]int `json:"windows"`
+	PeakPerHour    int            `json:"peak_per_hour"` // most flaps in any sliding hour of the range
+	DownStatuses   map[string]int `json:"down_statuses,omitempty"`
+	Samples        int            `json:"samples"`
+	FirstFlapAt    *time.Time     `json:"first_flap_at,omitempty"`
+	LastFlapAt     *time.Time     `json:"last_flap_at,omitempty"`
+	flapTimestamps []time.Time
+}
+
+// FlapReport lists the ONUs of a device that flapped at least MinFlaps times
+type FlapReport struct {
+	DeviceID string        `json:"device_id"`
+	PONID    string        `json:"pon_id,omitempty"`
+	From     time.Time     `json:"from"`
+	To       time.Time     `json:"to"`
+	MinFlaps int           `json:"min_flaps"`
+	Scanned  int           `json:"scanned"`
+	Total    int           `json:"total"`
+	ONUs     []ONUFlapStat `json:"onus"`
+}
+
+// flapLogRow is one ONU log row read for flap detection
+type flapLogRow struct {
+	ONUID      string    `gorm:"column:on_uid"`
+	Name       string    `gorm:"column:name"`
+	Status     string    `gorm:"column:status"`
+	RecordedAt time.Time `gorm:"column:recorded_at"`
+}
+
+// FlapService detects ONUs bouncing between online and offline from the
+// status transitions in the ONU history log. Bounces shorter than the
+// collector interval are not visible in the log.
+type FlapService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+// NewFlapService creates a new FlapService
+func NewFlapService(db *gorm.DB, cfg *config.Config) *FlapService {
+	return &FlapService{db: db, cfg: cfg}
+}
+
+// MaxWindow is the longest range flaps can be computed over, bounded by the
+// retention of raw ONU logs
+func (s *FlapService) MaxWindow() time.Duration {
+	if s.cfg.Retention.Raw > 0 {
+		return s.cfg.Retention.Raw
+	}
+	return 7 * 24 * time.Hour
+}
+
+// Report computes flap statistics of every ONU of a device (optionally one
+// PON) between from and to, keeping ONUs with at least minFlaps flaps,
+// most flaps first
+func (s *FlapService) Report(deviceID, ponID string, from, to time.Time, minFlaps int) (*FlapReport, error) {
+	if !to.After(from) {
+		return nil, ErrInvalidRange
+	}
+	if minFlaps <= 0 {
+		minFlaps = 1
+	}
+
+	stats, err := s.scan(deviceID, ponID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &FlapReport{
+		DeviceID: deviceID,
+		PONID:    ponID,
+		From:     from.Local(),
+		To:       to.Local(),
+		MinFlaps: minFlaps,
+		Scanned:  len(stats),
+		ONUs:     []ONUFlapStat{},
+	}
+	for _, stat := range stats {
+		if stat.Flaps < minFlaps {
+			continue
+		}
+		stat.Windows = map[string]int{}
+		for _, window := range flapWindows {
+			if window.Duration > to.Sub(from).Round(time.Second) {
+				break
+			}
+			since := to.Add(-window.Duration)
+			count := 0
+			for _, at := range stat.flapTimestamps {
+				if !at.Before(since) {
+					count++
+				}
+			}
+			stat.Windows[window.Label] = count
+		}
+		stat.PeakPerHour = peakFlaps(stat.flapTimestamps, time.Hour)
+		report.ONUs = append(report.ONUs, *stat)
+	}
+	sort.SliceStable(report.ONUs, func(i, j int) bool {
+		if report.ONUs[i].Flaps != report.ONUs[j].Flaps {
+			return report.ONUs[i].Flaps > report.ONUs[j].Flaps
+		}
+		return compareONUIDs(report.ONUs[i].ONUID, report.ONUs[j].ONUID) < 0
+	})
+	report.Total = len(report.ONUs)
+	return report, nil
+}
+
+// Counts returns the number of flaps per ONU of a device between from and to
+func (s *FlapService) Counts(deviceID string, from, to time.Time) (map[string]int, error) {
+	stats, err := s.scan(deviceID, "", from, to)
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]int, len(stats))
+	for onuID, stat := range stats {
+		counts[onuID] = stat.Flaps
+	}
+	return counts, nil
+}
+
+// scan walks the ONU log of a device in time order and counts status
+// transitions per ONU. The last row before from is included so a change at
+// the start of the range is not missed.
+func (s *FlapService) scan(deviceID, ponID string, from, to time.Time) (map[string]*ONUFlapStat, error) {
+	// Stored timestamps are in local time; compare like with like
+	from, to = from.Local(), to.Local()
+
+	query := s.db.Model(&database.ONULog{}).
+		Select("on_uid, name, status, recorded_at").
+		Where("device_id = ? AND recorded_at >= ? AND recorded_at < ?", deviceID, from.Add(-s.lookBehind()), to)
+	if ponID != "" {
+		query = query.Where("on_uid LIKE ?", ponID+":%")
+	}
+	rows, err := query.Order("on_uid ASC, recorded_at ASC").Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read ONU logs: %w", err)
+	}
+	defer rows.Close()
+
+	stats := map[string]*ONUFlapStat{}
+	previous := map[string]string{}
+	for rows.Next() {
+		var row flapLogRow
+		if err := s.db.ScanRows(rows, &row); err != nil {
+			return nil, fmt.Errorf("failed to read ONU log row: %w", err)
+		}
+
+		last, seen := previous[row.ONUID]
+		previous[row.ONUID] = row.Status
+		if row.RecordedAt.Before(from) {
+			continue
+		}
+
+		stat, ok := stats[row.ONUID]
+		if !ok {
+			stat = &ONUFlapStat{ONUID: row.ONUID}
+			stats[row.ONUID] = stat
+		}
+		stat.Samples++
+		stat.Name = row.Name
+		stat.Status = row.Status
+		if !seen || last == row.Status {
+			continue
+		}
+
+		stat.Transitions++
+		if isOnline(last) && !isOnline(row.Status) {
+			at := row.RecordedAt
+			stat.Flaps++
+			stat.flapTimestamps = append(stat.flapTimestamps, at)
+			if stat.FirstFlapAt == nil {
+				stat.FirstFlapAt = &at
+			}
+			stat.LastFlapAt = &at
+			if stat.DownStatuses == nil {
+				stat.DownStatuses = map[string]int{}
+			}
+			stat.DownStatuses[row.Status]++
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read ONU logs: %w", err)
+	}
+	return stats, nil
+}
+
+// lookBehind is how far before the range the previous status is looked up
+func (s *FlapService) lookBehind() time.Duration {
+	if s.cfg.Collector.Interval > 0 {
+		return 2 * s.cfg.Collector.Interval
+	}
+	return 10 * time.Minute
+}
+
+// peakFlaps returns the most timestamps falling inside any window of the
+// given length. Timestamps must be sorted.
+func peakFlaps(timestamps []time.Time, window time.Duration) int {
+	peak := 0
+	start := 0
+	for end := range timestamps {
+		for timestamps[end].Sub(timestamps[start]) >= window {
+			start++
+		}
+		if count := end - start + 1; count > peak {
+			peak = count
+		}
+	}
+	return peak
+}
@@ -0,0 +1,159 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+)
+
+func TestPeakFlaps(t *testing.T) {
+	base := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	at := func(minutes ...int) []time.Time {
+		times := make([]time.Time, len(minutes))
+		for i, m := range minutes {
+			times[i] = base.Add(time.Duration(m) * time.Minute)
+		}
+		return times
+	}
+	tests := []struct {
+		name       string
+		timestamps []time.Time
+		want       int
+	}{
+		{"none", nil, 0},
+		{"one", at(0), 1},
+		{"all inside the hour", at(0, 10, 59), 3},
+		{"window end is exclusive", at(0, 60), 1},
+		{"busiest hour later", at(0, 70, 80, 90, 200), 3},
+		{"sliding window", at(0, 50, 100, 105), 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := peakFlaps(tt.timestamps, time.Hour); got != tt.want {
+				t.Fatalf("peakFlaps = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFlapReport(t *testing.T) {
+	db := testDB(t)
+	createTestDevice(t, db, "olt1")
+	cfg := &config.Config{}
+	cfg.Collector.Interval = 5 * time.Minute
+
+	to := time.Date(2026, 5, 10, 12, 0, 0, 0, time.Local)
+	from := to.Add(-24 * time.Hour)
+	logs := []struct {
+		onuID  string
+		status string
+		at     time.Time
+	}{
+		// Online just before the range, so the first drop counts
+		{"0/1:1", "Online", from.Add(-5 * time.Minute)},
+		{"0/1:1", "Offline", from.Add(10 * time.Minute)},
+		{"0/1:1", "Online", from.Add(20 * time.Minute)},
+		{"0/1:1", "LOS", from.Add(30 * time.Minute)},
+		{"0/1:1", "Online", from.Add(40 * time.Minute)},
+		{"0/1:1", "Online", to.Add(-2 * time.Hour)},
+		{"0/1:1", "Offline", to.Add(-30 * time.Minute)},
+		// Coming online is a transition, not a flap
+		{"0/1:2", "Offline", from.Add(-5 * time.Minute)},
+		{"0/1:2", "Online", from.Add(time.Minute)},
+		{"0/1:2", "Online", to.Add(-time.Minute)},
+		// Older than the look-behind, so the first row in range has no
+		// previous status
+		{"0/1:3", "Online", from.Add(-30 * time.Minute)},
+		{"0/1:3", "Offline", from.Add(5 * time.Minute)},
+		{"0/2:1", "Online", to.Add(-3 * time.Hour)},
+		{"0/2:1", "Dying Gasp", to.Add(-150 * time.Minute)},
+		// At to, outside the range
+		{"0/2:1", "Online", to.Add(-time.Hour)},
+		{"0/2:1", "Offline", to},
+	}
+	for _, entry := range logs {
+		row := database.ONULog{DeviceID: "olt1", ONUID: entry.onuID, Name: "onu " + entry.onuID, Status: entry.status, RecordedAt: entry.at}
+		if err := db.Create(&row).Error; err != nil {
+			t.Fatalf("create log: %v", err)
+		}
+	}
+
+	svc := NewFlapService(db, cfg)
+	report, err := svc.Report("olt1", "", from, to, 1)
+	if err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	if report.Scanned != 4 || report.Total != 2 {
+		t.Fatalf("scanned %d, total %d, want 4 and 2", report.Scanned, report.Total)
+	}
+
+	first := report.ONUs[0]
+	if first.ONUID != "0/1:1" || first.Flaps != 3 || first.Transitions != 5 || first.Samples != 6 {
+		t.Fatalf("0/1:1: got %s flaps %d transitions %d samples %d, want 0/1:1 3 5 6",
+			first.ONUID, first.Flaps, first.Transitions, first.Samples)
+	}
+	if first.Status != "Offline" || first.PeakPerHour != 2 {
+		t.Errorf("0/1:1: status %q peak %d, want Offline and 2", first.Status, first.PeakPerHour)
+	}
+	wantWindows := map[string]int{"1h": 1, "6h": 1, "24h": 3}
+	if len(first.Windows) != len(wantWindows) {
+		t.Errorf("0/1:1 windows = %v, want %v", first.Windows, wantWindows)
+	}
+	for label, want := range wantWindows {
+		if first.Windows[label] != want {
+			t.Errorf("0/1:1 window %s = %d, want %d", label, first.Windows[label], want)
+		}
+	}
+	if first.DownStatuses["Offline"] != 2 || first.DownStatuses["LOS"] != 1 {
+		t.Errorf("0/1:1 down statuses = %v, want Offline 2 and LOS 1", first.DownStatuses)
+	}
+	if first.FirstFlapAt == nil || !first.FirstFlapAt.Equal(from.Add(10*time.Minute)) ||
+		first.LastFlapAt == nil || !first.LastFlapAt.Equal(to.Add(-30*time.Minute)) {
+		t.Errorf("0/1:1 first/last flap = %v/%v", first.FirstFlapAt, first.LastFlapAt)
+	}
+
+	second := report.ONUs[1]
+	if second.ONUID != "0/2:1" || second.Flaps != 1 || second.DownStatuses["Dying Gasp"] != 1 {
+		t.Errorf("second entry = %s with %d flaps %v, want 0/2:1 with one Dying Gasp flap",
+			second.ONUID, second.Flaps, second.DownStatuses)
+	}
+
+	filtered := []struct {
+		name     string
+		ponID    string
+		minFlaps int
+		want     []string
+	}{
+		{"min flaps", "", 2, []string{"0/1:1"}},
+		{"one PON", "0/2", 1, []string{"0/2:1"}},
+		{"no flapping ONU on PON", "0/3", 1, nil},
+	}
+	for _, tt := range filtered {
+		report, err := svc.Report("olt1", tt.ponID, from, to, tt.minFlaps)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		var got []string
+		for _, stat := range report.ONUs {
+			got = append(got, stat.ONUID)
+		}
+		if len(got) != len(tt.want) || (len(got) > 0 && got[0] != tt.want[0]) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	counts, err := svc.Counts("olt1", from, to)
+	if err != nil {
+		t.Fatalf("counts: %v", err)
+	}
+	if counts["0/1:1"] != 3 || counts["0/1:2"] != 0 || counts["0/1:3"] != 0 || counts["0/2:1"] != 1 {
+		t.Errorf("counts = %v", counts)
+	}
+
+	if _, err := svc.Report("olt1", "", to, from, 1); !errors.Is(err, ErrInvalidRange) {
+		t.Errorf("reversed range: got %v, want ErrInvalidRange", err)
+	}
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/parser"
+	"olt-api/internal/scraper"
+
+	"gorm.io/gorm"
+)
+
+// Fleet summary cache states
+const (
+	FleetCacheFresh   = "fresh"   // served from cache within fresh_ttl
+	FleetCacheStale   = "stale"   // served from cache while a refresh runs
+	FleetCacheMiss    = "miss"    // built synchronously, nothing usable cached
+	FleetCacheRefresh = "refresh" // rebuilt on request
+)
+
+// ONUStatusCounts counts ONUs by status
+type ONUStatusCounts struct {
+	Total    int `json:"total"`
+	Online   int `json:"online"`
+	Offline  int `json:"offline"`
+	LOS      int `json:"los"`
+	PowerOff int `json:"poweroff"`
+	Other    int `json:"other"`
+}
+
+// Add counts one ONU status
+func (c *ONUStatusCounts) Add(status string) {
+	c.Total++
+	switch status {
+	case "online":
+		c.Online++
+	case "offline", "down":
+		c.Offline++
+	case "los":
+		c.LOS++
+	case "poweroff", "powerdown":
+		c.PowerOff++
+	default:
+		c.Other++
+	}
+}
+
+// Merge adds other into c
+func (c *ONUStatusCounts) Merge(other ONUStatusCounts) {
+	c.Total += other.Total
+	c.Online += other.Online
+	c.Offline += other.Offline
+	c.LOS += other.LOS
+	c.PowerOff += other.PowerOff
+	c.Other += other.Other
+}
+
+// FleetDeviceSummary is the dashboard view of one device. Errors is keyed
+// by stage (reachability, system, pons, onus).
+type FleetDeviceSummary struct {
+	DeviceID   string                     `json:"device_id"`
+	Name       string                     `json:"name"`
+	Reachable  bool                       `json:"reachable"`
+	System     *parser.SystemInfoResponse `json:"system,omitempty"`
+	PONCount   int                        `json:"pon_count"`
+	FailedPONs int                        `json:"failed_pons"`
+	ONUs       ONUStatusCounts            `json:"onus"`
+	Errors     map[string]string          `json:"errors,omitempty"`
+	LatencyMs  int64                      `json:"latency_ms"`
+}
+
+// FleetSummary aggregates every device
+type FleetSummary struct {
+	GeneratedAt    time.Time            `json:"generated_at"`
+	DurationMs     int64                `json:"duration_ms"`
+	DeviceCount    int                  `json:"device_count"`
+	ReachableCount int                  `json:"reachable_count"`
+	DegradedCount  int                  `json:"degraded_count"`
+	PONCount       int                  `json:"pon_count"`
+	ONUs           ONUStatusCounts      `json:"onus"`
+	Devices        []FleetDeviceSummary `json:"devices"`
+}
+
+// FleetSummaryResult is a summary together with its cache state
+type FleetSummaryResult struct {
+	*FleetSummary
+	Cache      string `json:"cache"`
+	AgeSeconds int64  `json:"age_seconds"`
+}
+
+// fleetCache holds the last built summary; it is shared by all requests
+var fleetCache struct {
+	mu         sync.Mutex
+	summary    *FleetSummary
+	refreshing bool
+}
+
+// fleetBuildMu serializes summary builds so concurrent misses scrape once
+var fleetBuildMu sync.Mutex
+
+// FleetService builds fleet-wide dashboard summaries
+type FleetService struct {
+	db            *gorm.DB
+	cfg           *config.Config
+	deviceService *DeviceService
+}
+
+// NewFleetService creates a new FleetService
+func NewFleetService(db *gorm.DB, cfg *config.Config, deviceService *DeviceService) *FleetService {
+	return &FleetService{
+		db:            db,
+		cfg:           cfg,
+		deviceService: deviceService,
+	}
+}
+
+// Summary returns the fleet summary with stale-while-revalidate semantics.
+// forceRefresh rebuilds it before returning.
+func (s *FleetService) Summary(forceRefresh bool) (*FleetSummaryResult, error) {
+	if !forceRefresh {
+		fleetCache.mu.Lock()
+		cached := fleetCache.summary
+		if cached != nil {
+			age := time.Since(cached.GeneratedAt)
+			if age <= s.cfg.Fleet.FreshTTL {
+				fleetCache.mu.Unlock()
+				return newFleetSummaryResult(cached, FleetCacheFresh), nil
+			}
+			if age <= s.cfg.Fleet.StaleTTL {
+				if !fleetCache.refreshing {
+					fleetCache.refreshing = true
+					go s.refreshInBackground()
+				}
+				fleetCache.mu.Unlock()
+				return newFleetSummaryResult(cached, FleetCacheStale), nil
+			}
+		}
+		fleetCache.mu.Unlock()
+	}
+
+	started := time.Now()
+	fleetBuildMu.Lock()
+	defer fleetBuildMu.Unlock()
+
+	// Another request may have rebuilt it while we waited
+	fleetCache.mu.Lock()
+	cached := fleetCache.summary
+	fleetCache.mu.Unlock()
+	if cached != nil && cached.GeneratedAt.After(started) {
+		return newFleetSummaryResult(cached, FleetCacheRefresh), nil
+	}
+
+	summary, err := s.build()
+	if err != nil {
+		return nil, err
+	}
+	s.store(summary)
+
+	state := FleetCacheMiss
+	if forceRefresh {
+		state = FleetCacheRefresh
+	}
+	return newFleetSummaryResult(summary, state), nil
+}
+
+func (s *FleetService) refreshInBackground() {
+	defer func() {
+		fleetCache.mu.Lock()
+		fleetCache.refreshing = false
+		fleetCache.mu.Unlock()
+	}()
+
+	fleetBuildMu.Lock()
+	defer fleetBuildMu.Unlock()
+
+	summary, err := s.build()
+	if err != nil {
+		log.Printf("[FLEET] Background refresh failed: %v", err)
+		return
+	}
+	s.store(summary)
+	log.Printf("[FLEET] Refreshed summary of %d devices in %dms", summary.DeviceCount, summary.DurationMs)
+}
+
+func (s *FleetService) store(summary *FleetSummary) {
+	fleetCache.mu.Lock()
+	fleetCache.summary = summary
+	fleetCache.mu.Unlock()
+}
+
+func newFleetSummaryResult(summary *FleetSummary, state string) *FleetSummaryResult {
+	return &FleetSummaryResult{
+		FleetSummary: summary,
+		Cache:        state,
+		AgeSeconds:   int64(time.Since(summary.GeneratedAt).Seconds()),
+	}
+}
+
+// build gathers every device concurrently. Requests to one OLT still go
+// through its per-device limiter.
+func (s *FleetService) build() (*FleetSummary, error) {
+	started := time.Now()
+	devices, err := s.deviceService.GetAll()
+	if err != nil {
+		return nil, err
+	}
+
+	pool := scraper.NewWorkerPool(s.cfg.Scraper.MaxWorkers)
+	defer pool.Close()
+
+	summaries := make([]FleetDeviceSummary, len(devices))
+	for i, device := range devices {
+		i := i
+		deviceID, name := device.ID, device.Name
+		pool.Submit(func() {
+			deviceStarted := time.Now()
+			summaries[i] = s.summarizeDevice(deviceID, name)
+			summaries[i].LatencyMs = time.Since(deviceStarted).Milliseconds()
+		})
+	}
+	pool.Wait()
+
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].DeviceID < summaries[j].DeviceID
+	})
+
+	summary := &FleetSummary{
+		GeneratedAt: time.Now(),
+		DeviceCount: len(summaries),
+		Devices:     summaries,
+	}
+	for _, device := range summaries {
+		if device.Reachable {
+			summary.ReachableCount++
+		}
+		if len(device.Errors) > 0 {
+			summary.DegradedCount++
+		}
+		summary.PONCount += device.PONCount
+		summary.ONUs.Merge(device.ONUs)
+	}
+	summary.DurationMs = time.Since(started).Milliseconds()
+	return summary, nil
+}
+
+func (s *FleetService) summarizeDevice(deviceID, name string) FleetDeviceSummary {
+	summary := FleetDeviceSummary{
+		DeviceID: deviceID,
+		Name:     name,
+		Errors:   map[string]string{},
+	}
+
+	status, err := s.deviceService.CheckStatus(deviceID)
+	if err != nil {
+		summary.Errors["reachability"] = err.Error()
+		return summary
+	}
+	if reachable, _ := status["reachable"].(bool); !reachable {
+		summary.Errors["reachability"] = fmt.Sprintf("%v", status["error"])
+		return summary
+	}
+	summary.Reachable = true
+
+	if sysInfo, err := s.deviceService.GetSystemInfo(deviceID); err != nil {
+		summary.Errors["system"] = err.Error()
+	} else {
+		summary.System = sysInfo
+	}
+
+	pons, err := NewPONService(s.db, s.cfg, s.deviceService).GetPONList(deviceID)
+	if err != nil {
+		summary.Errors["pons"] = err.Error()
+		return summary
+	}
+	summary.PONCount = len(pons)
+
+	result, err := NewONUService(s.db, s.cfg, s.deviceService).GetAllONUsWithResults(deviceID, "")
+	if err != nil {
+		summary.Errors["onus"] = err.Error()
+		return summary
+	}
+	summary.FailedPONs = result.FailedPONs
+	if result.FailedPONs > 0 {
+		summary.Errors["onus"] = fmt.Sprintf("%d of %d PONs could not be read", result.FailedPONs, len(result.PONs))
+	}
+	for _, onu := range result.ONUs {
+		summary.ONUs.Add(onu.Status)
+	}
+
+	if len(summary.Errors) == 0 {
+		summary.Errors = nil
+	}
+	return summary
+}
@@ -0,0 +1,368 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+	"olt-api/internal/parser"
+
+	"gorm.io/gorm"
+)
+
+// ONU health statuses
+const (
+	HealthGood     = "good"
+	HealthWarning  = "warning"
+	HealthCritical = "critical"
+)
+
+// Score penalties. A warning costs between the minimum and maximum depending
+// on how close the reading is to the critical bound.
+const (
+	healthCriticalPenalty   = 40
+	healthWarningPenalty    = 10
+	healthWarningPenaltyMax = 25
+)
+
+// healthReading is one metric of an ONU checked against its range
+type healthReading struct {
+	Metric   string
+	Label    string
+	Unit     string
+	Value    float64
+	Reported bool
+	Range    config.HealthRange
+}
+
+// ONUHealthEntry is a flagged ONU in the fleet health report
+type ONUHealthEntry struct {
+	DeviceID    string    `json:"device_id"`
+	DeviceName  string    `json:"device_name"`
+	CollectedAt time.Time `json:"collected_at"`
+	parser.ONUResponse
+}
+
+// DeviceHealth is the ONU health of one device from a collector run
+type DeviceHealth struct {
+	CollectedAt time.Time
+	Scored      int
+	Counts      map[string]int
+	Flagged     []parser.ONUResponse // warning and critical ONUs
+}
+
+// SummarizeHealth counts the scored ONUs of a collection by status and keeps
+// the warning and critical ones
+func SummarizeHealth(onus []parser.ONUResponse, collectedAt time.Time) *DeviceHealth {
+	summary := &DeviceHealth{
+		CollectedAt: collectedAt,
+		Counts:      map[string]int{HealthGood: 0, HealthWarning: 0, HealthCritical: 0},
+	}
+	for _, onu := range onus {
+		if onu.Health == nil {
+			continue
+		}
+		summary.Scored++
+		summary.Counts[onu.Health.Status]++
+		if onu.Health.Status != HealthGood {
+			summary.Flagged = append(summary.Flagged, onu)
+		}
+	}
+	return summary
+}
+
+// HealthFilter selects the ONUs listed by the fleet health report
+type HealthFilter struct {
+	DeviceID string
+	Status   string // warning or critical; empty lists both
+}
+
+// FleetHealthReport lists warning and critical ONUs across devices. Errors
+// is keyed by device ID.
+type FleetHealthReport struct {
+	GeneratedAt time.Time         `json:"generated_at"`
+	DurationMs  int64             `json:"duration_ms"`
+	DeviceCount int               `json:"device_count"`
+	Scored      int               `json:"scored"`
+	Counts      map[string]int    `json:"counts"`
+	Errors      map[string]string `json:"errors,omitempty"`
+	Total       int               `json:"total"`
+	ONUs        []ONUHealthEntry  `json:"onus"`
+}
+
+// HealthService scores ONU optical health against configurable thresholds
+type HealthService struct {
+	db            *gorm.DB
+	cfg           *config.Config
+	deviceService *DeviceService
+}
+
+// NewHealthService creates a new HealthService
+func NewHealthService(db *gorm.DB, cfg *config.Config, deviceService *DeviceService) *HealthService {
+	return &HealthService{
+		db:            db,
+		cfg:           cfg,
+		deviceService: deviceService,
+	}
+}
+
+// AttachToONUs scores every online ONU of a list
+func (s *HealthService) AttachToONUs(onus []parser.ONUResponse) {
+	for i := range onus {
+		onu := &onus[i]
+		if !isOnline(onu.Status) {
+			continue
+		}
+		onu.Health = s.scoreONU(onu.FwVersion, onu.ChipID, onu.Metrics, onu.Distance)
+	}
+}
+
+// AttachToDetail scores an online ONU from its optical module readings and
+// the distance from its PON list, the same way as AttachToONUs
+func (s *HealthService) AttachToDetail(detail *parser.ONUDetailResponse) {
+	if detail == nil || detail.OpticalModule == nil || !isOnline(detail.Status) {
+		return
+	}
+	module := detail.OpticalModule
+	metrics := parser.ONUMetrics{
+		Temperature: module.Temperature,
+		Voltage:     module.Voltage,
+		Current:     module.BiasCurrent,
+		TxPower:     module.TxPower,
+		RxPower:     module.RxPower,
+	}
+	for _, name := range module.Unreported {
+		if name == "bias_current" {
+			name = "current"
+		}
+		metrics.Unreported = append(metrics.Unreported, name)
+	}
+	detail.Health = s.scoreONU(detail.FwVersion, detail.ChipID, metrics, detail.Distance)
+}
+
+// scoreONU checks every reported reading of an ONU against the thresholds
+// of the matching profile. A distance of 0 means it was not reported, since
+// the OLT reports at least 1 m. Returns nil when nothing could be scored.
+func (s *HealthService) scoreONU(fwVersion, chipID string, metrics parser.ONUMetrics, distance int) *parser.ONUHealth {
+	unreported := map[string]bool{}
+	for _, name := range metrics.Unreported {
+		unreported[name] = true
+	}
+	profile, thresholds := s.thresholdsFor(fwVersion, chipID)
+	readings := []healthReading{
+		{"rx_power", "Rx power", " dBm", metrics.RxPower, !unreported["rx_power"], thresholds.RxPower},
+		{"tx_power", "Tx power", " dBm", metrics.TxPower, !unreported["tx_power"], thresholds.TxPower},
+		{"temperature", "Temperature", " °C", metrics.Temperature, !unreported["temperature"], thresholds.Temperature},
+		{"voltage", "Voltage", " V", metrics.Voltage, !unreported["voltage"], thresholds.Voltage},
+		{"bias_current", "Bias current", " mA", metrics.Current, !unreported["current"], thresholds.BiasCurrent},
+		{"distance", "Distance", " m", float64(distance), distance > 0, thresholds.Distance},
+	}
+
+	health := &parser.ONUHealth{Score: 100, Status: HealthGood, Profile: profile}
+	scored := 0
+	for _, reading := range readings {
+		if !reading.Reported {
+			continue
+		}
+		scored++
+		issue, penalty := checkReading(reading)
+		if issue == nil {
+			continue
+		}
+		health.Issues = append(health.Issues, *issue)
+		health.Score -= penalty
+		if issue.Severity == HealthCritical || health.Status == HealthGood {
+			health.Status = issue.Severity
+		}
+	}
+	if scored == 0 {
+		return nil
+	}
+	if health.Score < 0 {
+		health.Score = 0
+	}
+	return health
+}
+
+// checkReading returns the issue of a reading outside its range and the
+// score penalty, or nil when it is within bounds
+func checkReading(reading healthReading) (*parser.ONUHealthIssue, int) {
+	value, limits := reading.Value, reading.Range
+	issue := func(severity, direction string, threshold float64) *parser.ONUHealthIssue {
+		return &parser.ONUHealthIssue{
+			Metric:    reading.Metric,
+			Severity:  severity,
+			Value:     value,
+			Threshold: threshold,
+			Message: fmt.Sprintf("%s %.2f%s is %s the %s threshold of %.2f%s",
+				reading.Label, value, reading.Unit, direction, severity, threshold, reading.Unit),
+		}
+	}
+
+	switch {
+	case limits.CritBelow != nil && value < *limits.CritBelow:
+		return issue(HealthCritical, "below", *limits.CritBelow), healthCriticalPenalty
+	case limits.CritAbove != nil && value > *limits.CritAbove:
+		return issue(HealthCritical, "above", *limits.CritAbove), healthCriticalPenalty
+	case limits.WarnBelow != nil && value < *limits.WarnBelow:
+		return issue(HealthWarning, "below", *limits.WarnBelow), warningPenalty(*limits.WarnBelow-value, limits.WarnBelow, limits.CritBelow)
+	case limits.WarnAbove != nil && value > *limits.WarnAbove:
+		return issue(HealthWarning, "above", *limits.WarnAbove), warningPenalty(value-*limits.WarnAbove, limits.WarnAbove, limits.CritAbove)
+	}
+	return nil, 0
+}
+
+// warningPenalty scales the warning penalty by how far past the warning
+// bound the reading is, relative to the distance to the critical bound
+func warningPenalty(excess float64, warn, crit *float64) int {
+	if crit == nil || *crit == *warn {
+		return healthWarningPenalty
+	}
+	fraction := math.Min(excess/math.Abs(*crit-*warn), 1)
+	return healthWarningPenalty + int(math.Round(fraction*(healthWarningPenaltyMax-healthWarningPenalty)))
+}
+
+// thresholdsFor returns the name of the first profile matching the firmware
+// version and model together with its thresholds merged over the defaults
+func (s *HealthService) thresholdsFor(fwVersion, chipID string) (string, config.HealthThresholds) {
+	defaults := s.cfg.Health.Thresholds
+	for _, profile := range s.cfg.Health.Profiles {
+		if !profileMatches(profile, fwVersion, chipID) {
+			continue
+		}
+		override := profile.Thresholds
+		return profile.Name, config.HealthThresholds{
+			RxPower:     mergeHealthRange(defaults.RxPower, override.RxPower),
+			TxPower:     mergeHealthRange(defaults.TxPower, override.TxPower),
+			Temperature: mergeHealthRange(defaults.Temperature, override.Temperature),
+			Voltage:     mergeHealthRange(defaults.Voltage, override.Voltage),
+			BiasCurrent: mergeHealthRange(defaults.BiasCurrent, override.BiasCurrent),
+			Distance:    mergeHealthRange(defaults.Distance, override.Distance),
+		}
+	}
+	return "", defaults
+}
+
+// profileMatches reports whether every criterion the profile sets is a
+// case-insensitive prefix of the ONU's value. A profile without criteria
+// never matches.
+func profileMatches(profile config.HealthProfile, fwVersion, chipID string) bool {
+	if profile.FwVersion == "" && profile.Model == "" {
+		return false
+	}
+	hasPrefix := func(value, prefix string) bool {
+		return strings.HasPrefix(strings.ToLower(strings.TrimSpace(value)), strings.ToLower(strings.TrimSpace(prefix)))
+	}
+	if profile.FwVersion != "" && !hasPrefix(fwVersion, profile.FwVersion) {
+		return false
+	}
+	if profile.Model != "" && !hasPrefix(chipID, profile.Model) {
+		return false
+	}
+	return true
+}
+
+func mergeHealthRange(base, override config.HealthRange) config.HealthRange {
+	if override.WarnBelow != nil {
+		base.WarnBelow = override.WarnBelow
+	}
+	if override.CritBelow != nil {
+		base.CritBelow = override.CritBelow
+	}
+	if override.WarnAbove != nil {
+		base.WarnAbove = override.WarnAbove
+	}
+	if override.CritAbove != nil {
+		base.CritAbove = override.CritAbove
+	}
+	return base
+}
+
+// Fleet lists the ONUs in warning or critical health on every device (or
+// one) from the collector's last successful run, critical and lowest scores
+// first. The OLTs are not queried. Devices without collected data or whose
+// last run failed are reported in Errors.
+func (s *HealthService) Fleet(filter HealthFilter, collector *Collector) (*FleetHealthReport, error) {
+	if filter.Status != "" && filter.Status != HealthWarning && filter.Status != HealthCritical {
+		return nil, invalidf("invalid status '%s' (expected warning or critical)", filter.Status)
+	}
+	if !s.cfg.Collector.Enabled {
+		return nil, kindErrorf(ErrNotConfigured, "fleet health is not available: the collector is disabled (collector.enabled)")
+	}
+
+	started := time.Now()
+	var devices []database.Device
+	if filter.DeviceID != "" {
+		device, err := s.deviceService.GetByID(filter.DeviceID)
+		if err != nil {
+			return nil, err
+		}
+		devices = append(devices, *device)
+	} else {
+		all, err := s.deviceService.GetAll()
+		if err != nil {
+			return nil, err
+		}
+		devices = all
+	}
+
+	report := &FleetHealthReport{
+		DeviceCount: len(devices),
+		Counts:      map[string]int{HealthGood: 0, HealthWarning: 0, HealthCritical: 0},
+		Errors:      map[string]string{},
+		ONUs:        []ONUHealthEntry{},
+	}
+
+	for _, device := range devices {
+		health, lastError := collector.Health(device.ID)
+		if lastError != "" {
+			report.Errors[device.ID] = lastError
+		}
+		if health == nil {
+			if lastError == "" {
+				report.Errors[device.ID] = "not collected yet"
+			}
+			continue
+		}
+		report.Scored += health.Scored
+		for status, count := range health.Counts {
+			report.Counts[status] += count
+		}
+		for _, onu := range health.Flagged {
+			if filter.Status != "" && onu.Health.Status != filter.Status {
+				continue
+			}
+			report.ONUs = append(report.ONUs, ONUHealthEntry{
+				DeviceID:    device.ID,
+				DeviceName:  device.Name,
+				CollectedAt: health.CollectedAt,
+				ONUResponse: onu,
+			})
+		}
+	}
+
+	sort.SliceStable(report.ONUs, func(i, j int) bool {
+		a, b := report.ONUs[i], report.ONUs[j]
+		if a.Health.Status != b.Health.Status {
+			return a.Health.Status == HealthCritical
+		}
+		if a.Health.Score != b.Health.Score {
+			return a.Health.Score < b.Health.Score
+		}
+		if a.DeviceID != b.DeviceID {
+			return a.DeviceID < b.DeviceID
+		}
+		return compareONUIDs(a.ONUID, b.ONUID) < 0
+	})
+	if len(report.Errors) == 0 {
+		report.Errors = nil
+	}
+	report.Total = len(report.ONUs)
+	report.GeneratedAt = time.Now()
+	report.DurationMs = time.Since(started).Milliseconds()
+	return report, nil
+}
@@ -0,0 +1,443 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// History steps
+const (
+	HistoryStepRaw  = "raw"
+	HistoryStepHour = "hour"
+	HistoryStepDay  = "day"
+	HistoryStepAuto = "auto"
+
+	maxRawHistoryPoints = 10000
+	maxRollupBuckets    = 24 * 31 // buckets processed per tier and run
+)
+
+// ONUHistorySample is one raw ONU log row
+type ONUHistorySample struct {
+	Time        time.Time `json:"time"`
+	Status      string    `json:"status"`
+	RxPower     float64   `json:"rx_power"`
+	TxPower     float64   `json:"tx_power"`
+	Temperature float64   `json:"temperature"`
+}
+
+// ONUHistory is a time series of one ONU. Points holds []ONUHistorySample
+// for raw steps and []database.ONUMetricRollup otherwise.
+type ONUHistory struct {
+	DeviceID  string      `json:"device_id"`
+	ONUID     string      `json:"onu_id"`
+	From      time.Time   `json:"from"`
+	To        time.Time   `json:"to"`
+	Step      string      `json:"step"`
+	Count     int         `json:"count"`
+	Truncated bool        `json:"truncated,omitempty"`
+	Points    interface{} `json:"points"`
+}
+
+// HistoryService serves ONU metric history and maintains its rollups
+type HistoryService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+// NewHistoryService creates a new HistoryService
+func NewHistoryService(db *gorm.DB, cfg *config.Config) *HistoryService {
+	return &HistoryService{db: db, cfg: cfg}
+}
+
+// ParseHistoryStep normalizes a step parameter
+func ParseHistoryStep(raw string) (string, error) {
+	switch strings.ToLower(strings.TrimSpace(raw)) {
+	case "", HistoryStepAuto:
+		return HistoryStepAuto, nil
+	case HistoryStepRaw:
+		return HistoryStepRaw, nil
+	case HistoryStepHour, "hourly", "1h":
+		return HistoryStepHour, nil
+	case HistoryStepDay, "daily", "1d", "24h":
+		return HistoryStepDay, nil
+	}
+	return "", fmt.Errorf("unsupported step: %s (supported: auto, raw, hour, day)", raw)
+}
+
+// ONUHistory returns the metrics of one ONU between from and to
+func (s *HistoryService) ONUHistory(deviceID, onuID string, from, to time.Time, step string) (*ONUHistory, error) {
+	if !to.After(from) {
+		return nil, ErrInvalidRange
+	}
+	// Stored timestamps are in local time; compare like with like
+	from, to = from.Local(), to.Local()
+	if step == HistoryStepAuto {
+		step = s.autoStep(from, to)
+	}
+
+	history := &ONUHistory{DeviceID: deviceID, ONUID: onuID, From: from, To: to, Step: step}
+	if step == HistoryStepRaw {
+		var samples []ONUHistorySample
+		if err := s.db.Model(&database.ONULog{}).
+			Select("recorded_at AS time, status, rx_power, tx_power, temperature").
+			Where("device_id = ? AND on_uid = ? AND recorded_at >= ? AND recorded_at < ?", deviceID, onuID, from, to).
+			Order("recorded_at ASC").
+			Limit(maxRawHistoryPoints + 1).
+			Scan(&samples).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch ONU history: %w", err)
+		}
+		if len(samples) > maxRawHistoryPoints {
+			samples = samples[:maxRawHistoryPoints]
+			history.Truncated = true
+		}
+		history.Count = len(samples)
+		history.Points = samples
+		return history, nil
+	}
+
+	var rollups []database.ONUMetricRollup
+	if err := s.db.Where("device_id = ? AND onu_id = ? AND tier = ? AND bucket_start >= ? AND bucket_start < ?",
+		deviceID, onuID, step, bucketStart(from, step), to).
+		Order("bucket_start ASC").
+		Find(&rollups).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch ONU history: %w", err)
+	}
+	history.Count = len(rollups)
+	history.Points = rollups
+	return history, nil
+}
+
+// autoStep picks raw data for short ranges still inside raw retention,
+// hourly rollups up to 60 days and daily rollups beyond
+func (s *HistoryService) autoStep(from, to time.Time) string {
+	span := to.Sub(from)
+	rawKept := s.cfg.Retention.Raw <= 0 || from.After(time.Now().Add(-s.cfg.Retention.Raw))
+	switch {
+	case span <= 48*time.Hour && rawKept:
+		return HistoryStepRaw
+	case span <= 60*24*time.Hour:
+		return HistoryStepHour
+	}
+	return HistoryStepDay
+}
+
+// bucketStart truncates t to the start of its hour or (local) day
+func bucketStart(t time.Time, tier string) time.Time {
+	t = t.Local()
+	if tier == HistoryStepDay {
+		return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.Local)
+	}
+	return t.Truncate(time.Hour)
+}
+
+func nextBucket(t time.Time, tier string) time.Time {
+	if tier == HistoryStepDay {
+		return t.AddDate(0, 0, 1)
+	}
+	return t.Add(time.Hour)
+}
+
+// rollupRow is one aggregated (device, ONU) row of a bucket
+type rollupRow struct {
+	DeviceID      string
+	ONUID         string `gorm:"column:onu_id"`
+	Samples       int
+	OnlineSamples int
+	RxSamples     int
+	RxMin         *float64
+	RxAvg         *float64
+	RxMax         *float64
+	TxMin         *float64
+	TxAvg         *float64
+	TxMax         *float64
+	TempMin       *float64
+	TempAvg       *float64
+	TempMax       *float64
+}
+
+// hourlyRollupSelect aggregates ONU log rows; optical figures skip
+// unreported (0) readings, see database.ONULog
+const hourlyRollupSelect = `device_id, on_uid AS onu_id, COUNT(*) AS samples,
+	SUM(CASE WHEN status = 'online' THEN 1 ELSE 0 END) AS online_samples,
+	SUM(CASE WHEN status = 'online' AND rx_power <> 0 THEN 1 ELSE 0 END) AS rx_samples,
+	MIN(CASE WHEN status = 'online' AND rx_power <> 0 THEN rx_power END) AS rx_min,
+	AVG(CASE WHEN status = 'online' AND rx_power <> 0 THEN rx_power END) AS rx_avg,
+	MAX(CASE WHEN status = 'online' AND rx_power <> 0 THEN rx_power END) AS rx_max,
+	MIN(CASE WHEN status = 'online' AND tx_power <> 0 THEN tx_power END) AS tx_min,
+	AVG(CASE WHEN status = 'online' AND tx_power <> 0 THEN tx_power END) AS tx_avg,
+	MAX(CASE WHEN status = 'online' AND tx_power <> 0 THEN tx_power END) AS tx_max,
+	MIN(CASE WHEN status = 'online' AND temperature <> 0 THEN temperature END) AS temp_min,
+	AVG(CASE WHEN status = 'online' AND temperature <> 0 THEN temperature END) AS temp_avg,
+	MAX(CASE WHEN status = 'online' AND temperature <> 0 THEN temperature END) AS temp_max`
+
+// Daily averages weight hourly averages by their sample counts
+const dailyRollupSelect = `device_id, onu_id, SUM(samples) AS samples,
+	SUM(online_samples) AS online_samples, SUM(rx_samples) AS rx_samples,
+	MIN(rx_min) AS rx_min, SUM(rx_avg * rx_samples) / NULLIF(SUM(CASE WHEN rx_avg IS NOT NULL THEN rx_samples END), 0) AS rx_avg, MAX(rx_max) AS rx_max,
+	MIN(tx_min) AS tx_min, SUM(tx_avg * online_samples) / NULLIF(SUM(CASE WHEN tx_avg IS NOT NULL THEN online_samples END), 0) AS tx_avg, MAX(tx_max) AS tx_max,
+	MIN(temp_min) AS temp_min, SUM(temp_avg * online_samples) / NULLIF(SUM(CASE WHEN temp_avg IS NOT NULL THEN online_samples END), 0) AS temp_avg, MAX(temp_max) AS temp_max`
+
+// Rollup aggregates completed hours from onu_logs and completed days from
+// hourly rollups. Buckets are rebuilt idempotently.
+func (s *HistoryService) Rollup(now time.Time) error {
+	hourEnd := bucketStart(now, HistoryStepHour)
+	hours, err := s.rollupTier(HistoryStepHour, hourEnd)
+	if err != nil {
+		return err
+	}
+
+	// Only roll up days whose hours are all done
+	dayEnd := bucketStart(now, HistoryStepDay)
+	if last, ok := s.lastBucket(HistoryStepHour); ok && nextBucket(last, HistoryStepHour).Before(dayEnd) {
+		dayEnd = bucketStart(nextBucket(last, HistoryStepHour), HistoryStepDay)
+	}
+	days, err := s.rollupTier(HistoryStepDay, dayEnd)
+	if err != nil {
+		return err
+	}
+
+	if hours > 0 || days > 0 {
+		log.Printf("[HISTORY] Rolled up %d hour and %d day buckets", hours, days)
+	}
+	return nil
+}
+
+// rollupTier processes the buckets of a tier that end before end and returns
+// how many were processed
+func (s *HistoryService) rollupTier(tier string, end time.Time) (int, error) {
+	start, ok := s.lastBucket(tier)
+	if ok {
+		start = nextBucket(start, tier)
+	} else {
+		first, found := s.firstSource(tier)
+		if !found {
+			return 0, nil
+		}
+		start = bucketStart(first, tier)
+	}
+
+	processed := 0
+	for bucket := start; !nextBucket(bucket, tier).After(end) && processed < maxRollupBuckets; bucket = nextBucket(bucket, tier) {
+		if err := s.rollupBucket(tier, bucket); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+func (s *HistoryService) rollupBucket(tier string, bucket time.Time) error {
+	until := nextBucket(bucket, tier)
+
+	var rows []rollupRow
+	var err error
+	if tier == HistoryStepHour {
+		err = s.db.Model(&database.ONULog{}).Select(hourlyRollupSelect).
+			Where("recorded_at >= ? AND recorded_at < ?", bucket, until).
+			Group("device_id, on_uid").Scan(&rows).Error
+	} else {
+		err = s.db.Model(&database.ONUMetricRollup{}).Select(dailyRollupSelect).
+			Where("tier = ? AND bucket_start >= ? AND bucket_start < ?", HistoryStepHour, bucket, until).
+			Group("device_id, onu_id").Scan(&rows).Error
+	}
+	if err != nil {
+		return fmt.Errorf("failed to aggregate %s bucket %s: %w", tier, bucket.Format(time.RFC3339), err)
+	}
+
+	rollups := make([]database.ONUMetricRollup, 0, len(rows))
+	for _, row := range rows {
+		rollups = append(rollups, database.ONUMetricRollup{
+			DeviceID:      row.DeviceID,
+			ONUID:         row.ONUID,
+			Tier:          tier,
+			BucketStart:   bucket,
+			Samples:       row.Samples,
+			OnlineSamples: row.OnlineSamples,
+			RxSamples:     row.RxSamples,
+			RxMin:         row.RxMin,
+			RxAvg:         roundMetric(row.RxAvg),
+			RxMax:         row.RxMax,
+			TxMin:         row.TxMin,
+			TxAvg:         roundMetric(row.TxAvg),
+			TxMax:         row.TxMax,
+			TempMin:       row.TempMin,
+			TempAvg:       roundMetric(row.TempAvg),
+			TempMax:       row.TempMax,
+		})
+	}
+
+	// An empty bucket still gets a marker row so progress is remembered
+	if len(rollups) == 0 {
+		rollups = append(rollups, database.ONUMetricRollup{Tier: tier, BucketStart: bucket})
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tier = ? AND bucket_start = ?", tier, bucket).
+			Delete(&database.ONUMetricRollup{}).Error; err != nil {
+			return fmt.Errorf("failed to replace %s bucket: %w", tier, err)
+		}
+		if err := tx.CreateInBatches(rollups, 500).Error; err != nil {
+			return fmt.Errorf("failed to store %s bucket: %w", tier, err)
+		}
+		return nil
+	})
+}
+
+// lastBucket returns the newest processed bucket of a tier
+func (s *HistoryService) lastBucket(tier string) (time.Time, bool) {
+	var rollup database.ONUMetricRollup
+	if err := s.db.Where("tier = ?", tier).Order("bucket_start DESC").First(&rollup).Error; err != nil {
+		return time.Time{}, false
+	}
+	return rollup.BucketStart, true
+}
+
+// firstSource returns the oldest input row of a tier
+func (s *HistoryService) firstSource(tier string) (time.Time, bool) {
+	if tier == HistoryStepHour {
+		var row database.ONULog
+		if err := s.db.Order("recorded_at ASC").First(&row).Error; err != nil {
+			return time.Time{}, false
+		}
+		return row.RecordedAt, true
+	}
+	var rollup database.ONUMetricRollup
+	if err := s.db.Where("tier = ?", HistoryStepHour).Order("bucket_start ASC").First(&rollup).Error; err != nil {
+		return time.Time{}, false
+	}
+	return rollup.BucketStart, true
+}
+
+// ApplyRetention deletes raw logs, rollups and traffic samples past their
+// retention. Raw logs are kept until their hour has been rolled up.
+func (s *HistoryService) ApplyRetention(now time.Time) error {
+	if s.cfg.Retention.Raw > 0 {
+		cutoff := now.Add(-s.cfg.Retention.Raw)
+		last, ok := s.lastBucket(HistoryStepHour)
+		if !ok {
+			cutoff = time.Time{}
+		} else if rolled := nextBucket(last, HistoryStepHour); rolled.Before(cutoff) {
+			cutoff = rolled
+		}
+		if !cutoff.IsZero() {
+			result := s.db.Where("recorded_at < ?", cutoff).Delete(&database.ONULog{})
+			if result.Error != nil {
+				return fmt.Errorf("failed to prune ONU logs: %w", result.Error)
+			}
+			if result.RowsAffected > 0 {
+				log.Printf("[HISTORY] Pruned %d raw ONU log rows", result.RowsAffected)
+			}
+		}
+	}
+
+	tiers := map[string]time.Duration{
+		HistoryStepHour: s.cfg.Retention.Hourly,
+		HistoryStepDay:  s.cfg.Retention.Daily,
+	}
+	for tier, retention := range tiers {
+		if retention <= 0 {
+			continue
+		}
+		// Keep the newest bucket of each tier; it marks rollup progress
+		last, ok := s.lastBucket(tier)
+		if !ok {
+			continue
+		}
+		cutoff := now.Add(-retention)
+		if last.Before(cutoff) {
+			cutoff = last
+		}
+		result := s.db.Where("tier = ? AND bucket_start < ?", tier, cutoff).Delete(&database.ONUMetricRollup{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to prune %s rollups: %w", tier, result.Error)
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("[HISTORY] Pruned %d %s rollup rows", result.RowsAffected, tier)
+		}
+	}
+
+	if s.cfg.Traffic.Retention > 0 {
+		result := s.db.Where("recorded_at < ?", now.Add(-s.cfg.Traffic.Retention)).Delete(&database.ONUTrafficSample{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to prune traffic samples: %w", result.Error)
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("[HISTORY] Pruned %d traffic sample rows", result.RowsAffected)
+		}
+	}
+	return nil
+}
+
+func roundMetric(value *float64) *float64 {
+	if value == nil {
+		return nil
+	}
+	rounded := math.Round(*value*100) / 100
+	return &rounded
+}
+
+// HistoryMaintainer periodically rolls up and prunes ONU history and OLT
+// system metrics and refreshes the optical trends computed from ONU history
+type HistoryMaintainer struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+// NewHistoryMaintainer creates a new HistoryMaintainer
+func NewHistoryMaintainer(db *gorm.DB, cfg *config.Config) *HistoryMaintainer {
+	return &HistoryMaintainer{db: db, cfg: cfg}
+}
+
+// Start launches the rollup and retention loop
+func (m *HistoryMaintainer) Start() {
+	interval := m.cfg.Retention.Interval
+	if interval <= 0 {
+		interval = 15 * time.Minute
+	}
+
+	trendInterval := m.cfg.Optics.TrendInterval
+	if trendInterval <= 0 {
+		trendInterval = 6 * time.Hour
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		var lastTrend time.Time
+		for {
+			history := NewHistoryService(m.db, m.cfg)
+			now := time.Now()
+			if err := history.Rollup(now); err != nil {
+				log.Printf("[HISTORY] Rollup failed: %v", err)
+			}
+			if err := history.ApplyRetention(now); err != nil {
+				log.Printf("[HISTORY] Retention failed: %v", err)
+			}
+			system := NewSystemMetricsService(m.db, m.cfg, NewDeviceService(m.db, m.cfg))
+			if err := system.Rollup(now); err != nil {
+				log.Printf("[HISTORY] System metrics rollup failed: %v", err)
+			}
+			if err := system.ApplyRetention(now); err != nil {
+				log.Printf("[HISTORY] System metrics retention failed: %v", err)
+			}
+			if now.Sub(lastTrend) >= trendInterval {
+				if count, err := NewOpticalTrendService(m.db, m.cfg).Analyze(now); err != nil {
+					log.Printf("[HISTORY] Optical trend analysis failed: %v", err)
+				} else {
+					lastTrend = now
+					log.Printf("[HISTORY] Optical trends updated for %d ONUs", count)
+				}
+			}
+			<-ticker.C
+		}
+	}()
+	log.Printf("[HISTORY] Rollup and retention started (interval %s)", interval)
+}
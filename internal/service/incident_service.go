@@ -0,0 +1,568 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+	"olt-api/internal/parser"
+
+	"gorm.io/gorm"
+)
+
+// Incident types
+const (
+	IncidentFiberCut    = "fiber_cut"
+	IncidentPowerOutage = "power_outage"
+	IncidentOLTIssue    = "olt_issue"
+)
+
+// Incident statuses
+const (
+	IncidentOpen     = "open"
+	IncidentResolved = "resolved"
+)
+
+// AlertPONOutage is the type of the alert raised for an incident. It has no
+// rule; its fingerprint is derived from the incident ID.
+const AlertPONOutage = "pon_outage"
+
+var incidentLabels = map[string]string{
+	IncidentFiberCut:    "fiber cut",
+	IncidentPowerOutage: "power outage",
+	IncidentOLTIssue:    "OLT issue",
+}
+
+// IncidentFilter narrows an incident listing
+type IncidentFilter struct {
+	Status   string
+	DeviceID string
+	Type     string
+	Limit    int
+}
+
+// IncidentService correlates ONU outages into incidents
+type IncidentService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+// NewIncidentService creates a new IncidentService
+func NewIncidentService(db *gorm.DB, cfg *config.Config) *IncidentService {
+	return &IncidentService{db: db, cfg: cfg}
+}
+
+func (s *IncidentService) window() time.Duration {
+	if s.cfg.Incidents.Window <= 0 {
+		return 10 * time.Minute
+	}
+	return s.cfg.Incidents.Window
+}
+
+func (s *IncidentService) minONUs() int {
+	if s.cfg.Incidents.MinONUs <= 0 {
+		return 5
+	}
+	return s.cfg.Incidents.MinONUs
+}
+
+// ListIncidents returns incidents, newest first
+func (s *IncidentService) ListIncidents(filter IncidentFilter) ([]database.Incident, error) {
+	query := s.db.Model(&database.Incident{})
+	switch filter.Status {
+	case "", "all":
+	case IncidentOpen, IncidentResolved:
+		query = query.Where("status = ?", filter.Status)
+	default:
+		return nil, invalidf("invalid status '%s'", filter.Status)
+	}
+	if filter.DeviceID != "" {
+		query = query.Where("device_id = ?", filter.DeviceID)
+	}
+	if filter.Type != "" {
+		query = query.Where("type = ?", filter.Type)
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 200
+	}
+
+	var incidents []database.Incident
+	if err := query.Order("detected_at DESC, id DESC").Limit(limit).Find(&incidents).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch incidents: %w", err)
+	}
+	for i := range incidents {
+		decodeIncident(&incidents[i])
+	}
+	return incidents, nil
+}
+
+// GetIncident returns an incident by ID
+func (s *IncidentService) GetIncident(id uint) (*database.Incident, error) {
+	var incident database.Incident
+	if err := s.db.First(&incident, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("incident '%d' %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch incident: %w", err)
+	}
+	decodeIncident(&incident)
+	return &incident, nil
+}
+
+// Resolve closes an open incident by hand, e.g. when some ONUs stay down for
+// unrelated reasons, and resolves its alert
+func (s *IncidentService) Resolve(id uint, by string) (*database.Incident, error) {
+	incident, err := s.GetIncident(id)
+	if err != nil {
+		return nil, err
+	}
+	if incident.Status == IncidentResolved {
+		return nil, conflictf("incident '%d' is already resolved", id)
+	}
+
+	now := time.Now()
+	incident.ResolvedBy = by
+	var transitions []alertTransition
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		changed, err := s.closeIncident(tx, incident, now)
+		transitions = changed
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if errs := NewAlertService(s.db, s.cfg).notify(transitions, now); len(errs) > 0 {
+		return incident, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return incident, nil
+}
+
+// Covers reports whether an ONU offline or PON alert belongs to an incident
+// that was already open when the alert fired. Such alerts are tracked as
+// usual but only the incident is notified.
+func (s *IncidentService) Covers(alert *database.Alert) (bool, error) {
+	if alert.FiredAt == nil || alert.PONID == "" {
+		return false, nil
+	}
+	if alert.Type != AlertONUOffline && alert.Type != AlertPONONUsDown {
+		return false, nil
+	}
+
+	var incidents []database.Incident
+	if err := s.db.Where("device_id = ? AND pon_id = ? AND detected_at <= ? AND (resolved_at IS NULL OR resolved_at >= ?)",
+		alert.DeviceID, alert.PONID, *alert.FiredAt, *alert.FiredAt).
+		Find(&incidents).Error; err != nil {
+		return false, fmt.Errorf("failed to load incidents: %w", err)
+	}
+	for i := range incidents {
+		if alert.Type == AlertPONONUsDown {
+			return true, nil
+		}
+		decodeIncident(&incidents[i])
+		for _, onu := range incidents[i].ONUs {
+			if onu.ONUID == alert.ONUID {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// Correlate groups ONUs that went down together into incidents and keeps
+// open incidents up to date. An ONU counts as part of an outage when it is
+// down and was last seen online within the window; once enough ONUs of one
+// PON qualify, an incident is opened and its alert fires. ONUs dropping later
+// within the window of the incident start join it, and the incident resolves
+// when none of its ONUs is down anymore. Failed PONs are left untouched.
+func (s *IncidentService) Correlate(snapshot *AlertSnapshot, now time.Time) ([]alertTransition, error) {
+	if !snapshot.ONUsKnown {
+		return nil, nil
+	}
+
+	byPON := map[string][]parser.ONUResponse{}
+	for _, onu := range snapshot.ONUs {
+		ponID := ponOfONU(onu.ONUID)
+		if ponID == "" || snapshot.FailedPONs[ponID] {
+			continue
+		}
+		byPON[ponID] = append(byPON[ponID], onu)
+	}
+	if len(byPON) == 0 {
+		return nil, nil
+	}
+	ponIDs := make([]string, 0, len(byPON))
+	for ponID := range byPON {
+		ponIDs = append(ponIDs, ponID)
+	}
+	sort.Strings(ponIDs)
+
+	lastOnline, err := s.lastOnline(snapshot.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	var open []database.Incident
+	if err := s.db.Where("device_id = ? AND status = ?", snapshot.DeviceID, IncidentOpen).
+		Find(&open).Error; err != nil {
+		return nil, fmt.Errorf("failed to load open incidents: %w", err)
+	}
+	openByPON := map[string]*database.Incident{}
+	for i := range open {
+		decodeIncident(&open[i])
+		openByPON[open[i].PONID] = &open[i]
+	}
+
+	var updated, created []*database.Incident
+	for _, ponID := range ponIDs {
+		if incident, ok := openByPON[ponID]; ok {
+			s.refreshIncident(incident, byPON[ponID], lastOnline, now)
+			updated = append(updated, incident)
+			continue
+		}
+
+		group, err := s.outageGroup(snapshot.DeviceID, ponID, byPON[ponID], lastOnline, now)
+		if err != nil {
+			return nil, err
+		}
+		if len(group) < s.minONUs() {
+			continue
+		}
+		incident := &database.Incident{
+			DeviceID:   snapshot.DeviceID,
+			PONID:      ponID,
+			Status:     IncidentOpen,
+			ONUs:       group,
+			PONTotal:   len(byPON[ponID]),
+			DetectedAt: now,
+			UpdatedAt:  now,
+		}
+		for _, onu := range group {
+			if onu.LastOnlineAt != nil && onu.LastOnlineAt.After(incident.StartedAt) {
+				incident.StartedAt = *onu.LastOnlineAt
+			}
+		}
+		classifyIncident(incident)
+		created = append(created, incident)
+	}
+
+	// ONUs dropping on every PON of the device at once point at the OLT
+	// rather than the outside plant
+	if len(created) > 1 && len(created) == len(byPON) {
+		for _, incident := range created {
+			incident.Type = IncidentOLTIssue
+		}
+	}
+
+	var transitions []alertTransition
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		transitions = nil
+		for _, incident := range created {
+			describeIncident(incident)
+			changed, err := s.openIncident(tx, incident, now)
+			if err != nil {
+				return err
+			}
+			transitions = append(transitions, changed...)
+		}
+		for _, incident := range updated {
+			if incident.Down == 0 {
+				changed, err := s.closeIncident(tx, incident, now)
+				if err != nil {
+					return err
+				}
+				transitions = append(transitions, changed...)
+				continue
+			}
+			if err := s.saveIncident(tx, incident, now); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return transitions, nil
+}
+
+// lastOnline returns when each ONU present on a device was last seen online,
+// keyed by ONU ID
+func (s *IncidentService) lastOnline(deviceID string) (map[string]*time.Time, error) {
+	var items []database.ONUInventory
+	if err := s.db.Select("onu_id", "last_online_at").
+		Where("device_id = ? AND present = ?", deviceID, true).
+		Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to load ONU inventory: %w", err)
+	}
+	lastOnline := make(map[string]*time.Time, len(items))
+	for _, item := range items {
+		lastOnline[item.ONUID] = item.LastOnlineAt
+	}
+	return lastOnline, nil
+}
+
+// outageGroup returns the ONUs of a PON that are down and were online within
+// the window. ONUs still down from the PON's previous incident are skipped,
+// so closing an incident by hand does not reopen it.
+func (s *IncidentService) outageGroup(deviceID, ponID string, onus []parser.ONUResponse, lastOnline map[string]*time.Time, now time.Time) ([]database.IncidentONU, error) {
+	since := now.Add(-s.window())
+	var group []database.IncidentONU
+	for _, onu := range onus {
+		seen := lastOnline[onu.ONUID]
+		if isOnline(onu.Status) || seen == nil || seen.Before(since) {
+			continue
+		}
+		group = append(group, incidentONU(onu, seen))
+	}
+	if len(group) < s.minONUs() {
+		return group, nil
+	}
+
+	var previous database.Incident
+	err := s.db.Where("device_id = ? AND pon_id = ? AND status = ?", deviceID, ponID, IncidentResolved).
+		Order("detected_at DESC").First(&previous).Error
+	if err == gorm.ErrRecordNotFound {
+		return group, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load previous incident: %w", err)
+	}
+	decodeIncident(&previous)
+	stale := map[string]bool{}
+	for _, onu := range previous.ONUs {
+		stale[onu.ONUID] = true
+	}
+
+	fresh := group[:0]
+	for _, onu := range group {
+		if stale[onu.ONUID] && !onu.LastOnlineAt.After(previous.DetectedAt) {
+			continue
+		}
+		fresh = append(fresh, onu)
+	}
+	return fresh, nil
+}
+
+// refreshIncident applies the current ONU statuses of a PON to an open
+// incident and adds ONUs that dropped within the window of its start
+func (s *IncidentService) refreshIncident(incident *database.Incident, onus []parser.ONUResponse, lastOnline map[string]*time.Time, now time.Time) {
+	byID := make(map[string]parser.ONUResponse, len(onus))
+	for _, onu := range onus {
+		byID[onu.ONUID] = onu
+	}
+
+	listed := make(map[string]bool, len(incident.ONUs))
+	for i := range incident.ONUs {
+		entry := &incident.ONUs[i]
+		listed[entry.ONUID] = true
+		onu, ok := byID[entry.ONUID]
+		if !ok {
+			entry.Status = "missing"
+			continue
+		}
+		entry.Status = onu.Status
+		if isOnline(onu.Status) {
+			if entry.RestoredAt == nil {
+				restored := now
+				entry.RestoredAt = &restored
+			}
+		} else {
+			entry.RestoredAt = nil
+		}
+	}
+
+	joined := false
+	window := s.window()
+	for _, onu := range onus {
+		seen := lastOnline[onu.ONUID]
+		if listed[onu.ONUID] || isOnline(onu.Status) || seen == nil {
+			continue
+		}
+		if seen.Before(incident.StartedAt.Add(-window)) || seen.After(incident.StartedAt.Add(window)) {
+			continue
+		}
+		incident.ONUs = append(incident.ONUs, incidentONU(onu, seen))
+		joined = true
+	}
+	sort.SliceStable(incident.ONUs, func(i, j int) bool {
+		return compareONUIDs(incident.ONUs[i].ONUID, incident.ONUs[j].ONUID) < 0
+	})
+
+	incident.PONTotal = len(onus)
+	if joined && incident.Type != IncidentOLTIssue {
+		classifyIncident(incident)
+	}
+	describeIncident(incident)
+}
+
+// openIncident stores a new incident and fires its alert
+func (s *IncidentService) openIncident(tx *gorm.DB, incident *database.Incident, now time.Time) ([]alertTransition, error) {
+	if err := s.saveIncident(tx, incident, now); err != nil {
+		return nil, err
+	}
+
+	alert := &database.Alert{
+		RuleName:        incidentRuleName(incident),
+		Type:            AlertPONOutage,
+		Severity:        "critical",
+		Fingerprint:     fmt.Sprintf("incident|%d", incident.ID),
+		Status:          AlertStatusFiring,
+		DeviceID:        incident.DeviceID,
+		PONID:           incident.PONID,
+		Value:           float64(incident.Down),
+		Message:         incident.Message,
+		StartedAt:       incident.StartedAt,
+		FiredAt:         &now,
+		LastEvaluatedAt: now,
+		UpdatedAt:       now,
+	}
+	if err := tx.Create(alert).Error; err != nil {
+		return nil, fmt.Errorf("failed to create incident alert: %w", err)
+	}
+	incident.AlertID = alert.ID
+	if err := tx.Model(incident).Update("alert_id", alert.ID).Error; err != nil {
+		return nil, fmt.Errorf("failed to link incident alert: %w", err)
+	}
+	return []alertTransition{{Alert: *alert, Event: AlertStatusFiring}}, nil
+}
+
+// closeIncident resolves an incident and its alert
+func (s *IncidentService) closeIncident(tx *gorm.DB, incident *database.Incident, now time.Time) ([]alertTransition, error) {
+	incident.Status = IncidentResolved
+	incident.ResolvedAt = &now
+	if err := s.saveIncident(tx, incident, now); err != nil {
+		return nil, err
+	}
+
+	var alert database.Alert
+	if err := tx.Where("id = ? AND status = ?", incident.AlertID, AlertStatusFiring).First(&alert).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to load incident alert: %w", err)
+	}
+	alert.Status = AlertStatusResolved
+	alert.ResolvedAt = &now
+	alert.Value = float64(incident.Down)
+	alert.Message = incident.Message
+	alert.LastEvaluatedAt = now
+	alert.UpdatedAt = now
+	if err := tx.Save(&alert).Error; err != nil {
+		return nil, fmt.Errorf("failed to resolve incident alert: %w", err)
+	}
+	return []alertTransition{{Alert: alert, Event: AlertStatusResolved}}, nil
+}
+
+// saveIncident stores an incident and, while it is open, mirrors its summary
+// onto its alert
+func (s *IncidentService) saveIncident(tx *gorm.DB, incident *database.Incident, now time.Time) error {
+	data, err := json.Marshal(incident.ONUs)
+	if err != nil {
+		return fmt.Errorf("failed to encode incident ONUs: %w", err)
+	}
+	incident.ONUsJSON = string(data)
+	incident.UpdatedAt = now
+	if err := tx.Save(incident).Error; err != nil {
+		return fmt.Errorf("failed to save incident: %w", err)
+	}
+
+	if incident.AlertID > 0 && incident.Status == IncidentOpen {
+		if err := tx.Model(&database.Alert{}).
+			Where("id = ? AND status = ?", incident.AlertID, AlertStatusFiring).
+			Updates(map[string]interface{}{
+				"rule_name":         incidentRuleName(incident),
+				"value":             float64(incident.Down),
+				"message":           incident.Message,
+				"last_evaluated_at": now,
+				"updated_at":        now,
+			}).Error; err != nil {
+			return fmt.Errorf("failed to update incident alert: %w", err)
+		}
+	}
+	return nil
+}
+
+func incidentONU(onu parser.ONUResponse, lastOnline *time.Time) database.IncidentONU {
+	entry := database.IncidentONU{
+		ONUID:        onu.ONUID,
+		Name:         onu.Name,
+		MacAddress:   onu.MacAddress,
+		DownStatus:   onu.Status,
+		Status:       onu.Status,
+		LastOnlineAt: lastOnline,
+	}
+	if onu.Subscriber != nil {
+		entry.Subscriber = &database.IncidentSubscriber{
+			ID:            onu.Subscriber.ID,
+			AccountNumber: onu.Subscriber.AccountNumber,
+			Name:          onu.Subscriber.Name,
+			Phone:         onu.Subscriber.Phone,
+		}
+	}
+	return entry
+}
+
+// classifyIncident sets the incident type from the statuses its ONUs went
+// down with: mostly LOS is a fiber cut, mostly dying gasp (poweroff) is a
+// power outage, and ONUs that dropped without either point at the OLT
+func classifyIncident(incident *database.Incident) {
+	incident.LOS, incident.PowerOff, incident.Offline = 0, 0, 0
+	for _, onu := range incident.ONUs {
+		switch onu.DownStatus {
+		case "los":
+			incident.LOS++
+		case "poweroff", "powerdown":
+			incident.PowerOff++
+		default:
+			incident.Offline++
+		}
+	}
+
+	switch {
+	case incident.LOS > 0 && incident.LOS >= incident.PowerOff && incident.LOS >= incident.Offline:
+		incident.Type = IncidentFiberCut
+	case incident.PowerOff > 0 && incident.PowerOff >= incident.Offline:
+		incident.Type = IncidentPowerOutage
+	default:
+		incident.Type = IncidentOLTIssue
+	}
+}
+
+// describeIncident updates the counters and message of an incident
+func describeIncident(incident *database.Incident) {
+	incident.Affected = len(incident.ONUs)
+	incident.Down = 0
+	subscribers := 0
+	for _, onu := range incident.ONUs {
+		if onu.Status != "missing" && !isOnline(onu.Status) {
+			incident.Down++
+		}
+		if onu.Subscriber != nil {
+			subscribers++
+		}
+	}
+
+	incident.Message = fmt.Sprintf("Suspected %s on PON %s of device %s: %d of %d affected ONUs down (%d LOS, %d power off, %d offline), %d of %d ONUs on the PON affected",
+		incidentLabels[incident.Type], incident.PONID, incident.DeviceID, incident.Down, incident.Affected,
+		incident.LOS, incident.PowerOff, incident.Offline, incident.Affected, incident.PONTotal)
+	if subscribers > 0 {
+		incident.Message += fmt.Sprintf(", %d subscribers", subscribers)
+	}
+}
+
+func incidentRuleName(incident *database.Incident) string {
+	return "PON outage (" + incidentLabels[incident.Type] + ")"
+}
+
+func decodeIncident(incident *database.Incident) {
+	incident.ONUs = []database.IncidentONU{}
+	if incident.ONUsJSON != "" {
+		_ = json.Unmarshal([]byte(incident.ONUsJSON), &incident.ONUs)
+	}
+}
@@ -0,0 +1,258 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"olt-api/internal/database"
+	"olt-api/internal/parser"
+
+	"gorm.io/gorm"
+)
+
+// Inventory event types
+const (
+	InventoryEventAppeared    = "appeared"
+	InventoryEventDisappeared = "disappeared"
+	InventoryEventMoved       = "moved"
+	InventoryEventRenamed     = "renamed"
+)
+
+// inventoryMu serializes inventory updates; PONs of one device are fetched
+// concurrently and an ONU can move between them.
+var inventoryMu sync.Mutex
+
+// InventoryChangeFilter controls change queries
+type InventoryChangeFilter struct {
+	Since time.Time
+	PONID string
+	Type  string
+	Limit int
+}
+
+// InventoryService maintains the persistent ONU inventory
+type InventoryService struct {
+	db *gorm.DB
+}
+
+// NewInventoryService creates a new InventoryService
+func NewInventoryService(db *gorm.DB) *InventoryService {
+	return &InventoryService{db: db}
+}
+
+// Record updates the inventory from a successful ONU list fetch of one PON
+// and stores appeared, disappeared, moved and renamed events.
+func (s *InventoryService) Record(deviceID, ponID string, onus []parser.ONUResponse) error {
+	inventoryMu.Lock()
+	defer inventoryMu.Unlock()
+
+	now := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		macs := make([]string, 0, len(onus))
+		for _, onu := range onus {
+			if mac := NormalizeMAC(onu.MacAddress); mac != "" {
+				macs = append(macs, mac)
+			}
+		}
+
+		// Rows currently on this PON plus any row for a MAC seen now
+		var existing []database.ONUInventory
+		query := tx.Where("device_id = ?", deviceID)
+		if len(macs) > 0 {
+			query = query.Where("pon_id = ? OR mac IN ?", ponID, macs)
+		} else {
+			query = query.Where("pon_id = ?", ponID)
+		}
+		if err := query.Find(&existing).Error; err != nil {
+			return fmt.Errorf("failed to load inventory: %w", err)
+		}
+		byMAC := make(map[string]*database.ONUInventory, len(existing))
+		for i := range existing {
+			byMAC[existing[i].MAC] = &existing[i]
+		}
+
+		var events []database.ONUInventoryEvent
+		seen := make(map[string]bool, len(onus))
+		for _, onu := range onus {
+			mac := NormalizeMAC(onu.MacAddress)
+			if mac == "" || seen[mac] {
+				continue
+			}
+			seen[mac] = true
+
+			onuID := strings.TrimSpace(onu.ONUID)
+			event := database.ONUInventoryEvent{
+				DeviceID:  deviceID,
+				MAC:       mac,
+				ONUID:     onuID,
+				PONID:     ponID,
+				CreatedAt: now,
+			}
+
+			row, ok := byMAC[mac]
+			if !ok {
+				item := database.ONUInventory{
+					DeviceID:    deviceID,
+					MAC:         mac,
+					MacAddress:  onu.MacAddress,
+					ONUID:       onuID,
+					PONID:       ponID,
+					Name:        onu.Name,
+					Status:      onu.Status,
+					FwVersion:   onu.FwVersion,
+					ChipID:      onu.ChipID,
+					Distance:    onu.Distance,
+					Present:     true,
+					FirstSeenAt: now,
+					LastSeenAt:  now,
+					UpdatedAt:   now,
+				}
+				if isOnline(onu.Status) {
+					item.LastOnlineAt = &now
+				}
+				if err := tx.Create(&item).Error; err != nil {
+					return fmt.Errorf("failed to create inventory item: %w", err)
+				}
+				event.Type = InventoryEventAppeared
+				events = append(events, event)
+				continue
+			}
+
+			switch {
+			case row.ONUID != onuID:
+				moved := event
+				moved.Type = InventoryEventMoved
+				moved.OldValue = row.ONUID
+				moved.NewValue = onuID
+				events = append(events, moved)
+			case !row.Present:
+				appeared := event
+				appeared.Type = InventoryEventAppeared
+				events = append(events, appeared)
+			}
+			if row.Name != onu.Name {
+				renamed := event
+				renamed.Type = InventoryEventRenamed
+				renamed.OldValue = row.Name
+				renamed.NewValue = onu.Name
+				events = append(events, renamed)
+			}
+
+			updates := map[string]interface{}{
+				"mac_address":  onu.MacAddress,
+				"onu_id":       onuID,
+				"pon_id":       ponID,
+				"name":         onu.Name,
+				"status":       onu.Status,
+				"fw_version":   onu.FwVersion,
+				"chip_id":      onu.ChipID,
+				"distance":     onu.Distance,
+				"present":      true,
+				"last_seen_at": now,
+				"updated_at":   now,
+			}
+			if isOnline(onu.Status) {
+				updates["last_online_at"] = &now
+			}
+			if err := tx.Model(&database.ONUInventory{}).Where("id = ?", row.ID).Updates(updates).Error; err != nil {
+				return fmt.Errorf("failed to update inventory item: %w", err)
+			}
+		}
+
+		// Anything previously on this PON that was not in the list is gone
+		for _, row := range existing {
+			if seen[row.MAC] || !row.Present || row.PONID != ponID {
+				continue
+			}
+			if err := tx.Model(&database.ONUInventory{}).Where("id = ?", row.ID).
+				Updates(map[string]interface{}{"present": false, "updated_at": now}).Error; err != nil {
+				return fmt.Errorf("failed to update inventory item: %w", err)
+			}
+			events = append(events, database.ONUInventoryEvent{
+				DeviceID:  deviceID,
+				MAC:       row.MAC,
+				ONUID:     row.ONUID,
+				PONID:     ponID,
+				Type:      InventoryEventDisappeared,
+				CreatedAt: now,
+			})
+		}
+
+		if len(events) > 0 {
+			if err := tx.CreateInBatches(events, 100).Error; err != nil {
+				return fmt.Errorf("failed to store inventory events: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// List returns the inventory of a device. present filters on presence when set.
+func (s *InventoryService) List(deviceID, ponID string, present *bool) ([]database.ONUInventory, error) {
+	query := s.db.Where("device_id = ?", deviceID)
+	if trimmed := strings.TrimSpace(ponID); trimmed != "" {
+		query = query.Where("pon_id = ?", trimmed)
+	}
+	if present != nil {
+		query = query.Where("present = ?", *present)
+	}
+
+	var items []database.ONUInventory
+	if err := query.Order("pon_id ASC, onu_id ASC").Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch inventory: %w", err)
+	}
+	return items, nil
+}
+
+// Changes returns inventory events of a device, newest first
+func (s *InventoryService) Changes(deviceID string, filter InventoryChangeFilter) ([]database.ONUInventoryEvent, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 200
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	query := s.db.Where("device_id = ?", deviceID).Order("created_at DESC, id DESC").Limit(limit)
+	if !filter.Since.IsZero() {
+		query = query.Where("created_at >= ?", filter.Since)
+	}
+	if trimmed := strings.TrimSpace(filter.PONID); trimmed != "" {
+		query = query.Where("pon_id = ?", trimmed)
+	}
+	if trimmed := strings.TrimSpace(filter.Type); trimmed != "" {
+		query = query.Where("type = ?", strings.ToLower(trimmed))
+	}
+
+	var events []database.ONUInventoryEvent
+	if err := query.Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch inventory changes: %w", err)
+	}
+	return events, nil
+}
+
+// MACForONU resolves an ONU ID ("0/1:8" or "1:8") to the normalized MAC of
+// the ONU currently in that slot
+func (s *InventoryService) MACForONU(deviceID, onuID string) (string, error) {
+	onuID = strings.TrimSpace(onuID)
+	if normalized := normalizeSearchONUID(onuID); normalized != "" {
+		onuID = normalized
+	}
+
+	var item database.ONUInventory
+	if err := s.db.Where("device_id = ? AND onu_id = ? AND present = ?", deviceID, onuID, true).
+		First(&item).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return "", fmt.Errorf("ONU '%s' %w in inventory of device '%s' (list its PON first or pass mac)", onuID, ErrNotFound, deviceID)
+		}
+		return "", fmt.Errorf("failed to resolve ONU: %w", err)
+	}
+	return item.MAC, nil
+}
+
+func isOnline(status string) bool {
+	return strings.EqualFold(strings.TrimSpace(status), "online")
+}
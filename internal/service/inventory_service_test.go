@@ -0,0 +1,179 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"olt-api/internal/database"
+	"olt-api/internal/parser"
+)
+
+func inventoryONU(onuID, mac, name string) parser.ONUResponse {
+	return parser.ONUResponse{ONUID: onuID, MacAddress: mac, Name: name, Status: "Online"}
+}
+
+func formatInventoryEvent(event database.ONUInventoryEvent) string {
+	return fmt.Sprintf("%s %s %s %s %q->%q", event.Type, event.MAC, event.PONID, event.ONUID, event.OldValue, event.NewValue)
+}
+
+func TestInventoryRecordEvents(t *testing.T) {
+	db := testDB(t)
+	createTestDevice(t, db, "olt1")
+	svc := NewInventoryService(db)
+
+	const macA, macB = "AA:BB:CC:00:00:01", "aa-bb-cc-00-00-02"
+	steps := []struct {
+		name  string
+		pon   string
+		onus  []parser.ONUResponse
+		wants []string
+	}{
+		{
+			name: "first list",
+			pon:  "0/1",
+			onus: []parser.ONUResponse{
+				inventoryONU("0/1:1", macA, "alice"),
+				inventoryONU("0/1:2", macB, "bob"),
+				inventoryONU("0/1:3", "", "no mac"),
+				inventoryONU("0/1:1", macA, "alice"),
+			},
+			wants: []string{
+				`appeared aabbcc000001 0/1 0/1:1 ""->""`,
+				`appeared aabbcc000002 0/1 0/1:2 ""->""`,
+			},
+		},
+		{
+			name: "unchanged",
+			pon:  "0/1",
+			onus: []parser.ONUResponse{
+				inventoryONU("0/1:1", macA, "alice"),
+				inventoryONU("0/1:2", macB, "bob"),
+			},
+		},
+		{
+			name: "renamed and disappeared",
+			pon:  "0/1",
+			onus: []parser.ONUResponse{inventoryONU("0/1:1", macA, "alice-2")},
+			wants: []string{
+				`renamed aabbcc000001 0/1 0/1:1 "alice"->"alice-2"`,
+				`disappeared aabbcc000002 0/1 0/1:2 ""->""`,
+			},
+		},
+		{
+			name: "still gone",
+			pon:  "0/1",
+			onus: []parser.ONUResponse{inventoryONU("0/1:1", macA, "alice-2")},
+		},
+		{
+			name: "reappeared",
+			pon:  "0/1",
+			onus: []parser.ONUResponse{
+				inventoryONU("0/1:1", macA, "alice-2"),
+				inventoryONU("0/1:2", macB, "bob"),
+			},
+			wants: []string{`appeared aabbcc000002 0/1 0/1:2 ""->""`},
+		},
+		{
+			name:  "moved to another PON",
+			pon:   "0/2",
+			onus:  []parser.ONUResponse{inventoryONU("0/2:5", macA, "alice-2")},
+			wants: []string{`moved aabbcc000001 0/2 0/2:5 "0/1:1"->"0/2:5"`},
+		},
+		{
+			name: "old PON no longer lists the moved ONU",
+			pon:  "0/1",
+			onus: []parser.ONUResponse{inventoryONU("0/1:2", macB, "bob")},
+		},
+		{
+			name:  "moved and renamed",
+			pon:   "0/1",
+			onus:  []parser.ONUResponse{inventoryONU("0/1:7", macB, "carol")},
+			wants: []string{`moved aabbcc000002 0/1 0/1:7 "0/1:2"->"0/1:7"`, `renamed aabbcc000002 0/1 0/1:7 "bob"->"carol"`},
+		},
+		{
+			name:  "empty PON",
+			pon:   "0/2",
+			wants: []string{`disappeared aabbcc000001 0/2 0/2:5 ""->""`},
+		},
+	}
+
+	var lastID uint
+	for _, step := range steps {
+		if err := svc.Record("olt1", step.pon, step.onus); err != nil {
+			t.Fatalf("%s: record: %v", step.name, err)
+		}
+		var events []database.ONUInventoryEvent
+		if err := db.Where("id > ?", lastID).Order("id ASC").Find(&events).Error; err != nil {
+			t.Fatalf("%s: load events: %v", step.name, err)
+		}
+		got := make([]string, len(events))
+		for i, event := range events {
+			if event.DeviceID != "olt1" {
+				t.Errorf("%s: event %d has device %q", step.name, event.ID, event.DeviceID)
+			}
+			got[i] = formatInventoryEvent(event)
+			lastID = event.ID
+		}
+		if fmt.Sprint(got) != fmt.Sprint(step.wants) {
+			t.Errorf("%s: events\n got %q\nwant %q", step.name, got, step.wants)
+		}
+	}
+
+	items, err := svc.List("olt1", "", nil)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(items) != 2 {
+		t.Fatalf("got %d inventory rows, want 2", len(items))
+	}
+	want := map[string]string{
+		"aabbcc000001": "0/2 0/2:5 alice-2 present=false",
+		"aabbcc000002": "0/1 0/1:7 carol present=true",
+	}
+	for _, item := range items {
+		got := fmt.Sprintf("%s %s %s present=%t", item.PONID, item.ONUID, item.Name, item.Present)
+		if got != want[item.MAC] {
+			t.Errorf("inventory %s: got %q, want %q", item.MAC, got, want[item.MAC])
+		}
+		if item.LastOnlineAt == nil {
+			t.Errorf("inventory %s: last_online_at not set", item.MAC)
+		}
+	}
+}
+
+func TestInventoryRecordKeepsDevicesApart(t *testing.T) {
+	db := testDB(t)
+	createTestDevice(t, db, "olt1")
+	createTestDevice(t, db, "olt2")
+	svc := NewInventoryService(db)
+
+	onus := []parser.ONUResponse{inventoryONU("0/1:1", "AA:BB:CC:00:00:01", "alice")}
+	if err := svc.Record("olt1", "0/1", onus); err != nil {
+		t.Fatalf("record olt1: %v", err)
+	}
+	if err := svc.Record("olt2", "0/1", onus); err != nil {
+		t.Fatalf("record olt2: %v", err)
+	}
+	if err := svc.Record("olt2", "0/1", nil); err != nil {
+		t.Fatalf("record olt2 empty: %v", err)
+	}
+
+	for _, check := range []struct {
+		device string
+		typ    string
+		want   int64
+	}{
+		{"olt1", InventoryEventAppeared, 1},
+		{"olt1", InventoryEventDisappeared, 0},
+		{"olt2", InventoryEventAppeared, 1},
+		{"olt2", InventoryEventDisappeared, 1},
+	} {
+		got := countRows(t, db, &database.ONUInventoryEvent{}, "device_id = ? AND type = ?", check.device, check.typ)
+		if got != check.want {
+			t.Errorf("%s %s events: got %d, want %d", check.device, check.typ, got, check.want)
+		}
+	}
+	if got := countRows(t, db, &database.ONUInventory{}, "device_id = ? AND present = ?", "olt1", true); got != 1 {
+		t.Errorf("olt1 present rows: got %d, want 1", got)
+	}
+}
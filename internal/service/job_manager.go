@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// JobHandler executes one claimed job. It should stop early when ctx is
+// cancelled and return ctx.Err(). The returned result is stored on the job.
+type JobHandler func(ctx context.Context, job *database.Job) (interface{}, error)
+
+type jobRegistration struct {
+	handler   JobHandler
+	resumable bool
+}
+
+// JobManager runs persisted jobs on a fixed worker pool. Jobs are claimed
+// from the database, so pending work survives restarts.
+type JobManager struct {
+	db       *gorm.DB
+	cfg      *config.Config
+	jobs     *JobService
+	handlers map[string]jobRegistration
+	wake     chan struct{}
+
+	mu      sync.Mutex
+	running map[uint]context.CancelFunc
+}
+
+// NewJobManager creates a JobManager with the built-in job types registered
+func NewJobManager(db *gorm.DB, cfg *config.Config) *JobManager {
+	m := &JobManager{
+		db:       db,
+		cfg:      cfg,
+		jobs:     NewJobService(db),
+		handlers: map[string]jobRegistration{},
+		wake:     make(chan struct{}, 1),
+		running:  map[uint]context.CancelFunc{},
+	}
+
+	m.Register(JobTypeONUBulkAction, true, func(ctx context.Context, job *database.Job) (interface{}, error) {
+		return NewBulkService(db, cfg, NewDeviceService(db, cfg)).Execute(ctx, job)
+	})
+	m.Register(JobTypeONUScan, false, func(ctx context.Context, job *database.Job) (interface{}, error) {
+		return NewONUService(db, cfg, NewDeviceService(db, cfg)).ExecuteScan(ctx, job)
+	})
+	m.Register(JobTypeSaveConfig, true, func(ctx context.Context, job *database.Job) (interface{}, error) {
+		return NewONUService(db, cfg, NewDeviceService(db, cfg)).ExecuteSaveConfig(ctx, job)
+	})
+
+	return m
+}
+
+// Register adds a handler for a job type. Resumable jobs are re-queued after
+// a restart instead of being failed.
+func (m *JobManager) Register(jobType string, resumable bool, handler JobHandler) {
+	m.handlers[jobType] = jobRegistration{handler: handler, resumable: resumable}
+}
+
+// Start recovers interrupted jobs and launches the worker pool
+func (m *JobManager) Start() {
+	resumed, failed, err := m.jobs.RecoverInterrupted(func(jobType string) bool {
+		return m.handlers[jobType].resumable
+	})
+	if err != nil {
+		log.Printf("[JOB] Failed to recover interrupted jobs: %v", err)
+	} else if resumed > 0 || failed > 0 {
+		log.Printf("[JOB] Recovered interrupted jobs: %d resumed, %d failed", resumed, failed)
+	}
+
+	workers := m.cfg.Jobs.Workers
+	if workers <= 0 {
+		workers = 2 // default
+	}
+	for i := 0; i < workers; i++ {
+		go m.worker()
+	}
+	log.Printf("[JOB] Started %d job workers", workers)
+}
+
+// Enqueue stores a new job and wakes a worker to run it
+func (m *JobManager) Enqueue(jobType, deviceID string, actor JobActor, payload interface{}, targets []string) (*JobDescriptor, error) {
+	if _, ok := m.handlers[jobType]; !ok {
+		return nil, fmt.Errorf("unsupported job type: %s", jobType)
+	}
+
+	job, err := m.jobs.Create(jobType, deviceID, actor, payload, targets)
+	if err != nil {
+		return nil, err
+	}
+	m.notify()
+
+	return &JobDescriptor{ID: job.ID, Type: job.Type, Total: job.Total}, nil
+}
+
+// Cancel stops a pending or running job
+func (m *JobManager) Cancel(id uint) error {
+	cancelled, err := m.jobs.CancelPending(id)
+	if err != nil {
+		return err
+	}
+	if cancelled {
+		return nil
+	}
+
+	m.mu.Lock()
+	cancel, ok := m.running[id]
+	m.mu.Unlock()
+	if !ok {
+		job, err := m.jobs.Get(id)
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("job '%d' is already %s", id, job.Status)
+	}
+
+	cancel()
+	return nil
+}
+
+func (m *JobManager) notify() {
+	select {
+	case m.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (m *JobManager) worker() {
+	pollInterval := m.cfg.Jobs.PollInterval
+	if pollInterval <= 0 {
+		pollInterval = 5 * time.Second
+	}
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+
+	for {
+		for m.runNext() {
+		}
+
+		select {
+		case <-m.wake:
+		case <-ticker.C:
+		}
+	}
+}
+
+// runNext claims and executes one job. It reports whether a job was run.
+func (m *JobManager) runNext() bool {
+	job, err := m.jobs.Claim()
+	if err != nil {
+		log.Printf("[JOB] %v", err)
+		return false
+	}
+	if job == nil {
+		return false
+	}
+
+	registration, ok := m.handlers[job.Type]
+	if !ok {
+		m.finish(job, nil, fmt.Errorf("unsupported job type: %s", job.Type))
+		return true
+	}
+	if err := CheckScheduledFreeze(m.db, job); err != nil {
+		m.finish(job, nil, err)
+		return true
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	m.mu.Lock()
+	m.running[job.ID] = cancel
+	m.mu.Unlock()
+
+	defer func() {
+		m.mu.Lock()
+		delete(m.running, job.ID)
+		m.mu.Unlock()
+		cancel()
+	}()
+
+	log.Printf("[JOB] Running %s job %d (device %s)", job.Type, job.ID, job.DeviceID)
+	result, runErr := m.safeRun(ctx, registration.handler, job)
+	m.finish(job, result, runErr)
+	return true
+}
+
+func (m *JobManager) safeRun(ctx context.Context, handler JobHandler, job *database.Job) (result interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("job panicked: %v", r)
+		}
+	}()
+	return handler(ctx, job)
+}
+
+func (m *JobManager) finish(job *database.Job, result interface{}, runErr error) {
+	if err := m.jobs.Finish(job.ID, result, runErr); err != nil {
+		log.Printf("[JOB] Failed to finish job %d: %v", job.ID, err)
+		return
+	}
+	if runErr != nil {
+		log.Printf("[JOB] %s job %d ended: %v", job.Type, job.ID, runErr)
+		return
+	}
+	log.Printf("[JOB] %s job %d completed", job.Type, job.ID)
+}
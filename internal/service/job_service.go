@@ -0,0 +1,422 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"olt-api/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// Job status values
+const (
+	JobStatusPending   = "pending"
+	JobStatusRunning   = "running"
+	JobStatusCompleted = "completed"
+	JobStatusFailed    = "failed"
+	JobStatusCancelled = "cancelled"
+)
+
+// JobItem status values
+const (
+	JobItemPending   = "pending"
+	JobItemRunning   = "running"
+	JobItemSucceeded = "succeeded"
+	JobItemFailed    = "failed"
+	JobItemCancelled = "cancelled"
+)
+
+// JobActor identifies the user a job runs on behalf of, for audit logging.
+type JobActor struct {
+	UserID    uint
+	Username  string
+	Role      string
+	IPAddress string
+	UserAgent string
+}
+
+// JobDescriptor is returned when a job is accepted
+type JobDescriptor struct {
+	ID    uint   `json:"job_id"`
+	Type  string `json:"type"`
+	Total int    `json:"total"`
+}
+
+// JobFilter controls list query behavior.
+type JobFilter struct {
+	Limit    int
+	UserID   uint
+	DeviceID string
+	Type     string
+	Status   string
+}
+
+// jobWriteMu serializes job and progress writes from concurrent workers.
+// It is shared by every JobService, since handlers, the job manager and the
+// job handlers each create their own.
+var jobWriteMu sync.Mutex
+
+// JobService persists jobs and their per-item progress.
+type JobService struct {
+	db *gorm.DB
+}
+
+// NewJobService creates a new JobService
+func NewJobService(db *gorm.DB) *JobService {
+	return &JobService{db: db}
+}
+
+// Create stores a new pending job with one item per target.
+func (s *JobService) Create(jobType, deviceID string, actor JobActor, payload interface{}, targets []string) (*database.Job, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode job payload: %w", err)
+	}
+
+	job := &database.Job{
+		Type:      jobType,
+		DeviceID:  deviceID,
+		Status:    JobStatusPending,
+		Total:     len(targets),
+		Payload:   string(raw),
+		UserID:    actor.UserID,
+		Username:  actor.Username,
+		Role:      actor.Role,
+		IPAddress: actor.IPAddress,
+		UserAgent: actor.UserAgent,
+		CreatedAt: time.Now(),
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(job).Error; err != nil {
+			return err
+		}
+		return createJobItems(tx, job.ID, targets)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create job: %w", err)
+	}
+
+	return job, nil
+}
+
+// AddItems appends items to a job whose targets are only known once it runs.
+func (s *JobService) AddItems(jobID uint, targets []string) error {
+	jobWriteMu.Lock()
+	defer jobWriteMu.Unlock()
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := createJobItems(tx, jobID, targets); err != nil {
+			return err
+		}
+		return tx.Model(&database.Job{}).Where("id = ?", jobID).
+			UpdateColumn("total", gorm.Expr("total + ?", len(targets))).Error
+	})
+}
+
+func createJobItems(tx *gorm.DB, jobID uint, targets []string) error {
+	if len(targets) == 0 {
+		return nil
+	}
+	items := make([]database.JobItem, 0, len(targets))
+	for _, target := range targets {
+		items = append(items, database.JobItem{
+			JobID:  jobID,
+			Target: target,
+			Status: JobItemPending,
+		})
+	}
+	return tx.CreateInBatches(items, 100).Error
+}
+
+// Get returns a job with its items, result and computed progress.
+func (s *JobService) Get(id uint) (*database.Job, error) {
+	var job database.Job
+	if err := s.db.Preload("Items").First(&job, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("job '%d' not found", id)
+		}
+		return nil, fmt.Errorf("failed to fetch job: %w", err)
+	}
+	decorateJob(&job)
+	return &job, nil
+}
+
+// List returns recent jobs without items or results.
+func (s *JobService) List(filter JobFilter) ([]database.Job, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 50
+	}
+	if limit > 200 {
+		limit = 200
+	}
+
+	query := s.db.Model(&database.Job{}).Omit("result", "payload").Order("created_at DESC").Limit(limit)
+	if filter.UserID > 0 {
+		query = query.Where("user_id = ?", filter.UserID)
+	}
+	if trimmed := strings.TrimSpace(filter.DeviceID); trimmed != "" {
+		query = query.Where("device_id = ?", trimmed)
+	}
+	if trimmed := strings.TrimSpace(filter.Type); trimmed != "" {
+		query = query.Where("LOWER(type) = ?", strings.ToLower(trimmed))
+	}
+	if trimmed := strings.TrimSpace(filter.Status); trimmed != "" {
+		query = query.Where("LOWER(status) = ?", strings.ToLower(trimmed))
+	}
+
+	var jobs []database.Job
+	if err := query.Find(&jobs).Error; err != nil {
+		return nil, fmt.Errorf("failed to query jobs: %w", err)
+	}
+	for i := range jobs {
+		jobs[i].Progress = jobProgress(&jobs[i])
+	}
+	return jobs, nil
+}
+
+// Claim atomically moves the oldest pending job to running and returns it.
+// It returns nil when nothing is pending.
+func (s *JobService) Claim() (*database.Job, error) {
+	jobWriteMu.Lock()
+	defer jobWriteMu.Unlock()
+
+	var claimed *database.Job
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var job database.Job
+		if err := tx.Where("status = ?", JobStatusPending).Order("id ASC").First(&job).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return nil
+			}
+			return err
+		}
+
+		now := time.Now()
+		result := tx.Model(&database.Job{}).
+			Where("id = ? AND status = ?", job.ID, JobStatusPending).
+			Updates(map[string]interface{}{
+				"status":     JobStatusRunning,
+				"started_at": &now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+
+		job.Status = JobStatusRunning
+		job.StartedAt = &now
+		claimed = &job
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim job: %w", err)
+	}
+	if claimed == nil {
+		return nil, nil
+	}
+	if err := s.db.Where("job_id = ?", claimed.ID).Order("id ASC").Find(&claimed.Items).Error; err != nil {
+		return nil, fmt.Errorf("failed to load job items: %w", err)
+	}
+	return claimed, nil
+}
+
+// StartItem marks a pending item running before its operation is sent, so
+// an interrupted run does not send it again. It reports whether the item was
+// still pending.
+func (s *JobService) StartItem(jobID uint, target string) (bool, error) {
+	jobWriteMu.Lock()
+	defer jobWriteMu.Unlock()
+
+	result := s.db.Model(&database.JobItem{}).
+		Where("job_id = ? AND target = ? AND status = ?", jobID, target, JobItemPending).
+		Update("status", JobItemRunning)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// RecordItem stores the outcome of one job item and bumps the job counters.
+func (s *JobService) RecordItem(jobID uint, target string, itemErr error) error {
+	jobWriteMu.Lock()
+	defer jobWriteMu.Unlock()
+
+	now := time.Now()
+	status := JobItemSucceeded
+	errMsg := ""
+	counter := "succeeded"
+	if itemErr != nil {
+		status = JobItemFailed
+		errMsg = itemErr.Error()
+		counter = "failed"
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&database.JobItem{}).
+			Where("job_id = ? AND target = ?", jobID, target).
+			Updates(map[string]interface{}{
+				"status":      status,
+				"error":       errMsg,
+				"finished_at": &now,
+			}).Error; err != nil {
+			return err
+		}
+		return tx.Model(&database.Job{}).Where("id = ?", jobID).
+			UpdateColumn(counter, gorm.Expr(counter+" + 1")).Error
+	})
+}
+
+// Finish stores the job result and final status. A context cancellation
+// error marks the job cancelled, any other error marks it failed.
+func (s *JobService) Finish(id uint, result interface{}, jobErr error) error {
+	jobWriteMu.Lock()
+	defer jobWriteMu.Unlock()
+
+	now := time.Now()
+	updates := map[string]interface{}{
+		"status":      JobStatusCompleted,
+		"finished_at": &now,
+	}
+	if result != nil {
+		raw, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to encode job result: %w", err)
+		}
+		updates["result"] = string(raw)
+	}
+
+	switch {
+	case jobErr == nil:
+	case errors.Is(jobErr, context.Canceled):
+		updates["status"] = JobStatusCancelled
+		updates["error"] = "cancelled"
+	default:
+		updates["status"] = JobStatusFailed
+		updates["error"] = jobErr.Error()
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if jobErr != nil {
+			if err := tx.Model(&database.JobItem{}).
+				Where("job_id = ? AND status = ?", id, JobItemPending).
+				Update("status", JobItemCancelled).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&database.Job{}).Where("id = ?", id).Updates(updates).Error
+	})
+}
+
+// CancelPending cancels a job that has not started yet. It reports whether
+// the job was still pending.
+func (s *JobService) CancelPending(id uint) (bool, error) {
+	jobWriteMu.Lock()
+	defer jobWriteMu.Unlock()
+
+	now := time.Now()
+	var cancelled bool
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&database.Job{}).
+			Where("id = ? AND status = ?", id, JobStatusPending).
+			Updates(map[string]interface{}{
+				"status":      JobStatusCancelled,
+				"error":       "cancelled",
+				"finished_at": &now,
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		cancelled = true
+		return tx.Model(&database.JobItem{}).
+			Where("job_id = ? AND status = ?", id, JobItemPending).
+			Update("status", JobItemCancelled).Error
+	})
+	if err != nil {
+		return false, fmt.Errorf("failed to cancel job: %w", err)
+	}
+	return cancelled, nil
+}
+
+// RecoverInterrupted handles jobs left running by a previous process.
+// Items that were running are failed, since their operation may already
+// have reached the OLT. Resumable jobs go back to pending (finished items
+// are skipped on the next run); all others are failed.
+func (s *JobService) RecoverInterrupted(resumable func(jobType string) bool) (int, int, error) {
+	var jobs []database.Job
+	if err := s.db.Omit("result", "payload").Where("status = ?", JobStatusRunning).Find(&jobs).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to query interrupted jobs: %w", err)
+	}
+
+	resumed, failed := 0, 0
+	for _, job := range jobs {
+		if err := s.failRunningItems(job.ID); err != nil {
+			return resumed, failed, err
+		}
+		if resumable(job.Type) {
+			if err := s.db.Model(&database.Job{}).Where("id = ?", job.ID).
+				Update("status", JobStatusPending).Error; err != nil {
+				return resumed, failed, err
+			}
+			resumed++
+			continue
+		}
+		if err := s.Finish(job.ID, nil, fmt.Errorf("interrupted by server restart")); err != nil {
+			return resumed, failed, err
+		}
+		failed++
+	}
+	return resumed, failed, nil
+}
+
+// failRunningItems fails the items of a job that were running when the
+// server stopped and counts them as failed
+func (s *JobService) failRunningItems(jobID uint) error {
+	now := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&database.JobItem{}).
+			Where("job_id = ? AND status = ?", jobID, JobItemRunning).
+			Updates(map[string]interface{}{
+				"status":      JobItemFailed,
+				"error":       "interrupted by server restart; outcome unknown",
+				"finished_at": &now,
+			})
+		if result.Error != nil {
+			return fmt.Errorf("failed to fail interrupted job items: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return nil
+		}
+		return tx.Model(&database.Job{}).Where("id = ?", jobID).
+			UpdateColumn("failed", gorm.Expr("failed + ?", result.RowsAffected)).Error
+	})
+}
+
+func decorateJob(job *database.Job) {
+	job.Progress = jobProgress(job)
+	if job.ResultJSON != "" {
+		job.Result = json.RawMessage(job.ResultJSON)
+	}
+}
+
+func jobProgress(job *database.Job) float64 {
+	if job.Total <= 0 {
+		if job.Status == JobStatusCompleted {
+			return 100
+		}
+		return 0
+	}
+	done := job.Succeeded + job.Failed
+	return float64(done) * 100 / float64(job.Total)
+}
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"olt-api/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// MaintenanceService manages per-device maintenance freezes
+type MaintenanceService struct {
+	db *gorm.DB
+}
+
+// NewMaintenanceService creates a new MaintenanceService
+func NewMaintenanceService(db *gorm.DB) *MaintenanceService {
+	return &MaintenanceService{db: db}
+}
+
+// Create stores a maintenance window for a device
+func (s *MaintenanceService) Create(deviceID string, req *database.MaintenanceWindowRequest, createdBy string) (*database.MaintenanceWindow, error) {
+	if !req.EndsAt.After(req.StartsAt) {
+		return nil, fmt.Errorf("ends_at must be after starts_at")
+	}
+
+	window := &database.MaintenanceWindow{
+		DeviceID:  deviceID,
+		StartsAt:  req.StartsAt,
+		EndsAt:    req.EndsAt,
+		Reason:    strings.TrimSpace(req.Reason),
+		CreatedBy: createdBy,
+		CreatedAt: time.Now(),
+	}
+	if err := s.db.Create(window).Error; err != nil {
+		return nil, fmt.Errorf("failed to create maintenance window: %w", err)
+	}
+	return window, nil
+}
+
+// List returns current and upcoming maintenance windows for a device
+func (s *MaintenanceService) List(deviceID string) ([]database.MaintenanceWindow, error) {
+	var windows []database.MaintenanceWindow
+	if err := s.db.Where("device_id = ? AND ends_at > ?", deviceID, time.Now()).
+		Order("starts_at ASC").Find(&windows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch maintenance windows: %w", err)
+	}
+	return windows, nil
+}
+
+// Delete removes a maintenance window
+func (s *MaintenanceService) Delete(deviceID string, id uint) error {
+	result := s.db.Where("id = ? AND device_id = ?", id, deviceID).Delete(&database.MaintenanceWindow{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete maintenance window: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("maintenance window '%d' not found", id)
+	}
+	return nil
+}
+
+// ActiveWindow returns the window freezing a device at the given time, if any
+func (s *MaintenanceService) ActiveWindow(deviceID string, at time.Time) (*database.MaintenanceWindow, error) {
+	var window database.MaintenanceWindow
+	err := s.db.Where("device_id = ? AND starts_at <= ? AND ends_at > ?", deviceID, at, at).
+		Order("ends_at DESC").First(&window).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to check maintenance windows: %w", err)
+	}
+	return &window, nil
+}
@@ -0,0 +1,474 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"text/template"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// Notification channel types
+const (
+	ChannelWebhook  = "webhook"
+	ChannelSlack    = "slack"
+	ChannelTelegram = "telegram"
+	ChannelEmail    = "email"
+)
+
+// Notification statuses
+const (
+	NotificationPending = "pending"
+	NotificationSent    = "sent"
+	NotificationFailed  = "failed"
+)
+
+const maskedSecret = "********"
+
+const defaultNotificationTemplate = `[{{upper .Event}}] {{upper .Alert.Severity}}: {{.Alert.RuleName}}
+{{.Alert.Message}}
+Device: {{.Alert.DeviceID}}{{if .Alert.ONUID}}  ONU: {{.Alert.ONUID}}{{else if .Alert.PONID}}  PON: {{.Alert.PONID}}{{end}}
+Since: {{fmtTime .Alert.StartedAt}}{{if .Alert.ResolvedAt}}  Resolved: {{fmtTime .Alert.ResolvedAt}}{{end}}`
+
+const defaultSubjectTemplate = `[{{upper .Event}}] {{.Alert.RuleName}} on {{.Alert.DeviceID}}`
+
+var notificationFuncs = template.FuncMap{
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"fmtTime": func(value interface{}) string {
+		switch t := value.(type) {
+		case time.Time:
+			return t.Format("2006-01-02 15:04:05")
+		case *time.Time:
+			if t != nil {
+				return t.Format("2006-01-02 15:04:05")
+			}
+		}
+		return ""
+	},
+}
+
+// NotificationData is the data passed to channel templates
+type NotificationData struct {
+	Event string
+	Alert *database.Alert
+	Time  time.Time
+}
+
+// NotificationPayload is the JSON body posted by webhook channels
+type NotificationPayload struct {
+	Event  string          `json:"event"`
+	Title  string          `json:"title"`
+	Text   string          `json:"text"`
+	Alert  *database.Alert `json:"alert"`
+	SentAt time.Time       `json:"sent_at"`
+}
+
+// NotificationFilter narrows a delivery log listing
+type NotificationFilter struct {
+	ChannelID uint
+	AlertID   uint
+	Status    string
+	Limit     int
+}
+
+// NotificationService manages notification channels and queues deliveries
+type NotificationService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+// NewNotificationService creates a new NotificationService
+func NewNotificationService(db *gorm.DB, cfg *config.Config) *NotificationService {
+	return &NotificationService{db: db, cfg: cfg}
+}
+
+// ListChannels returns all channels with secrets masked
+func (s *NotificationService) ListChannels() ([]database.NotificationChannel, error) {
+	var channels []database.NotificationChannel
+	if err := s.db.Order("id ASC").Find(&channels).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch notification channels: %w", err)
+	}
+	for i := range channels {
+		decodeChannel(&channels[i])
+		maskChannel(&channels[i])
+	}
+	return channels, nil
+}
+
+// GetChannel returns a channel with its stored settings
+func (s *NotificationService) GetChannel(id uint) (*database.NotificationChannel, error) {
+	var channel database.NotificationChannel
+	if err := s.db.First(&channel, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("notification channel '%d' %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch notification channel: %w", err)
+	}
+	decodeChannel(&channel)
+	return &channel, nil
+}
+
+// CreateChannel validates and stores a new channel
+func (s *NotificationService) CreateChannel(req *database.NotificationChannelRequest, createdBy string) (*database.NotificationChannel, error) {
+	channel := &database.NotificationChannel{Enabled: true, CreatedBy: createdBy}
+	if err := applyChannelRequest(channel, req); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	channel.CreatedAt = now
+	channel.UpdatedAt = now
+	if err := s.db.Create(channel).Error; err != nil {
+		return nil, fmt.Errorf("failed to create notification channel: %w", err)
+	}
+	maskChannel(channel)
+	return channel, nil
+}
+
+// UpdateChannel replaces a channel definition, keeping stored secrets that
+// the request leaves empty
+func (s *NotificationService) UpdateChannel(id uint, req *database.NotificationChannelRequest) (*database.NotificationChannel, error) {
+	channel, err := s.GetChannel(id)
+	if err != nil {
+		return nil, err
+	}
+
+	settings := req.Settings
+	if settings.Secret == "" || settings.Secret == maskedSecret {
+		settings.Secret = channel.Settings.Secret
+	}
+	if settings.Password == "" || settings.Password == maskedSecret {
+		settings.Password = channel.Settings.Password
+	}
+	if settings.BotToken == "" || settings.BotToken == maskedSecret {
+		settings.BotToken = channel.Settings.BotToken
+	}
+	req.Settings = settings
+
+	if err := applyChannelRequest(channel, req); err != nil {
+		return nil, err
+	}
+	channel.UpdatedAt = time.Now()
+	if err := s.db.Save(channel).Error; err != nil {
+		return nil, fmt.Errorf("failed to update notification channel: %w", err)
+	}
+	maskChannel(channel)
+	return channel, nil
+}
+
+// DeleteChannel removes a channel and its queued deliveries; sent and
+// failed deliveries are kept as history
+func (s *NotificationService) DeleteChannel(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("channel_id = ? AND status = ?", id, NotificationPending).
+			Delete(&database.Notification{}).Error; err != nil {
+			return fmt.Errorf("failed to drop queued notifications: %w", err)
+		}
+		if err := tx.Delete(&database.NotificationChannel{}, id).Error; err != nil {
+			return fmt.Errorf("failed to delete notification channel: %w", err)
+		}
+		return nil
+	})
+}
+
+// ListNotifications returns the delivery log, newest first
+func (s *NotificationService) ListNotifications(filter NotificationFilter) ([]database.Notification, error) {
+	query := s.db.Model(&database.Notification{})
+	if filter.ChannelID > 0 {
+		query = query.Where("channel_id = ?", filter.ChannelID)
+	}
+	if filter.AlertID > 0 {
+		query = query.Where("alert_id = ?", filter.AlertID)
+	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	limit := filter.Limit
+	if limit <= 0 || limit > 1000 {
+		limit = 100
+	}
+
+	var notifications []database.Notification
+	if err := query.Order("id DESC").Limit(limit).Find(&notifications).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch notifications: %w", err)
+	}
+	return notifications, nil
+}
+
+// Enqueue queues an alert event for every enabled channel whose route
+// matches it
+func (s *NotificationService) Enqueue(alert *database.Alert, event string) error {
+	var channels []database.NotificationChannel
+	if err := s.db.Where("enabled = ?", true).Find(&channels).Error; err != nil {
+		return fmt.Errorf("failed to load notification channels: %w", err)
+	}
+
+	var deviceTags []string
+	tagsLoaded := false
+	now := time.Now()
+	for i := range channels {
+		channel := &channels[i]
+		decodeChannel(channel)
+		route := channel.Route
+
+		if len(route.Tags) > 0 && !tagsLoaded {
+			tags, err := NewTagService(s.db).DeviceTags(alert.DeviceID)
+			if err != nil {
+				return err
+			}
+			for _, tag := range tags {
+				deviceTags = append(deviceTags, tag.Name)
+			}
+			tagsLoaded = true
+		}
+		if !routeMatches(route, alert, event, deviceTags) {
+			continue
+		}
+
+		notification, err := buildNotification(channel, alert, event, now)
+		if err != nil {
+			log.Printf("[NOTIFY] Channel %d: %v", channel.ID, err)
+			continue
+		}
+		if err := s.db.Create(notification).Error; err != nil {
+			return fmt.Errorf("failed to queue notification: %w", err)
+		}
+	}
+	return nil
+}
+
+// SendTest renders a sample alert through a channel and sends it right away.
+// The attempt is recorded in the delivery log.
+func (s *NotificationService) SendTest(id uint) (*database.Notification, error) {
+	channel, err := s.GetChannel(id)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	sample := &database.Alert{
+		RuleName:        "Test notification",
+		Type:            AlertONUOffline,
+		Severity:        "info",
+		Status:          AlertStatusFiring,
+		DeviceID:        "test-device",
+		PONID:           "0/1",
+		ONUID:           "0/1:1",
+		Message:         "This is a test notification from the OLT API",
+		StartedAt:       now,
+		FiredAt:         &now,
+		LastEvaluatedAt: now,
+	}
+	notification, err := buildNotification(channel, sample, "test", now)
+	if err != nil {
+		return nil, err
+	}
+	notification.Attempts = 1
+
+	sendErr := NewNotifier(s.db, s.cfg).deliver(channel, notification)
+	if sendErr != nil {
+		notification.Status = NotificationFailed
+		notification.LastError = sendErr.Error()
+	} else {
+		notification.Status = NotificationSent
+		notification.SentAt = &now
+	}
+	if err := s.db.Create(notification).Error; err != nil {
+		return nil, fmt.Errorf("failed to record test notification: %w", err)
+	}
+	return notification, sendErr
+}
+
+func buildNotification(channel *database.NotificationChannel, alert *database.Alert, event string, now time.Time) (*database.Notification, error) {
+	data := NotificationData{Event: event, Alert: alert, Time: now}
+	subject, err := renderTemplate(channel.SubjectTemplate, defaultSubjectTemplate, data)
+	if err != nil {
+		return nil, fmt.Errorf("subject template: %w", err)
+	}
+	body, err := renderTemplate(channel.Template, defaultNotificationTemplate, data)
+	if err != nil {
+		return nil, fmt.Errorf("template: %w", err)
+	}
+
+	payload, err := json.Marshal(NotificationPayload{
+		Event:  event,
+		Title:  subject,
+		Text:   body,
+		Alert:  alert,
+		SentAt: now,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode payload: %w", err)
+	}
+
+	return &database.Notification{
+		ChannelID:     channel.ID,
+		AlertID:       alert.ID,
+		Event:         event,
+		Status:        NotificationPending,
+		NextAttemptAt: now,
+		Subject:       subject,
+		Body:          body,
+		PayloadJSON:   string(payload),
+		CreatedAt:     now,
+	}, nil
+}
+
+func renderTemplate(text, fallback string, data NotificationData) (string, error) {
+	if strings.TrimSpace(text) == "" {
+		text = fallback
+	}
+	tmpl, err := template.New("notification").Funcs(notificationFuncs).Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(buf.String()), nil
+}
+
+func routeMatches(route database.NotificationRoute, alert *database.Alert, event string, deviceTags []string) bool {
+	if len(route.Events) > 0 && !containsString(route.Events, event) {
+		return false
+	}
+	if len(route.Severities) > 0 && !containsString(route.Severities, alert.Severity) {
+		return false
+	}
+	if len(route.DeviceIDs) > 0 && !containsString(route.DeviceIDs, alert.DeviceID) {
+		return false
+	}
+	if len(route.Tags) > 0 {
+		for _, tag := range route.Tags {
+			if containsString(deviceTags, tag) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func applyChannelRequest(channel *database.NotificationChannel, req *database.NotificationChannelRequest) error {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return invalidf("name is required")
+	}
+	channelType := strings.ToLower(strings.TrimSpace(req.Type))
+	settings := req.Settings
+
+	switch channelType {
+	case ChannelWebhook, ChannelSlack:
+		if !strings.HasPrefix(settings.URL, "http://") && !strings.HasPrefix(settings.URL, "https://") {
+			return invalidf("settings.url must be an http(s) URL")
+		}
+	case ChannelTelegram:
+		if settings.BotToken == "" || settings.ChatID == "" {
+			return invalidf("settings.bot_token and settings.chat_id are required")
+		}
+	case ChannelEmail:
+		if settings.SMTPHost == "" || settings.From == "" || len(settings.To) == 0 {
+			return invalidf("settings.smtp_host, settings.from and settings.to are required")
+		}
+		switch settings.TLS {
+		case "", "starttls", "tls", "none":
+		default:
+			return invalidf("invalid settings.tls '%s' (use starttls, tls or none)", settings.TLS)
+		}
+	default:
+		return invalidf("unsupported channel type '%s' (use webhook, slack, telegram or email)", req.Type)
+	}
+
+	route := req.Route
+	route.Events = lowerList(route.Events)
+	route.Severities = lowerList(route.Severities)
+	route.Tags = lowerList(route.Tags)
+	for _, event := range route.Events {
+		if event != AlertStatusFiring && event != AlertStatusResolved {
+			return invalidf("invalid route event '%s' (use firing or resolved)", event)
+		}
+	}
+	for _, severity := range route.Severities {
+		if !containsString(alertSeverities, severity) {
+			return invalidf("invalid route severity '%s'", severity)
+		}
+	}
+
+	// Templates are checked against a sample alert so mistakes surface now
+	// rather than on the first delivery
+	sample := NotificationData{Event: AlertStatusFiring, Alert: &database.Alert{}, Time: time.Now()}
+	if _, err := renderTemplate(req.SubjectTemplate, defaultSubjectTemplate, sample); err != nil {
+		return invalidf("invalid subject_template: %v", err)
+	}
+	if _, err := renderTemplate(req.Template, defaultNotificationTemplate, sample); err != nil {
+		return invalidf("invalid template: %v", err)
+	}
+
+	settingsJSON, err := json.Marshal(settings)
+	if err != nil {
+		return fmt.Errorf("failed to encode settings: %w", err)
+	}
+	routeJSON, err := json.Marshal(route)
+	if err != nil {
+		return fmt.Errorf("failed to encode route: %w", err)
+	}
+
+	channel.Name = name
+	channel.Type = channelType
+	channel.Settings = settings
+	channel.SettingsJSON = string(settingsJSON)
+	channel.Route = route
+	channel.RouteJSON = string(routeJSON)
+	channel.Template = req.Template
+	channel.SubjectTemplate = req.SubjectTemplate
+	if req.Enabled != nil {
+		channel.Enabled = *req.Enabled
+	}
+	return nil
+}
+
+func decodeChannel(channel *database.NotificationChannel) {
+	if channel.SettingsJSON != "" {
+		_ = json.Unmarshal([]byte(channel.SettingsJSON), &channel.Settings)
+	}
+	if channel.RouteJSON != "" {
+		_ = json.Unmarshal([]byte(channel.RouteJSON), &channel.Route)
+	}
+}
+
+// MaskedChannel masks the secrets of a channel for use in responses
+func MaskedChannel(channel *database.NotificationChannel) *database.NotificationChannel {
+	maskChannel(channel)
+	return channel
+}
+
+func maskChannel(channel *database.NotificationChannel) {
+	if channel.Settings.Secret != "" {
+		channel.Settings.Secret = maskedSecret
+	}
+	if channel.Settings.Password != "" {
+		channel.Settings.Password = maskedSecret
+	}
+	if channel.Settings.BotToken != "" {
+		channel.Settings.BotToken = maskedSecret
+	}
+}
+
+func lowerList(values []string) []string {
+	var out []string
+	for _, value := range values {
+		if value = strings.ToLower(strings.TrimSpace(value)); value != "" {
+			out = append(out, value)
+		}
+	}
+	return out
+}
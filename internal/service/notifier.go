@@ -0,0 +1,342 @@
+package service
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+
+	"gorm.io/gorm"
+)
+
+const notificationBatchSize = 50
+
+// Notifier sends queued notifications, retrying failed ones with exponential
+// backoff, and queues escalations of unacknowledged alerts
+type Notifier struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+// NewNotifier creates a new Notifier
+func NewNotifier(db *gorm.DB, cfg *config.Config) *Notifier {
+	return &Notifier{db: db, cfg: cfg}
+}
+
+// Start launches the delivery loop
+func (n *Notifier) Start() {
+	if !n.cfg.Notifications.Enabled {
+		log.Printf("[NOTIFY] Disabled by configuration")
+		return
+	}
+	interval := n.cfg.Notifications.PollInterval
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			now := time.Now()
+			if err := NewAlertService(n.db, n.cfg).Escalate(now); err != nil {
+				log.Printf("[NOTIFY] Escalation failed: %v", err)
+			}
+			n.processDue(now)
+		}
+	}()
+	log.Printf("[NOTIFY] Started (poll interval %s)", interval)
+}
+
+// processDue sends every pending notification whose next attempt is due
+func (n *Notifier) processDue(now time.Time) {
+	var due []database.Notification
+	if err := n.db.Where("status = ? AND next_attempt_at <= ?", NotificationPending, now).
+		Order("next_attempt_at ASC").
+		Limit(notificationBatchSize).
+		Find(&due).Error; err != nil {
+		log.Printf("[NOTIFY] Failed to load queued notifications: %v", err)
+		return
+	}
+
+	channels := map[uint]*database.NotificationChannel{}
+	for i := range due {
+		notification := &due[i]
+		channel, ok := channels[notification.ChannelID]
+		if !ok {
+			var loaded database.NotificationChannel
+			if err := n.db.First(&loaded, notification.ChannelID).Error; err == nil {
+				decodeChannel(&loaded)
+				channel = &loaded
+			}
+			channels[notification.ChannelID] = channel
+		}
+
+		notification.Attempts++
+		var err error
+		if channel == nil {
+			err = fmt.Errorf("channel no longer exists")
+			notification.Attempts = n.maxAttempts()
+		} else {
+			err = n.deliver(channel, notification)
+		}
+
+		sentAt := time.Now()
+		switch {
+		case err == nil:
+			notification.Status = NotificationSent
+			notification.SentAt = &sentAt
+			notification.LastError = ""
+		case notification.Attempts >= n.maxAttempts():
+			notification.Status = NotificationFailed
+			notification.LastError = err.Error()
+			log.Printf("[NOTIFY] Notification %d to channel %d failed after %d attempts: %v",
+				notification.ID, notification.ChannelID, notification.Attempts, err)
+		default:
+			notification.LastError = err.Error()
+			notification.NextAttemptAt = sentAt.Add(n.backoff(notification.Attempts))
+		}
+		if err := n.db.Save(notification).Error; err != nil {
+			log.Printf("[NOTIFY] Failed to update notification %d: %v", notification.ID, err)
+		}
+	}
+}
+
+func (n *Notifier) maxAttempts() int {
+	if n.cfg.Notifications.MaxAttempts <= 0 {
+		return 5
+	}
+	return n.cfg.Notifications.MaxAttempts
+}
+
+// backoff returns the delay before the next attempt, doubling per failed
+// attempt up to the configured maximum
+func (n *Notifier) backoff(attempts int) time.Duration {
+	delay := n.cfg.Notifications.Backoff
+	if delay <= 0 {
+		delay = 30 * time.Second
+	}
+	limit := n.cfg.Notifications.MaxBackoff
+	for i := 1; i < attempts; i++ {
+		delay *= 2
+		if limit > 0 && delay >= limit {
+			return limit
+		}
+	}
+	return delay
+}
+
+func (n *Notifier) timeout() time.Duration {
+	if n.cfg.Notifications.Timeout <= 0 {
+		return 10 * time.Second
+	}
+	return n.cfg.Notifications.Timeout
+}
+
+// deliver sends one notification through its channel
+func (n *Notifier) deliver(channel *database.NotificationChannel, notification *database.Notification) error {
+	settings := channel.Settings
+	switch channel.Type {
+	case ChannelWebhook:
+		body := []byte(notification.PayloadJSON)
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		headers := map[string]string{"X-OLT-Event": notification.Event, "X-OLT-Timestamp": timestamp}
+		if settings.Secret != "" {
+			headers["X-OLT-Signature"] = "sha256=" + SignWebhook(settings.Secret, timestamp, body)
+		}
+		return n.postJSON(settings.URL, body, headers, settings.Headers)
+
+	case ChannelSlack:
+		body, _ := json.Marshal(map[string]string{"text": notification.Body})
+		return n.postJSON(settings.URL, body, nil, settings.Headers)
+
+	case ChannelTelegram:
+		apiURL := strings.TrimRight(settings.APIURL, "/")
+		if apiURL == "" {
+			apiURL = "https://api.telegram.org"
+		}
+		body, _ := json.Marshal(map[string]interface{}{
+			"chat_id":                  settings.ChatID,
+			"text":                     notification.Body,
+			"disable_web_page_preview": true,
+		})
+		return n.postJSON(apiURL+"/bot"+settings.BotToken+"/sendMessage", body, nil, nil)
+
+	case ChannelEmail:
+		return n.sendEmail(settings, notification.Subject, notification.Body)
+	}
+	return fmt.Errorf("unsupported channel type '%s'", channel.Type)
+}
+
+// SignWebhook returns the hex HMAC-SHA256 of "<timestamp>.<body>"
+func SignWebhook(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// postJSON posts a JSON body. Errors never quote the URL, which may carry a
+// secret such as a Telegram bot token.
+func (n *Notifier) postJSON(target string, body []byte, headers, extra map[string]string) error {
+	req, err := http.NewRequest(http.MethodPost, target, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("invalid request: %w", redactURLError(err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "olt-api-notifier")
+	for key, value := range extra {
+		req.Header.Set(key, value)
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	client := &http.Client{Timeout: n.timeout()}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("request to %s failed: %w", req.URL.Host, redactURLError(err))
+	}
+	defer resp.Body.Close()
+
+	respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// redactURLError drops the URL that *url.Error quotes in its message
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		return urlErr.Err
+	}
+	return err
+}
+
+// plainTextAuth is PLAIN authentication without the TLS requirement of
+// smtp.PlainAuth, used only when a channel explicitly sets tls to none
+type plainTextAuth struct {
+	username, password string
+}
+
+func (a plainTextAuth) Start(_ *smtp.ServerInfo) (string, []byte, error) {
+	return "PLAIN", []byte("\x00" + a.username + "\x00" + a.password), nil
+}
+
+func (a plainTextAuth) Next(_ []byte, more bool) ([]byte, error) {
+	if more {
+		return nil, fmt.Errorf("unexpected server challenge")
+	}
+	return nil, nil
+}
+
+func (n *Notifier) sendEmail(settings database.NotificationSettings, subject, body string) error {
+	mode := settings.TLS
+	if mode == "" {
+		mode = "starttls"
+	}
+	port := settings.SMTPPort
+	if port == 0 {
+		switch mode {
+		case "tls":
+			port = 465
+		case "none":
+			port = 25
+		default:
+			port = 587
+		}
+	}
+	addr := net.JoinHostPort(settings.SMTPHost, strconv.Itoa(port))
+	tlsConfig := &tls.Config{ServerName: settings.SMTPHost}
+
+	dialer := &net.Dialer{Timeout: n.timeout()}
+	var conn net.Conn
+	var err error
+	if mode == "tls" {
+		conn, err = tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	} else {
+		conn, err = dialer.Dial("tcp", addr)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to %s: %w", addr, err)
+	}
+	_ = conn.SetDeadline(time.Now().Add(n.timeout()))
+
+	client, err := smtp.NewClient(conn, settings.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("smtp handshake failed: %w", err)
+	}
+	defer client.Close()
+
+	if mode == "starttls" {
+		if ok, _ := client.Extension("STARTTLS"); !ok {
+			return fmt.Errorf("server does not support STARTTLS (set tls to none to send in clear text)")
+		}
+		if err := client.StartTLS(tlsConfig); err != nil {
+			return fmt.Errorf("starttls failed: %w", err)
+		}
+	}
+	// Without a username the message is sent unauthenticated. Credentials go
+	// in clear text only when the channel explicitly disables TLS.
+	if settings.Username != "" {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("server does not offer AUTH (clear username to send without authentication)")
+		}
+		var auth smtp.Auth = smtp.PlainAuth("", settings.Username, settings.Password, settings.SMTPHost)
+		if mode == "none" {
+			auth = plainTextAuth{username: settings.Username, password: settings.Password}
+		}
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth failed: %w", err)
+		}
+	}
+
+	if err := client.Mail(settings.From); err != nil {
+		return fmt.Errorf("smtp MAIL FROM failed: %w", err)
+	}
+	for _, to := range settings.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp RCPT TO %s failed: %w", to, err)
+		}
+	}
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp DATA failed: %w", err)
+	}
+
+	var msg strings.Builder
+	msg.WriteString("From: " + settings.From + "\r\n")
+	msg.WriteString("To: " + strings.Join(settings.To, ", ") + "\r\n")
+	msg.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	msg.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body, "\n", "\r\n") + "\r\n")
+	if _, err := writer.Write([]byte(msg.String())); err != nil {
+		return fmt.Errorf("failed to write message: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("smtp message rejected: %w", err)
+	}
+	return client.Quit()
+}
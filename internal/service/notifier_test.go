@@ -0,0 +1,263 @@
+package service
+
+import (
+	"bufio"
+	"encoding/base64"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+)
+
+func testNotifier() *Notifier {
+	return NewNotifier(nil, &config.Config{
+		Notifications: config.NotificationsConfig{Timeout: 5 * time.Second},
+	})
+}
+
+func testNotification() *database.Notification {
+	return &database.Notification{
+		Event:       "firing",
+		Subject:     "ONU 0/1:2 offline",
+		Body:        "ONU 0/1:2 on olt-1 went offline",
+		PayloadJSON: `{"event":"firing"}`,
+	}
+}
+
+func TestDeliverWebhookSignsBody(t *testing.T) {
+	var gotBody []byte
+	var gotHeader http.Header
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotBody, _ = io.ReadAll(r.Body)
+		gotHeader = r.Header.Clone()
+	}))
+	defer server.Close()
+
+	channel := &database.NotificationChannel{
+		Type: ChannelWebhook,
+		Settings: database.NotificationSettings{
+			URL:     server.URL,
+			Secret:  "s3cret",
+			Headers: map[string]string{"X-Extra": "1"},
+		},
+	}
+	if err := testNotifier().deliver(channel, testNotification()); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+
+	if string(gotBody) != `{"event":"firing"}` {
+		t.Errorf("body = %s", gotBody)
+	}
+	if gotHeader.Get("X-OLT-Event") != "firing" || gotHeader.Get("X-Extra") != "1" {
+		t.Errorf("headers = %v", gotHeader)
+	}
+	want := "sha256=" + SignWebhook("s3cret", gotHeader.Get("X-OLT-Timestamp"), gotBody)
+	if got := gotHeader.Get("X-OLT-Signature"); got != want {
+		t.Errorf("signature = %s, want %s", got, want)
+	}
+}
+
+func TestDeliverWebhookRejectsErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "nope", http.StatusBadGateway)
+	}))
+	defer server.Close()
+
+	channel := &database.NotificationChannel{Type: ChannelSlack, Settings: database.NotificationSettings{URL: server.URL}}
+	err := testNotifier().deliver(channel, testNotification())
+	if err == nil || !strings.Contains(err.Error(), "unexpected status 502") {
+		t.Fatalf("err = %v, want unexpected status 502", err)
+	}
+}
+
+func TestDeliverTelegramErrorHidesToken(t *testing.T) {
+	const token = "123456:SECRET-TOKEN"
+
+	var gotPath string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPath = r.URL.Path
+	}))
+	channel := &database.NotificationChannel{
+		Type:     ChannelTelegram,
+		Settings: database.NotificationSettings{BotToken: token, ChatID: "42", APIURL: server.URL},
+	}
+	if err := testNotifier().deliver(channel, testNotification()); err != nil {
+		t.Fatalf("deliver: %v", err)
+	}
+	if gotPath != "/bot"+token+"/sendMessage" {
+		t.Errorf("path = %s", gotPath)
+	}
+
+	// A failed request must not quote the URL carrying the token
+	server.Close()
+	err := testNotifier().deliver(channel, testNotification())
+	if err == nil {
+		t.Fatal("expected an error from a closed server")
+	}
+	if strings.Contains(err.Error(), token) {
+		t.Errorf("error leaks the bot token: %v", err)
+	}
+}
+
+// smtpSession is what the fake SMTP server received
+type smtpSession struct {
+	auth string
+	from string
+	to   []string
+	data string
+}
+
+// startFakeSMTP serves one SMTP session on addr, offering AUTH PLAIN when
+// withAuth is set. The session is sent on the returned channel once the
+// client quits.
+func startFakeSMTP(t *testing.T, addr string, withAuth bool) (string, <-chan smtpSession) {
+	t.Helper()
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		t.Skipf("cannot listen on %s: %v", addr, err)
+	}
+	t.Cleanup(func() { listener.Close() })
+
+	sessions := make(chan smtpSession, 1)
+	go func() {
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := bufio.NewReader(conn)
+		reply := func(line string) { _, _ = io.WriteString(conn, line+"\r\n") }
+
+		var session smtpSession
+		reply("220 fake ESMTP")
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				return
+			}
+			line = strings.TrimRight(line, "\r\n")
+			command := strings.ToUpper(line)
+			switch {
+			case strings.HasPrefix(command, "EHLO"):
+				if withAuth {
+					reply("250-fake")
+					reply("250 AUTH PLAIN")
+				} else {
+					reply("250 fake")
+				}
+			case strings.HasPrefix(command, "AUTH PLAIN "):
+				decoded, _ := base64.StdEncoding.DecodeString(line[len("AUTH PLAIN "):])
+				session.auth = string(decoded)
+				reply("235 ok")
+			case strings.HasPrefix(command, "MAIL FROM:"):
+				session.from = line[len("MAIL FROM:"):]
+				reply("250 ok")
+			case strings.HasPrefix(command, "RCPT TO:"):
+				session.to = append(session.to, line[len("RCPT TO:"):])
+				reply("250 ok")
+			case command == "DATA":
+				reply("354 go ahead")
+				var data strings.Builder
+				for {
+					dataLine, err := reader.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if dataLine == ".\r\n" {
+						break
+					}
+					data.WriteString(dataLine)
+				}
+				session.data = data.String()
+				reply("250 queued")
+			case command == "QUIT":
+				reply("221 bye")
+				sessions <- session
+				return
+			default:
+				reply("502 unsupported")
+			}
+		}
+	}()
+	return listener.Addr().String(), sessions
+}
+
+func emailSettings(t *testing.T, addr string) database.NotificationSettings {
+	t.Helper()
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	settings := database.NotificationSettings{
+		SMTPHost: host,
+		TLS:      "none",
+		From:     "olt@example.com",
+		To:       []string{"noc@example.com"},
+	}
+	settings.SMTPPort, _ = net.LookupPort("tcp", port)
+	return settings
+}
+
+func waitSession(t *testing.T, sessions <-chan smtpSession) smtpSession {
+	t.Helper()
+	select {
+	case session := <-sessions:
+		return session
+	case <-time.After(5 * time.Second):
+		t.Fatal("fake SMTP server saw no complete session")
+	}
+	return smtpSession{}
+}
+
+func TestSendEmailWithoutAuth(t *testing.T) {
+	addr, sessions := startFakeSMTP(t, "127.0.0.1:0", false)
+	settings := emailSettings(t, addr)
+
+	if err := testNotifier().sendEmail(settings, "ONU offline", "line one\nline two"); err != nil {
+		t.Fatalf("sendEmail: %v", err)
+	}
+	session := waitSession(t, sessions)
+	if session.auth != "" {
+		t.Errorf("unexpected AUTH: %q", session.auth)
+	}
+	if session.from != "<olt@example.com>" || len(session.to) != 1 || session.to[0] != "<noc@example.com>" {
+		t.Errorf("envelope = %s -> %v", session.from, session.to)
+	}
+	if !strings.Contains(session.data, "Subject: ONU offline\r\n") || !strings.Contains(session.data, "line one\r\nline two") {
+		t.Errorf("data = %q", session.data)
+	}
+}
+
+// A plain-text relay on a host other than localhost is refused by
+// smtp.PlainAuth; tls none must still authenticate
+func TestSendEmailPlainTextAuth(t *testing.T) {
+	addr, sessions := startFakeSMTP(t, "127.0.0.2:0", true)
+	settings := emailSettings(t, addr)
+	settings.Username = "relay"
+	settings.Password = "pw"
+
+	if err := testNotifier().sendEmail(settings, "ONU offline", "body"); err != nil {
+		t.Fatalf("sendEmail: %v", err)
+	}
+	if session := waitSession(t, sessions); session.auth != "\x00relay\x00pw" {
+		t.Errorf("auth = %q", session.auth)
+	}
+}
+
+func TestSendEmailAuthNotOffered(t *testing.T) {
+	addr, _ := startFakeSMTP(t, "127.0.0.1:0", false)
+	settings := emailSettings(t, addr)
+	settings.Username = "relay"
+	settings.Password = "pw"
+
+	err := testNotifier().sendEmail(settings, "ONU offline", "body")
+	if err == nil || !strings.Contains(err.Error(), "does not offer AUTH") {
+		t.Fatalf("err = %v, want AUTH not offered", err)
+	}
+}
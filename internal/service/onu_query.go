@@ -0,0 +1,275 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"olt-api/internal/parser"
+)
+
+// ONUSortFields lists the fields ONU lists can be sorted by
+var ONUSortFields = []string{
+	"onu_id", "name", "mac_address", "status", "fw_version", "chip_id",
+	"distance", "rx_power", "tx_power", "temperature",
+}
+
+// ONUSortKey is one sort criterion
+type ONUSortKey struct {
+	Field string
+	Desc  bool
+}
+
+// ONUQuery filters, sorts, pages and projects an ONU list.
+// Nil range bounds and empty lists are not applied. A reading the OLT did
+// not report fails any range on it and sorts last in both directions.
+type ONUQuery struct {
+	Status      []string
+	RxMin       *float64
+	RxMax       *float64
+	TxMin       *float64
+	TxMax       *float64
+	TempMin     *float64
+	TempMax     *float64
+	DistanceMin *int
+	DistanceMax *int
+	FwVersions  []string
+	ChipIDs     []string
+	Activated   *bool
+	NamePattern *regexp.Regexp // case-insensitive regex
+	MACPattern  string         // normalized MAC fragment
+
+	Sort     []ONUSortKey
+	Page     int // 1-based; 0 disables paging
+	PageSize int
+	Fields   []string // JSON field names to keep; empty keeps all
+}
+
+// ParseONUSort parses "-rx_power,name" into sort keys
+func ParseONUSort(raw string) ([]ONUSortKey, error) {
+	var keys []ONUSortKey
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.ToLower(strings.TrimSpace(part))
+		if part == "" {
+			continue
+		}
+		key := ONUSortKey{Field: part}
+		if strings.HasPrefix(part, "-") {
+			key = ONUSortKey{Field: part[1:], Desc: true}
+		} else if strings.HasPrefix(part, "+") {
+			key.Field = part[1:]
+		}
+		if !containsString(ONUSortFields, key.Field) {
+			return nil, fmt.Errorf("unsupported sort field: %s (supported: %s)", key.Field, strings.Join(ONUSortFields, ", "))
+		}
+		keys = append(keys, key)
+	}
+	return keys, nil
+}
+
+// Apply filters and sorts onus, then returns the requested page and the
+// number of ONUs that matched before paging.
+func (q *ONUQuery) Apply(onus []parser.ONUResponse) ([]parser.ONUResponse, int) {
+	matched := make([]parser.ONUResponse, 0, len(onus))
+	for _, onu := range onus {
+		if q.matches(onu) {
+			matched = append(matched, onu)
+		}
+	}
+
+	if len(q.Sort) > 0 {
+		sort.SliceStable(matched, func(i, j int) bool {
+			for _, key := range q.Sort {
+				reportedA, reportedB := metricReported(matched[i], key.Field), metricReported(matched[j], key.Field)
+				if reportedA != reportedB {
+					return reportedA
+				}
+				if !reportedA {
+					continue
+				}
+				cmp := compareONUs(matched[i], matched[j], key.Field)
+				if cmp == 0 {
+					continue
+				}
+				if key.Desc {
+					return cmp > 0
+				}
+				return cmp < 0
+			}
+			return false
+		})
+	}
+
+	total := len(matched)
+	if q.Page <= 0 || q.PageSize <= 0 {
+		return matched, total
+	}
+	start := (q.Page - 1) * q.PageSize
+	if start >= total {
+		return []parser.ONUResponse{}, total
+	}
+	end := start + q.PageSize
+	if end > total {
+		end = total
+	}
+	return matched[start:end], total
+}
+
+func (q *ONUQuery) matches(onu parser.ONUResponse) bool {
+	if len(q.Status) > 0 && !containsString(q.Status, strings.ToLower(onu.Status)) {
+		return false
+	}
+	if !inMetricRange(onu, "rx_power", onu.Metrics.RxPower, q.RxMin, q.RxMax) ||
+		!inMetricRange(onu, "tx_power", onu.Metrics.TxPower, q.TxMin, q.TxMax) ||
+		!inMetricRange(onu, "temperature", onu.Metrics.Temperature, q.TempMin, q.TempMax) {
+		return false
+	}
+	if q.DistanceMin != nil && onu.Distance < *q.DistanceMin {
+		return false
+	}
+	if q.DistanceMax != nil && onu.Distance > *q.DistanceMax {
+		return false
+	}
+	if len(q.FwVersions) > 0 && !containsString(q.FwVersions, strings.ToLower(onu.FwVersion)) {
+		return false
+	}
+	if len(q.ChipIDs) > 0 && !containsString(q.ChipIDs, strings.ToLower(onu.ChipID)) {
+		return false
+	}
+	if q.Activated != nil && onu.IsActivated != *q.Activated {
+		return false
+	}
+	if q.NamePattern != nil && !q.NamePattern.MatchString(onu.Name) {
+		return false
+	}
+	if q.MACPattern != "" && !strings.Contains(NormalizeMAC(onu.MacAddress), q.MACPattern) {
+		return false
+	}
+	return true
+}
+
+// Project reduces each ONU to the requested JSON fields. It returns onus
+// unchanged when no fields are requested.
+func (q *ONUQuery) Project(onus []parser.ONUResponse) (interface{}, error) {
+	if len(q.Fields) == 0 {
+		return onus, nil
+	}
+
+	projected := make([]map[string]interface{}, 0, len(onus))
+	for _, onu := range onus {
+		raw, err := json.Marshal(onu)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode ONU: %w", err)
+		}
+		var full map[string]interface{}
+		if err := json.Unmarshal(raw, &full); err != nil {
+			return nil, fmt.Errorf("failed to encode ONU: %w", err)
+		}
+
+		row := make(map[string]interface{}, len(q.Fields))
+		for _, field := range q.Fields {
+			if value, ok := full[field]; ok {
+				row[field] = value
+			}
+		}
+		projected = append(projected, row)
+	}
+	return projected, nil
+}
+
+func compareONUs(a, b parser.ONUResponse, field string) int {
+	switch field {
+	case "onu_id":
+		return compareONUIDs(a.ONUID, b.ONUID)
+	case "name":
+		return strings.Compare(strings.ToLower(a.Name), strings.ToLower(b.Name))
+	case "mac_address":
+		return strings.Compare(NormalizeMAC(a.MacAddress), NormalizeMAC(b.MacAddress))
+	case "status":
+		return strings.Compare(a.Status, b.Status)
+	case "fw_version":
+		return strings.Compare(a.FwVersion, b.FwVersion)
+	case "chip_id":
+		return strings.Compare(a.ChipID, b.ChipID)
+	case "distance":
+		return compareFloats(float64(a.Distance), float64(b.Distance))
+	case "rx_power":
+		return compareFloats(a.Metrics.RxPower, b.Metrics.RxPower)
+	case "tx_power":
+		return compareFloats(a.Metrics.TxPower, b.Metrics.TxPower)
+	case "temperature":
+		return compareFloats(a.Metrics.Temperature, b.Metrics.Temperature)
+	}
+	return 0
+}
+
+// compareONUIDs orders "0/1:2" before "0/1:10" by comparing numeric parts
+func compareONUIDs(a, b string) int {
+	split := func(id string) []string {
+		return strings.FieldsFunc(id, func(r rune) bool { return r == '/' || r == ':' })
+	}
+	pa, pb := split(a), split(b)
+	for i := 0; i < len(pa) && i < len(pb); i++ {
+		na, errA := strconv.Atoi(pa[i])
+		nb, errB := strconv.Atoi(pb[i])
+		if errA == nil && errB == nil {
+			if na != nb {
+				return compareFloats(float64(na), float64(nb))
+			}
+			continue
+		}
+		if cmp := strings.Compare(pa[i], pb[i]); cmp != 0 {
+			return cmp
+		}
+	}
+	return len(pa) - len(pb)
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// metricReported reports whether the OLT reported the metric an ONU field
+// holds, from Metrics.Unreported; fields that are not optical readings always
+// count as reported. Live ONU lists are checked with it everywhere (queries,
+// PON stats, alerts and health scores); stored logs follow database.ONULog.
+func metricReported(onu parser.ONUResponse, field string) bool {
+	return !containsString(onu.Metrics.Unreported, field)
+}
+
+// inMetricRange checks a reading against a range; an unreported reading
+// fails any bound
+func inMetricRange(onu parser.ONUResponse, field string, value float64, min, max *float64) bool {
+	if (min != nil || max != nil) && !metricReported(onu, field) {
+		return false
+	}
+	return inFloatRange(value, min, max)
+}
+
+func inFloatRange(value float64, min, max *float64) bool {
+	if min != nil && value < *min {
+		return false
+	}
+	if max != nil && value > *max {
+		return false
+	}
+	return true
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
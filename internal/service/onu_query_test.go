@@ -0,0 +1,53 @@
+package service
+
+import (
+	"testing"
+
+	"olt-api/internal/parser"
+)
+
+func queryTestONUs() []parser.ONUResponse {
+	return []parser.ONUResponse{
+		{ONUID: "0/1:1", Metrics: parser.ONUMetrics{RxPower: -20, Temperature: 40}},
+		{ONUID: "0/1:2", Metrics: parser.ONUMetrics{Temperature: 45, Unreported: []string{"rx_power"}}},
+		{ONUID: "0/1:3", Metrics: parser.ONUMetrics{RxPower: -27, Temperature: 50}},
+	}
+}
+
+func onuIDs(onus []parser.ONUResponse) []string {
+	ids := make([]string, len(onus))
+	for i, onu := range onus {
+		ids[i] = onu.ONUID
+	}
+	return ids
+}
+
+func TestONUQueryUnreportedReadings(t *testing.T) {
+	rxMax, rxMin := -25.0, -30.0
+	tests := []struct {
+		name  string
+		query ONUQuery
+		want  []string
+	}{
+		{"rx max skips unreported", ONUQuery{RxMax: &rxMax}, []string{"0/1:3"}},
+		{"rx min skips unreported", ONUQuery{RxMin: &rxMin}, []string{"0/1:1", "0/1:3"}},
+		{"no rx range keeps unreported", ONUQuery{}, []string{"0/1:1", "0/1:2", "0/1:3"}},
+		{"rx ascending puts unreported last", ONUQuery{Sort: []ONUSortKey{{Field: "rx_power"}}}, []string{"0/1:3", "0/1:1", "0/1:2"}},
+		{"rx descending puts unreported last", ONUQuery{Sort: []ONUSortKey{{Field: "rx_power", Desc: true}}}, []string{"0/1:1", "0/1:3", "0/1:2"}},
+		{"reported temperature sorts normally", ONUQuery{Sort: []ONUSortKey{{Field: "temperature", Desc: true}}}, []string{"0/1:3", "0/1:2", "0/1:1"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, total := tt.query.Apply(queryTestONUs())
+			ids := onuIDs(got)
+			if total != len(tt.want) || len(ids) != len(tt.want) {
+				t.Fatalf("got %v (total %d), want %v", ids, total, tt.want)
+			}
+			for i := range ids {
+				if ids[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", ids, tt.want)
+				}
+			}
+		})
+	}
+}
@@ -1,9 +1,11 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"log"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -34,6 +36,18 @@ func NewONUService(db *gorm.DB, cfg *config.Config, deviceService *DeviceService
 	}
 }
 
+func (s *ONUService) subscribers() *SubscriberService {
+	return NewSubscriberService(s.db, s.cfg, s.deviceService)
+}
+
+// decorateONUs attaches locally stored subscriber records, tags and the
+// health score
+func (s *ONUService) decorateONUs(deviceID string, onus []parser.ONUResponse) {
+	s.subscribers().AttachToONUs(deviceID, onus)
+	NewTagService(s.db).AttachToONUs(deviceID, onus)
+	NewHealthService(s.db, s.cfg, s.deviceService).AttachToONUs(onus)
+}
+
 // GetONUsByPON retrieves ONUs for a specific PON port
 func (s *ONUService) GetONUsByPON(deviceID, ponID string, filter string) ([]parser.ONUResponse, error) {
 	// Check cache first
@@ -43,7 +57,9 @@ func (s *ONUService) GetONUsByPON(deviceID, ponID string, filter string) ([]pars
 			var onus []parser.ONUResponse
 			if err := json.Unmarshal([]byte(cached), &onus); err == nil {
 				log.Printf("[ONU] Cache hit for device %s PON %s", deviceID, ponID)
-				return s.filterONUs(onus, filter), nil
+				onus = s.filterONUs(onus, filter)
+				s.decorateONUs(deviceID, onus)
+				return onus, nil
 			}
 		}
 	}
@@ -55,7 +71,7 @@ func (s *ONUService) GetONUsByPON(deviceID, ponID string, filter string) ([]pars
 	}
 
 	// Fetch ONU list from OLT with endpoint fallback.
-	onus, err := s.fetchONUsWithFallback(client, ponID)
+	onus, _, err := s.fetchONUsWithFallback(deviceID, client, ponID)
 	if err != nil {
 		return nil, err
 	}
@@ -67,11 +83,10 @@ func (s *ONUService) GetONUsByPON(deviceID, ponID string, filter string) ([]pars
 		}
 	}
 
-	// Log ONU data for history
-	go s.logONUs(deviceID, onus)
-
 	log.Printf("[ONU] Fetched %d ONUs from device %s PON %s", len(onus), deviceID, ponID)
-	return s.filterONUs(onus, filter), nil
+	filtered := s.filterONUs(onus, filter)
+	s.decorateONUs(deviceID, filtered)
+	return filtered, nil
 }
 
 // GetONUDetail retrieves detailed information for a specific ONU
@@ -92,6 +107,8 @@ func (s *ONUService) GetONUDetail(deviceID, onuID string) (*parser.ONUDetailResp
 		if cached, ok := database.GetCache(s.db, cacheKey); ok {
 			var detail parser.ONUDetailResponse
 			if err := json.Unmarshal([]byte(cached), &detail); err == nil {
+				detail.Subscriber = onuSubscriber(s.subscribers().ForONU(deviceID, detail.MacAddress))
+				NewHealthService(s.db, s.cfg, s.deviceService).AttachToDetail(&detail)
 				return &detail, nil
 			}
 		}
@@ -118,6 +135,7 @@ func (s *ONUService) GetONUDetail(deviceID, onuID string) (*parser.ONUDetailResp
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse ONU detail: %w", err)
 	}
+	detail.Distance = s.distanceOf(deviceID, ponNo, onuID)
 
 	// Cache result
 	if s.cfg.Cache.Enabled {
@@ -126,9 +144,36 @@ func (s *ONUService) GetONUDetail(deviceID, onuID string) (*parser.ONUDetailResp
 		}
 	}
 
+	detail.Subscriber = onuSubscriber(s.subscribers().ForONU(deviceID, detail.MacAddress))
+	NewHealthService(s.db, s.cfg, s.deviceService).AttachToDetail(detail)
 	return detail, nil
 }
 
+// distanceOf returns the distance of an ONU, which the detail page does not
+// show, from the cached PON list or else the ONU inventory. It never reads
+// the PON list from the OLT and returns 0 when neither has the ONU.
+func (s *ONUService) distanceOf(deviceID, ponNo, onuID string) int {
+	if s.cfg.Cache.Enabled {
+		if cached, ok := database.GetCache(s.db, fmt.Sprintf("onus:%s:%s", deviceID, ponNo)); ok {
+			var onus []parser.ONUResponse
+			if err := json.Unmarshal([]byte(cached), &onus); err == nil {
+				for _, onu := range onus {
+					if onu.ONUID == onuID {
+						return onu.Distance
+					}
+				}
+			}
+		}
+	}
+
+	var item database.ONUInventory
+	if err := s.db.Where("device_id = ? AND onu_id = ? AND present = ?", deviceID, onuID, true).
+		First(&item).Error; err == nil {
+		return item.Distance
+	}
+	return 0
+}
+
 // GetONUTraffic retrieves traffic counters for a specific ONU.
 func (s *ONUService) GetONUTraffic(deviceID, onuID string) (*parser.ONUTrafficResponse, error) {
 	parts := strings.Split(onuID, ":")
@@ -209,6 +254,24 @@ type ONUActionRequest struct {
 	Action string `json:"action" binding:"required"` // reboot, activate, deactivate, factory
 }
 
+// onuOperationFor maps an API action name to the setOnu onuOperation value
+func onuOperationFor(action string) (string, error) {
+	switch strings.ToLower(action) {
+	case "reboot":
+		return "rebootOp", nil
+	case "activate":
+		return "activeOp", nil
+	case "deactivate":
+		return "noactiveOp", nil
+	case "factory":
+		return "restoreOp", nil
+	case "cleanloop":
+		return "cleanLoopOp", nil
+	default:
+		return "", fmt.Errorf("unsupported action: %s (supported: reboot, activate, deactivate, factory, cleanloop)", action)
+	}
+}
+
 // PerformAction executes an action on an ONU
 // All actions use POST /goform/setOnu with onuOperation field
 func (s *ONUService) PerformAction(deviceID, onuID string, action string) error {
@@ -220,20 +283,9 @@ func (s *ONUService) PerformAction(deviceID, onuID string, action string) error
 	ponNo := parts[0]
 
 	// Map action to onuOperation value
-	var onuOperation string
-	switch strings.ToLower(action) {
-	case "reboot":
-		onuOperation = "rebootOp"
-	case "activate":
-		onuOperation = "activeOp"
-	case "deactivate":
-		onuOperation = "noactiveOp"
-	case "factory":
-		onuOperation = "restoreOp"
-	case "cleanloop":
-		onuOperation = "cleanLoopOp"
-	default:
-		return fmt.Errorf("unsupported action: %s (supported: reboot, activate, deactivate, factory, cleanloop)", action)
+	onuOperation, err := onuOperationFor(action)
+	if err != nil {
+		return err
 	}
 
 	// Get current ONU detail to preserve the name
@@ -312,8 +364,38 @@ func (s *ONUService) DeleteONU(deviceID, onuID string) error {
 	return nil
 }
 
+// PONFetchResult reports how the ONU list of one PON was fetched
+type PONFetchResult struct {
+	PONID     string `json:"pon_id"`
+	Success   bool   `json:"success"`
+	Count     int    `json:"count"`
+	Endpoint  string `json:"endpoint,omitempty"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// DeviceONUsResult holds all ONUs of a device plus the per-PON fetch results
+type DeviceONUsResult struct {
+	DeviceID   string               `json:"device_id"`
+	Total      int                  `json:"total"`
+	Complete   bool                 `json:"complete"`
+	FailedPONs int                  `json:"failed_pons"`
+	PONs       []PONFetchResult     `json:"pons"`
+	ONUs       []parser.ONUResponse `json:"onus"`
+}
+
 // GetAllONUs retrieves all ONUs across all PON ports for a device
 func (s *ONUService) GetAllONUs(deviceID string, filter string) ([]parser.ONUResponse, error) {
+	result, err := s.GetAllONUsWithResults(deviceID, filter)
+	if err != nil {
+		return nil, err
+	}
+	return result.ONUs, nil
+}
+
+// GetAllONUsWithResults retrieves all ONUs of a device and reports the outcome
+// of every PON fetch, so a failed PON is not mistaken for missing ONUs.
+func (s *ONUService) GetAllONUsWithResults(deviceID string, filter string) (*DeviceONUsResult, error) {
 	// Get PON list first
 	ponService := NewPONService(s.db, s.cfg, s.deviceService)
 	pons, err := ponService.GetPONList(deviceID)
@@ -331,20 +413,39 @@ func (s *ONUService) GetAllONUs(deviceID string, filter string) ([]parser.ONURes
 	pool := scraper.NewWorkerPool(s.cfg.Scraper.MaxWorkers)
 	defer pool.Close()
 
-	var allONUs []parser.ONUResponse
+	allONUs := []parser.ONUResponse{}
 	var mu sync.Mutex
+	ponResults := make([]PONFetchResult, len(pons))
 
 	// Fetch ONUs from each PON port concurrently
-	for _, pon := range pons {
-		ponID := pon.PONID
+	for i, pon := range pons {
+		i := i
+		ponID := pon.FullID
+		cacheKey := fmt.Sprintf("onus:%s:%s", deviceID, ponID)
 		pool.Submit(func() {
-			onus, err := s.fetchONUsWithFallback(client, ponID)
+			started := time.Now()
+			onus, endpoint, err := s.fetchONUsWithFallback(deviceID, client, ponID)
+			result := PONFetchResult{
+				PONID:     ponID,
+				Success:   err == nil,
+				Count:     len(onus),
+				Endpoint:  endpoint,
+				LatencyMs: time.Since(started).Milliseconds(),
+			}
 			if err != nil {
 				log.Printf("[ONU] Failed to fetch ONUs from PON %s: %v", ponID, err)
-				return
+				result.Error = err.Error()
+			}
+
+			if err == nil && s.cfg.Cache.Enabled {
+				// Keep per-PON cache fresh so cached lookups (e.g. search) see it
+				if data, err := json.Marshal(onus); err == nil {
+					database.SetCache(s.db, cacheKey, string(data), s.cfg.Cache.TTL)
+				}
 			}
 
 			mu.Lock()
+			ponResults[i] = result
 			allONUs = append(allONUs, onus...)
 			mu.Unlock()
 		})
@@ -352,11 +453,124 @@ func (s *ONUService) GetAllONUs(deviceID string, filter string) ([]parser.ONURes
 
 	pool.Wait()
 
-	log.Printf("[ONU] Fetched %d total ONUs from device %s", len(allONUs), deviceID)
-	return s.filterONUs(allONUs, filter), nil
+	failed := 0
+	for _, result := range ponResults {
+		if !result.Success {
+			failed++
+		}
+	}
+	log.Printf("[ONU] Fetched %d total ONUs from device %s (%d/%d PONs failed)", len(allONUs), deviceID, failed, len(pons))
+
+	filtered := s.filterONUs(allONUs, filter)
+	sort.SliceStable(filtered, func(i, j int) bool {
+		return compareONUIDs(filtered[i].ONUID, filtered[j].ONUID) < 0
+	})
+	s.decorateONUs(deviceID, filtered)
+
+	return &DeviceONUsResult{
+		DeviceID:   deviceID,
+		Total:      len(filtered),
+		Complete:   failed == 0,
+		FailedPONs: failed,
+		PONs:       ponResults,
+		ONUs:       filtered,
+	}, nil
+}
+
+// JobTypeONUScan is the job type for full-OLT ONU scans
+const JobTypeONUScan = "onu.scan"
+
+// ONUScanRequest is the payload of a full-OLT ONU scan job
+type ONUScanRequest struct {
+	Filter string `json:"filter"`
+}
+
+// ONUScanResult is stored as the result of a completed scan job
+type ONUScanResult struct {
+	Total int                  `json:"total"`
+	ONUs  []parser.ONUResponse `json:"onus"`
 }
 
-func (s *ONUService) fetchONUsWithFallback(client *scraper.Client, ponID string) ([]parser.ONUResponse, error) {
+// ExecuteScan runs a claimed full-OLT ONU scan job. Every PON port becomes a
+// job item so partial failures are visible in the job progress.
+func (s *ONUService) ExecuteScan(ctx context.Context, job *database.Job) (interface{}, error) {
+	var req ONUScanRequest
+	if job.Payload != "" {
+		if err := json.Unmarshal([]byte(job.Payload), &req); err != nil {
+			return nil, fmt.Errorf("invalid job payload: %w", err)
+		}
+	}
+
+	ponService := NewPONService(s.db, s.cfg, s.deviceService)
+	pons, err := ponService.GetPONList(job.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := s.deviceService.GetClient(job.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	jobService := NewJobService(s.db)
+	targets := make([]string, 0, len(pons))
+	for _, pon := range pons {
+		targets = append(targets, pon.FullID)
+	}
+	if err := jobService.AddItems(job.ID, targets); err != nil {
+		return nil, fmt.Errorf("failed to create job items: %w", err)
+	}
+
+	pool := scraper.NewWorkerPool(s.cfg.Scraper.MaxWorkers)
+	defer pool.Close()
+
+	var allONUs []parser.ONUResponse
+	var mu sync.Mutex
+
+	for _, ponID := range targets {
+		ponID := ponID
+		pool.Submit(func() {
+			if ctx.Err() != nil {
+				return
+			}
+
+			onus, _, fetchErr := s.fetchONUsWithFallback(job.DeviceID, client, ponID)
+			if err := jobService.RecordItem(job.ID, ponID, fetchErr); err != nil {
+				log.Printf("[JOB] Failed to record item %s of job %d: %v", ponID, job.ID, err)
+			}
+			if fetchErr != nil {
+				return
+			}
+
+			if s.cfg.Cache.Enabled {
+				if data, err := json.Marshal(onus); err == nil {
+					database.SetCache(s.db, fmt.Sprintf("onus:%s:%s", job.DeviceID, ponID), string(data), s.cfg.Cache.TTL)
+				}
+			}
+
+			mu.Lock()
+			allONUs = append(allONUs, onus...)
+			mu.Unlock()
+		})
+	}
+	pool.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	filtered := s.filterONUs(allONUs, req.Filter)
+	log.Printf("[ONU] Scan job %d fetched %d ONUs from device %s", job.ID, len(allONUs), job.DeviceID)
+	return ONUScanResult{Total: len(filtered), ONUs: filtered}, nil
+}
+
+// onuListPageSize is the page size of firmware that paginates per-PON ONU lists
+const onuListPageSize = 8
+
+// fetchONUsWithFallback fetches the ONU list of one PON and records it in the
+// ONU inventory when successful, including an empty list. It also returns the
+// endpoint that was used.
+func (s *ONUService) fetchONUsWithFallback(deviceID string, client *scraper.Client, ponID string) ([]parser.ONUResponse, string, error) {
 	type attemptResult struct {
 		endpoint string
 		onus     []parser.ONUResponse
@@ -403,14 +617,8 @@ func (s *ONUService) fetchONUsWithFallback(client *scraper.Client, ponID string)
 			onus = filterONUsByPONPrefix(onus, ponID)
 		}
 		log.Printf("[ONU] Endpoint %s returned %d rows for PON %s", endpoint.path, len(onus), ponID)
-		if len(onus) == 0 {
-			results = append(results, attemptResult{
-				endpoint: endpoint.path,
-				err:      fmt.Errorf("%s returned no ONU rows for PON %s", endpoint.path, ponID),
-			})
-			continue
-		}
 
+		// A page without ONU rows is an empty PON, not a failure
 		results = append(results, attemptResult{
 			endpoint: endpoint.path,
 			onus:     onus,
@@ -418,24 +626,38 @@ func (s *ONUService) fetchONUsWithFallback(client *scraper.Client, ponID string)
 	}
 
 	var best []parser.ONUResponse
+	var bestEndpoint string
 	var errs []string
-	for _, result := range results {
+	allPonCount := -1
+	for i, result := range results {
 		if result.err != nil {
 			errs = append(errs, result.err.Error())
 			continue
 		}
-		if len(result.onus) > len(best) {
+		if endpoints[i].allPon {
+			allPonCount = len(result.onus)
+		}
+		if best == nil || len(result.onus) > len(best) {
 			best = result.onus
+			bestEndpoint = result.endpoint
 		}
 	}
 
-	if len(best) > 0 {
-		return best, nil
+	if best != nil {
+		// A full page from a paginated per-PON endpoint may be cut off; only
+		// record it when the all-PON endpoint saw as many ONUs
+		partial := len(best) > 0 && len(best)%onuListPageSize == 0 && allPonCount < len(best)
+		if partial {
+			log.Printf("[INVENTORY] Skipping device %s PON %s: %d rows may be a truncated page", deviceID, ponID, len(best))
+		} else if err := NewInventoryService(s.db).Record(deviceID, ponID, best); err != nil {
+			log.Printf("[INVENTORY] Failed to update inventory for device %s PON %s: %v", deviceID, ponID, err)
+		}
+		return best, bestEndpoint, nil
 	}
 	if len(errs) > 0 {
-		return nil, fmt.Errorf(strings.Join(errs, "; "))
+		return nil, "", fmt.Errorf("%s", strings.Join(errs, "; "))
 	}
-	return nil, fmt.Errorf("failed to fetch ONU list for PON %s", ponID)
+	return nil, "", fmt.Errorf("failed to fetch ONU list for PON %s", ponID)
 }
 
 func filterONUsByPONPrefix(onus []parser.ONUResponse, ponID string) []parser.ONUResponse {
@@ -517,10 +739,19 @@ func (s *ONUService) filterONUs(onus []parser.ONUResponse, filter string) []pars
 	return filtered
 }
 
-// logONUs saves ONU data to history log
-func (s *ONUService) logONUs(deviceID string, onus []parser.ONUResponse) {
+// WriteONULogs stores one history row per ONU in a single transaction,
+// inserting batchSize rows per statement
+func WriteONULogs(db *gorm.DB, deviceID string, onus []parser.ONUResponse, recordedAt time.Time, batchSize int) error {
+	if len(onus) == 0 {
+		return nil
+	}
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	logs := make([]database.ONULog, 0, len(onus))
 	for _, onu := range onus {
-		log := database.ONULog{
+		logs = append(logs, database.ONULog{
 			DeviceID:    deviceID,
 			ONUID:       onu.ONUID,
 			Name:        onu.Name,
@@ -528,10 +759,16 @@ func (s *ONUService) logONUs(deviceID string, onus []parser.ONUResponse) {
 			Temperature: onu.Metrics.Temperature,
 			TxPower:     onu.Metrics.TxPower,
 			RxPower:     onu.Metrics.RxPower,
-			RecordedAt:  time.Now(),
-		}
-		s.db.Create(&log)
+			RecordedAt:  recordedAt,
+		})
 	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.CreateInBatches(logs, batchSize).Error; err != nil {
+			return fmt.Errorf("failed to write ONU logs: %w", err)
+		}
+		return nil
+	})
 }
 
 // SaveConfig saves the OLT configuration
@@ -550,6 +787,42 @@ func (s *ONUService) SaveConfig(deviceID string) error {
 	return nil
 }
 
+// JobTypeSaveConfig is the job type for saving the OLT configuration
+const JobTypeSaveConfig = "device.save_config"
+
+// ExecuteSaveConfig runs a claimed save-config job and audits it as the job owner
+func (s *ONUService) ExecuteSaveConfig(ctx context.Context, job *database.Job) (interface{}, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	saveErr := s.SaveConfig(job.DeviceID)
+
+	metadata := map[string]interface{}{
+		"job_id":  job.ID,
+		"success": saveErr == nil,
+	}
+	if saveErr != nil {
+		metadata["error"] = saveErr.Error()
+	}
+	auditSvc := NewAuditService(s.db)
+	if err := auditSvc.Log(AuditLogEntry{
+		UserID:     job.UserID,
+		Username:   job.Username,
+		Role:       job.Role,
+		Action:     "device.config.saved",
+		Resource:   "device",
+		ResourceID: job.DeviceID,
+		Metadata:   metadata,
+		IPAddress:  job.IPAddress,
+		UserAgent:  job.UserAgent,
+	}); err != nil {
+		log.Printf("[AUDIT] failed to write log action=device.config.saved resource=device resource_id=%s: %v", job.DeviceID, err)
+	}
+
+	return nil, saveErr
+}
+
 // GetLogs retrieves recent ONU logs for a device
 func (s *ONUService) GetLogs(deviceID string, limit int) ([]database.ONULog, error) {
 	if limit <= 0 {
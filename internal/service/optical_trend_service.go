@@ -0,0 +1,350 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// Optical trend flags
+const (
+	TrendFlagDegrading = "degrading" // falling faster than the degradation rate
+	TrendFlagCrossing  = "crossing"  // projected to reach the sensitivity limit
+)
+
+const (
+	minTrendPoints = 24             // hourly averages needed for a fit
+	minTrendSpan   = 48 * time.Hour // first to last point
+)
+
+// opticalTrendMu keeps the periodic and on-demand analyses from overlapping
+var opticalTrendMu sync.Mutex
+
+// OpticalTrendFilter narrows a trend listing. Rate (dB per day) and Days
+// override the configured thresholds; All includes ONUs that are not flagged.
+type OpticalTrendFilter struct {
+	DeviceID string
+	PONID    string
+	All      bool
+	Rate     float64
+	Days     float64
+	Limit    int
+}
+
+// OpticalTrend is a stored trend with the reasons it is flagged
+type OpticalTrend struct {
+	database.ONUOpticalTrend
+	Flags      []string             `json:"flags"`
+	Subscriber *database.Subscriber `json:"subscriber,omitempty"`
+}
+
+// OpticalTrendReport lists ONU Rx trends, most urgent first
+type OpticalTrendReport struct {
+	AnalyzedAt       *time.Time     `json:"analyzed_at,omitempty"`
+	SensitivityLimit float64        `json:"sensitivity_limit"`
+	DegradationRate  float64        `json:"degradation_rate"`
+	ProjectionDays   float64        `json:"projection_days"`
+	Total            int            `json:"total"`
+	ONUs             []OpticalTrend `json:"onus"`
+}
+
+// trendRow is one hourly Rx average read for the analysis
+type trendRow struct {
+	DeviceID    string    `gorm:"column:device_id"`
+	ONUID       string    `gorm:"column:onu_id"`
+	BucketStart time.Time `gorm:"column:bucket_start"`
+	RxAvg       float64   `gorm:"column:rx_avg"`
+}
+
+// trendFit accumulates the least-squares sums of one ONU. x is in days
+// since the start of the window.
+type trendFit struct {
+	deviceID, onuID       string
+	n                     int
+	sx, sy, sxx, sxy, syy float64
+	first, last           trendRow
+	lastX                 float64
+}
+
+func (f *trendFit) add(row trendRow, x float64) {
+	if f.n == 0 {
+		f.first = row
+	}
+	f.n++
+	f.sx += x
+	f.sy += row.RxAvg
+	f.sxx += x * x
+	f.sxy += x * row.RxAvg
+	f.syy += row.RxAvg * row.RxAvg
+	f.last = row
+	f.lastX = x
+}
+
+// OpticalTrendService fits a linear trend to the Rx power history of every
+// ONU to find links degrading before they drop
+type OpticalTrendService struct {
+	db  *gorm.DB
+	cfg *config.Config
+}
+
+// NewOpticalTrendService creates a new OpticalTrendService
+func NewOpticalTrendService(db *gorm.DB, cfg *config.Config) *OpticalTrendService {
+	return &OpticalTrendService{db: db, cfg: cfg}
+}
+
+func (s *OpticalTrendService) window() time.Duration {
+	if s.cfg.Optics.TrendWindow <= 0 {
+		return 14 * 24 * time.Hour
+	}
+	return s.cfg.Optics.TrendWindow
+}
+
+func (s *OpticalTrendService) sensitivityLimit() float64 {
+	if s.cfg.Optics.SensitivityLimit == 0 {
+		return -28
+	}
+	return s.cfg.Optics.SensitivityLimit
+}
+
+// Analyze fits the hourly Rx averages of every ONU over the trend window and
+// replaces the stored trends. ONUs with fewer than 24 points or less than two
+// days of data are skipped. It returns the number of trends stored.
+func (s *OpticalTrendService) Analyze(now time.Time) (int, error) {
+	opticalTrendMu.Lock()
+	defer opticalTrendMu.Unlock()
+
+	// Stored timestamps are in local time; compare like with like
+	now = now.Local()
+	trends, err := s.fitAll(now)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.describe(trends); err != nil {
+		return 0, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("1 = 1").Delete(&database.ONUOpticalTrend{}).Error; err != nil {
+			return fmt.Errorf("failed to clear optical trends: %w", err)
+		}
+		if len(trends) > 0 {
+			if err := tx.CreateInBatches(trends, 500).Error; err != nil {
+				return fmt.Errorf("failed to store optical trends: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return len(trends), nil
+}
+
+// fitAll reads the hourly Rx averages inside the trend window, one ONU after
+// the other, and fits each ONU
+func (s *OpticalTrendService) fitAll(now time.Time) ([]database.ONUOpticalTrend, error) {
+	from := now.Add(-s.window())
+	rows, err := s.db.Model(&database.ONUMetricRollup{}).
+		Select("device_id, onu_id, bucket_start, rx_avg").
+		Where("tier = ? AND bucket_start >= ? AND rx_avg IS NOT NULL", HistoryStepHour, from).
+		Order("device_id ASC, onu_id ASC, bucket_start ASC").
+		Rows()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Rx history: %w", err)
+	}
+	defer rows.Close()
+
+	var trends []database.ONUOpticalTrend
+	var fit *trendFit
+	flush := func() {
+		if fit != nil {
+			if trend, ok := s.fitTrend(fit, now); ok {
+				trends = append(trends, trend)
+			}
+		}
+	}
+	for rows.Next() {
+		var row trendRow
+		if err := s.db.ScanRows(rows, &row); err != nil {
+			return nil, fmt.Errorf("failed to read Rx history row: %w", err)
+		}
+		if fit == nil || fit.deviceID != row.DeviceID || fit.onuID != row.ONUID {
+			flush()
+			fit = &trendFit{deviceID: row.DeviceID, onuID: row.ONUID}
+		}
+		fit.add(row, row.BucketStart.Sub(from).Hours()/24)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read Rx history: %w", err)
+	}
+	flush()
+	return trends, nil
+}
+
+// fitTrend computes the least-squares line of one ONU and its projection to
+// the sensitivity limit
+func (s *OpticalTrendService) fitTrend(fit *trendFit, now time.Time) (database.ONUOpticalTrend, bool) {
+	n := float64(fit.n)
+	den := n*fit.sxx - fit.sx*fit.sx
+	if fit.n < minTrendPoints || fit.last.BucketStart.Sub(fit.first.BucketStart) < minTrendSpan || den == 0 {
+		return database.ONUOpticalTrend{}, false
+	}
+
+	slope := (n*fit.sxy - fit.sx*fit.sy) / den
+	intercept := (fit.sy - slope*fit.sx) / n
+	fitted := intercept + slope*fit.lastX
+	r2 := 0.0
+	if spread := n*fit.syy - fit.sy*fit.sy; spread > 0 {
+		r := (n*fit.sxy - fit.sx*fit.sy) / math.Sqrt(den*spread)
+		r2 = r * r
+	}
+
+	trend := database.ONUOpticalTrend{
+		DeviceID:   fit.deviceID,
+		ONUID:      fit.onuID,
+		PONID:      ponOfONU(fit.onuID),
+		Points:     fit.n,
+		From:       fit.first.BucketStart,
+		To:         fit.last.BucketStart,
+		Slope:      roundTrend(slope, 4),
+		R2:         roundTrend(r2, 3),
+		FirstRx:    roundTrend(fit.first.RxAvg, 2),
+		LastRx:     roundTrend(fit.last.RxAvg, 2),
+		FittedRx:   roundTrend(fitted, 2),
+		AnalyzedAt: now,
+	}
+	if slope < 0 {
+		days := 0.0
+		if limit := s.sensitivityLimit(); fitted > limit {
+			days = (limit - fitted) / slope
+		}
+		days = roundTrend(days, 1)
+		trend.DaysToLimit = &days
+	}
+	return trend, true
+}
+
+// describe fills in ONU names and MACs from the inventory
+func (s *OpticalTrendService) describe(trends []database.ONUOpticalTrend) error {
+	if len(trends) == 0 {
+		return nil
+	}
+	var items []database.ONUInventory
+	if err := s.db.Select("device_id", "onu_id", "name", "mac_address").
+		Where("present = ?", true).Find(&items).Error; err != nil {
+		return fmt.Errorf("failed to load ONU inventory: %w", err)
+	}
+	byKey := make(map[string]*database.ONUInventory, len(items))
+	for i := range items {
+		byKey[items[i].DeviceID+"|"+items[i].ONUID] = &items[i]
+	}
+	for i := range trends {
+		if item, ok := byKey[trends[i].DeviceID+"|"+trends[i].ONUID]; ok {
+			trends[i].Name = item.Name
+			trends[i].MAC = item.MacAddress
+		}
+	}
+	return nil
+}
+
+// List returns stored trends ranked by urgency: soonest projected crossing
+// first, then steepest decline. Unless filter.All is set, only flagged ONUs
+// are returned.
+func (s *OpticalTrendService) List(filter OpticalTrendFilter) (*OpticalTrendReport, error) {
+	report := &OpticalTrendReport{
+		SensitivityLimit: s.sensitivityLimit(),
+		DegradationRate:  filter.Rate,
+		ProjectionDays:   filter.Days,
+		ONUs:             []OpticalTrend{},
+	}
+	if report.DegradationRate <= 0 {
+		report.DegradationRate = s.cfg.Optics.DegradationRate
+	}
+	if report.ProjectionDays <= 0 {
+		report.ProjectionDays = float64(s.cfg.Optics.ProjectionDays)
+	}
+
+	query := s.db.Model(&database.ONUOpticalTrend{})
+	if filter.DeviceID != "" {
+		query = query.Where("device_id = ?", filter.DeviceID)
+	}
+	if filter.PONID != "" {
+		query = query.Where("pon_id = ?", filter.PONID)
+	}
+	var stored []database.ONUOpticalTrend
+	if err := query.Find(&stored).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch optical trends: %w", err)
+	}
+
+	for _, trend := range stored {
+		if report.AnalyzedAt == nil || trend.AnalyzedAt.After(*report.AnalyzedAt) {
+			analyzedAt := trend.AnalyzedAt
+			report.AnalyzedAt = &analyzedAt
+		}
+		item := OpticalTrend{ONUOpticalTrend: trend, Flags: []string{}}
+		if report.DegradationRate > 0 && trend.Slope <= -report.DegradationRate {
+			item.Flags = append(item.Flags, TrendFlagDegrading)
+		}
+		if trend.DaysToLimit != nil && *trend.DaysToLimit <= report.ProjectionDays {
+			item.Flags = append(item.Flags, TrendFlagCrossing)
+		}
+		if len(item.Flags) == 0 && !filter.All {
+			continue
+		}
+		report.ONUs = append(report.ONUs, item)
+	}
+
+	sort.SliceStable(report.ONUs, func(i, j int) bool {
+		a, b := report.ONUs[i], report.ONUs[j]
+		if (a.DaysToLimit == nil) != (b.DaysToLimit == nil) {
+			return a.DaysToLimit != nil
+		}
+		if a.DaysToLimit != nil && *a.DaysToLimit != *b.DaysToLimit {
+			return *a.DaysToLimit < *b.DaysToLimit
+		}
+		if a.Slope != b.Slope {
+			return a.Slope < b.Slope
+		}
+		if a.DeviceID != b.DeviceID {
+			return a.DeviceID < b.DeviceID
+		}
+		return compareONUIDs(a.ONUID, b.ONUID) < 0
+	})
+	report.Total = len(report.ONUs)
+	if filter.Limit > 0 && len(report.ONUs) > filter.Limit {
+		report.ONUs = report.ONUs[:filter.Limit]
+	}
+
+	s.attachSubscribers(report.ONUs)
+	return report, nil
+}
+
+// attachSubscribers links subscribers to the listed ONUs by MAC
+func (s *OpticalTrendService) attachSubscribers(trends []OpticalTrend) {
+	subscribers := map[string]map[string]*database.Subscriber{}
+	subscriberSvc := NewSubscriberService(s.db, s.cfg, NewDeviceService(s.db, s.cfg))
+	for i := range trends {
+		if strings.TrimSpace(trends[i].MAC) == "" {
+			continue
+		}
+		byMAC, ok := subscribers[trends[i].DeviceID]
+		if !ok {
+			byMAC, _ = subscriberSvc.ForDevice(trends[i].DeviceID)
+			subscribers[trends[i].DeviceID] = byMAC
+		}
+		trends[i].Subscriber = byMAC[NormalizeMAC(trends[i].MAC)]
+	}
+}
+
+func roundTrend(value float64, places int) float64 {
+	scale := math.Pow(10, float64(places))
+	return math.Round(value*scale) / scale
+}
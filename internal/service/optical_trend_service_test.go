@@ -0,0 +1,206 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+)
+
+// hourlyTrendFit builds the fit of one hourly average per value, starting at
+// start, with y(x) for x in days since start
+func hourlyTrendFit(start time.Time, points int, y func(x float64, i int) float64) *trendFit {
+	fit := &trendFit{deviceID: "olt1", onuID: "0/1:1"}
+	for i := 0; i < points; i++ {
+		at := start.Add(time.Duration(i) * time.Hour)
+		x := at.Sub(start).Hours() / 24
+		fit.add(trendRow{DeviceID: "olt1", ONUID: "0/1:1", BucketStart: at, RxAvg: y(x, i)}, x)
+	}
+	return fit
+}
+
+func TestOpticalFitTrend(t *testing.T) {
+	start := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	now := start.Add(72 * time.Hour)
+	days := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name      string
+		points    int
+		y         func(x float64, i int) float64
+		wantOK    bool
+		wantSlope float64
+		wantR2    float64
+		wantFit   float64
+		wantDays  *float64
+	}{
+		{
+			name:      "steady decline",
+			points:    49,
+			y:         func(x float64, _ int) float64 { return -20 - 0.5*x },
+			wantOK:    true,
+			wantSlope: -0.5,
+			wantR2:    1,
+			wantFit:   -21,
+			wantDays:  days(14),
+		},
+		{
+			name:      "flat",
+			points:    49,
+			y:         func(float64, int) float64 { return -20 },
+			wantOK:    true,
+			wantSlope: 0,
+			wantR2:    0,
+			wantFit:   -20,
+		},
+		{
+			name:      "improving",
+			points:    49,
+			y:         func(x float64, _ int) float64 { return -25 + 0.25*x },
+			wantOK:    true,
+			wantSlope: 0.25,
+			wantR2:    1,
+			wantFit:   -24.5,
+		},
+		{
+			name:      "already past the limit",
+			points:    49,
+			y:         func(x float64, _ int) float64 { return -29 - 0.1*x },
+			wantOK:    true,
+			wantSlope: -0.1,
+			wantR2:    1,
+			wantFit:   -29.2,
+			wantDays:  days(0),
+		},
+		{
+			name:   "too few points",
+			points: minTrendPoints - 1,
+			y:      func(x float64, _ int) float64 { return -20 - x },
+		},
+		{
+			name:   "span under two days",
+			points: 48,
+			y:      func(x float64, _ int) float64 { return -20 - x },
+		},
+	}
+	svc := NewOpticalTrendService(nil, &config.Config{})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			trend, ok := svc.fitTrend(hourlyTrendFit(start, tt.points, tt.y), now)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %t, want %t", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if trend.Slope != tt.wantSlope || trend.R2 != tt.wantR2 || trend.FittedRx != tt.wantFit {
+				t.Errorf("slope %v r2 %v fitted %v, want %v %v %v",
+					trend.Slope, trend.R2, trend.FittedRx, tt.wantSlope, tt.wantR2, tt.wantFit)
+			}
+			switch {
+			case tt.wantDays == nil && trend.DaysToLimit != nil:
+				t.Errorf("days_to_limit = %v, want none", *trend.DaysToLimit)
+			case tt.wantDays != nil && trend.DaysToLimit == nil:
+				t.Errorf("days_to_limit missing, want %v", *tt.wantDays)
+			case tt.wantDays != nil && *trend.DaysToLimit != *tt.wantDays:
+				t.Errorf("days_to_limit = %v, want %v", *trend.DaysToLimit, *tt.wantDays)
+			}
+			if trend.Points != tt.points || trend.PONID != "0/1" || !trend.AnalyzedAt.Equal(now) {
+				t.Errorf("points %d pon %q analyzed %v", trend.Points, trend.PONID, trend.AnalyzedAt)
+			}
+		})
+	}
+}
+
+func TestOpticalFitTrendNoisy(t *testing.T) {
+	start := time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC)
+	noise := []float64{0.4, -0.3, 0.1, -0.2}
+	fit := hourlyTrendFit(start, 97, func(x float64, i int) float64 {
+		return -22 - 0.8*x + noise[i%len(noise)]
+	})
+
+	svc := NewOpticalTrendService(nil, &config.Config{Optics: config.OpticsConfig{SensitivityLimit: -27}})
+	trend, ok := svc.fitTrend(fit, start.Add(96*time.Hour))
+	if !ok {
+		t.Fatal("expected a trend")
+	}
+	if math.Abs(trend.Slope+0.8) > 0.05 {
+		t.Errorf("slope = %v, want about -0.8", trend.Slope)
+	}
+	if trend.R2 <= 0.9 || trend.R2 >= 1 {
+		t.Errorf("r2 = %v, want between 0.9 and 1", trend.R2)
+	}
+	if trend.FirstRx != -21.6 || trend.LastRx != -24.8 {
+		t.Errorf("first/last rx = %v/%v, want -21.6/-24.8", trend.FirstRx, trend.LastRx)
+	}
+	wantDays := (-27 - trend.FittedRx) / trend.Slope
+	if trend.DaysToLimit == nil || math.Abs(*trend.DaysToLimit-wantDays) > 0.2 {
+		t.Errorf("days_to_limit = %v, want about %.1f", trend.DaysToLimit, wantDays)
+	}
+}
+
+func TestOpticalTrendAnalyze(t *testing.T) {
+	db := testDB(t)
+	createTestDevice(t, db, "olt1")
+	now := time.Date(2026, 5, 15, 12, 0, 0, 0, time.Local)
+
+	rollup := func(onuID string, at time.Time, rx float64) database.ONUMetricRollup {
+		return database.ONUMetricRollup{DeviceID: "olt1", ONUID: onuID, Tier: HistoryStepHour, BucketStart: at, Samples: 12, RxAvg: &rx}
+	}
+	var rows []database.ONUMetricRollup
+	for i := 0; i < 72; i++ {
+		at := now.Add(-time.Duration(72-i) * time.Hour)
+		rows = append(rows,
+			rollup("0/1:1", at, -20-float64(i)/24),
+			rollup("0/1:2", at, -19),
+		)
+	}
+	// Too few points for a fit
+	for i := 0; i < 10; i++ {
+		rows = append(rows, rollup("0/2:1", now.Add(-time.Duration(60-6*i)*time.Hour), -30))
+	}
+	// Daily rollups and hours outside the window are ignored
+	daily := rollup("0/1:2", now.Add(-48*time.Hour), -40)
+	daily.Tier = HistoryStepDay
+	rows = append(rows, daily, rollup("0/1:2", now.Add(-30*24*time.Hour), -40))
+	if err := db.Create(&rows).Error; err != nil {
+		t.Fatalf("create rollups: %v", err)
+	}
+	item := database.ONUInventory{DeviceID: "olt1", MAC: "aabbcc000001", MacAddress: "AA:BB:CC:00:00:01", ONUID: "0/1:1", PONID: "0/1", Name: "alice", Present: true}
+	if err := db.Create(&item).Error; err != nil {
+		t.Fatalf("create inventory: %v", err)
+	}
+
+	svc := NewOpticalTrendService(db, &config.Config{})
+	count, err := svc.Analyze(now)
+	if err != nil {
+		t.Fatalf("analyze: %v", err)
+	}
+	if count != 2 {
+		t.Fatalf("stored %d trends, want 2", count)
+	}
+
+	var trends []database.ONUOpticalTrend
+	if err := db.Order("onu_id ASC").Find(&trends).Error; err != nil {
+		t.Fatalf("load trends: %v", err)
+	}
+	if trends[0].ONUID != "0/1:1" || trends[0].Slope != -1 || trends[0].Name != "alice" || trends[0].MAC != "AA:BB:CC:00:00:01" {
+		t.Errorf("0/1:1 trend = %+v", trends[0])
+	}
+	if trends[0].DaysToLimit == nil || *trends[0].DaysToLimit != 5.0 {
+		t.Errorf("0/1:1 days_to_limit = %v, want 5", trends[0].DaysToLimit)
+	}
+	if trends[1].ONUID != "0/1:2" || trends[1].Slope != 0 || trends[1].FittedRx != -19 || trends[1].Points != 72 {
+		t.Errorf("0/1:2 trend = %+v", trends[1])
+	}
+
+	// A second run replaces the stored trends
+	if _, err := svc.Analyze(now.Add(time.Hour)); err != nil {
+		t.Fatalf("second analyze: %v", err)
+	}
+	if got := countRows(t, db, &database.ONUOpticalTrend{}, "1 = 1"); got != 2 {
+		t.Errorf("got %d trends after the second run, want 2", got)
+	}
+}
@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"math"
+	"regexp"
+	"strconv"
 	"strings"
 
 	"olt-api/internal/config"
@@ -97,6 +100,288 @@ func (s *PONService) GetPONListWithClient(client *scraper.Client, deviceID strin
 	return pons, nil
 }
 
+// GetPONListWithStats returns the PON list with per-PON ONU aggregates.
+// ONU lists come from the cache where possible; a PON whose ONUs cannot be
+// read carries the error in its stats.
+func (s *PONService) GetPONListWithStats(deviceID string, rxThreshold float64) ([]parser.PONResponse, error) {
+	pons, err := s.GetPONList(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	onuService := NewONUService(s.db, s.cfg, s.deviceService)
+	pool := scraper.NewWorkerPool(s.cfg.Scraper.MaxWorkers)
+	defer pool.Close()
+
+	for i := range pons {
+		pon := &pons[i]
+		pool.Submit(func() {
+			onus, err := onuService.GetONUsByPON(deviceID, pon.FullID, "")
+			if err != nil {
+				pon.Stats = &parser.PONStats{RxThreshold: rxThreshold, Error: err.Error()}
+				return
+			}
+			pon.Stats = ComputePONStats(onus, rxThreshold)
+		})
+	}
+	pool.Wait()
+
+	return pons, nil
+}
+
+// ComputePONStats aggregates status counts, Rx power and distance of ONUs
+func ComputePONStats(onus []parser.ONUResponse, rxThreshold float64) *parser.PONStats {
+	stats := &parser.PONStats{Total: len(onus), RxThreshold: rxThreshold}
+
+	var rxSum float64
+	var rxCount int
+	for _, onu := range onus {
+		switch onu.Status {
+		case "online":
+			stats.Online++
+		case "offline", "down":
+			stats.Offline++
+		case "los":
+			stats.LOS++
+		case "poweroff", "powerdown":
+			stats.PowerOff++
+		}
+		if onu.Distance > stats.MaxDistance {
+			stats.MaxDistance = onu.Distance
+		}
+
+		rx := onu.Metrics.RxPower
+		if onu.Status != "online" || !metricReported(onu, "rx_power") {
+			continue
+		}
+		if stats.RxMin == nil || rx < *stats.RxMin {
+			value := rx
+			stats.RxMin = &value
+		}
+		if stats.RxMax == nil || rx > *stats.RxMax {
+			value := rx
+			stats.RxMax = &value
+		}
+		if rx < rxThreshold {
+			stats.LowRx++
+		}
+		rxSum += rx
+		rxCount++
+	}
+
+	if rxCount > 0 {
+		avg := math.Round(rxSum/float64(rxCount)*100) / 100
+		stats.RxAvg = &avg
+	}
+	return stats
+}
+
+// PONSeverity ranks PONs for "worst first" ordering: down ONUs weigh more
+// than ONUs with low Rx power. PONs that could not be read rank highest;
+// PONs without ONUs score 0.
+func PONSeverity(stats *parser.PONStats) int {
+	if stats == nil {
+		return 0
+	}
+	if stats.Error != "" {
+		return math.MaxInt32
+	}
+	if stats.Total == 0 {
+		return 0
+	}
+	return (stats.Offline+stats.LOS+stats.PowerOff)*2 + stats.LowRx
+}
+
+// PONActionRequest is the body of a PON port action
+type PONActionRequest struct {
+	Action string `json:"action" binding:"required"` // enable, disable
+}
+
+var (
+	formLoginPattern = regexp.MustCompile(`(?i)<input[^>]+type\s*=\s*["']?password`)
+	formAlertPattern = regexp.MustCompile(`(?i)alert\s*\(\s*["']([^"']*(?:fail|error|invalid|denied)[^"']*)["']`)
+)
+
+// ponAdminStatusFor maps an API action name to the configured admin status value
+func (s *PONService) ponAdminStatusFor(action string) (string, error) {
+	switch strings.ToLower(action) {
+	case "enable":
+		return s.cfg.PON.AdminForm.EnableValue, nil
+	case "disable":
+		return s.cfg.PON.AdminForm.DisableValue, nil
+	default:
+		return "", invalidf("unsupported action: %s (supported: enable, disable)", action)
+	}
+}
+
+// PerformAction enables or disables a PON port through the admin form set
+// in pon.admin_form. The PON must exist on the device and the OLT's reply
+// must not be a login page or an error alert.
+func (s *PONService) PerformAction(deviceID, ponID, action string) error {
+	adminStatus, err := s.ponAdminStatusFor(action)
+	if err != nil {
+		return err
+	}
+	form := s.cfg.PON.AdminForm
+	if form.Path == "" || form.StatusField == "" {
+		return kindErrorf(ErrNotConfigured, "PON actions are not configured: set pon.admin_form in config.yaml")
+	}
+
+	if _, err := s.deviceService.GetByID(deviceID); err != nil {
+		return err
+	}
+	pons, err := s.GetPONList(deviceID)
+	if err != nil {
+		return err
+	}
+	found := false
+	for _, pon := range pons {
+		if pon.FullID == ponID {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("PON %s %w on device %s", ponID, ErrNotFound, deviceID)
+	}
+
+	client, err := s.deviceService.GetClient(deviceID)
+	if err != nil {
+		return err
+	}
+
+	ponField := form.PONField
+	if ponField == "" {
+		ponField = "oltponno"
+	}
+	respBody, err := client.Post(form.Path, map[string]string{
+		ponField:         ponID,
+		form.StatusField: adminStatus,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to %s PON %s: %w", strings.ToLower(action), ponID, err)
+	}
+	if err := checkFormReply(respBody); err != nil {
+		return fmt.Errorf("failed to %s PON %s: %w", strings.ToLower(action), ponID, err)
+	}
+
+	// Invalidate cache
+	s.db.Where("key = ?", fmt.Sprintf("pons:%s", deviceID)).Delete(&database.CacheEntry{})
+	s.db.Where("key = ?", fmt.Sprintf("onus:%s:%s", deviceID, ponID)).Delete(&database.CacheEntry{})
+
+	log.Printf("[PON] Performed %s on device %s PON %s", action, deviceID, ponID)
+	return nil
+}
+
+// checkFormReply rejects form replies that are a login page or carry an
+// error alert with ErrOLTRejected
+func checkFormReply(body string) error {
+	if formLoginPattern.MatchString(body) {
+		return kindErrorf(ErrOLTRejected, "OLT returned a login page")
+	}
+	if match := formAlertPattern.FindStringSubmatch(body); match != nil {
+		return kindErrorf(ErrOLTRejected, "OLT rejected the command: %s", strings.TrimSpace(match[1]))
+	}
+	return nil
+}
+
+// PONCapacity reports ONU slot usage of one PON
+type PONCapacity struct {
+	PONID          string  `json:"pon_id"`
+	FullID         string  `json:"full_id"`
+	MaxONUs        int     `json:"max_onus"`
+	Used           int     `json:"used"`
+	Free           int     `json:"free"`
+	UtilizationPct float64 `json:"utilization_pct"`
+	NearFull       bool    `json:"near_full"`
+	FreeSlots      []int   `json:"free_slots,omitempty"`
+	Error          string  `json:"error,omitempty"`
+}
+
+// PONCapacityReport lists slot usage of every PON of a device
+type PONCapacityReport struct {
+	DeviceID      string        `json:"device_id"`
+	MaxONUs       int           `json:"max_onus"`
+	ThresholdPct  float64       `json:"threshold_pct"`
+	NearFullCount int           `json:"near_full_count"`
+	PONs          []PONCapacity `json:"pons"`
+}
+
+// GetCapacity reports used and free ONU slots per PON. Registered ONUs hold
+// their slot whatever their status. PONs at or above thresholdPct are flagged.
+func (s *PONService) GetCapacity(deviceID string, maxONUs int, thresholdPct float64) (*PONCapacityReport, error) {
+	if maxONUs <= 0 {
+		return nil, fmt.Errorf("max_onus must be positive")
+	}
+
+	pons, err := s.GetPONList(deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	onuService := NewONUService(s.db, s.cfg, s.deviceService)
+	pool := scraper.NewWorkerPool(s.cfg.Scraper.MaxWorkers)
+	defer pool.Close()
+
+	capacities := make([]PONCapacity, len(pons))
+	for i, pon := range pons {
+		i, pon := i, pon
+		pool.Submit(func() {
+			capacity := PONCapacity{PONID: pon.PONID, FullID: pon.FullID, MaxONUs: maxONUs}
+			onus, err := onuService.GetONUsByPON(deviceID, pon.FullID, "")
+			if err != nil {
+				capacity.Error = err.Error()
+				capacities[i] = capacity
+				return
+			}
+
+			used := make(map[int]bool, len(onus))
+			for _, onu := range onus {
+				if slot, ok := onuSlot(onu.ONUID); ok {
+					used[slot] = true
+				}
+			}
+			capacity.Used = len(used)
+			for slot := 1; slot <= maxONUs; slot++ {
+				if !used[slot] {
+					capacity.FreeSlots = append(capacity.FreeSlots, slot)
+				}
+			}
+			capacity.Free = len(capacity.FreeSlots)
+			capacity.UtilizationPct = math.Round(float64(capacity.Used)/float64(maxONUs)*1000) / 10
+			capacity.NearFull = capacity.UtilizationPct >= thresholdPct
+			capacities[i] = capacity
+		})
+	}
+	pool.Wait()
+
+	report := &PONCapacityReport{
+		DeviceID:     deviceID,
+		MaxONUs:      maxONUs,
+		ThresholdPct: thresholdPct,
+		PONs:         capacities,
+	}
+	for _, capacity := range capacities {
+		if capacity.NearFull {
+			report.NearFullCount++
+		}
+	}
+	return report, nil
+}
+
+// onuSlot returns the ONU number of an ID such as "0/1:8"
+func onuSlot(onuID string) (int, bool) {
+	idx := strings.LastIndex(onuID, ":")
+	if idx < 0 {
+		return 0, false
+	}
+	slot, err := strconv.Atoi(strings.TrimSpace(onuID[idx+1:]))
+	if err != nil || slot <= 0 {
+		return 0, false
+	}
+	return slot, true
+}
+
 func (s *PONService) fetchPONListWithFallback(client *scraper.Client) ([]parser.PONResponse, error) {
 	endpoints := []string{
 		"/onuOverviewPonList.asp",
@@ -0,0 +1,59 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"olt-api/internal/config"
+)
+
+func TestPerformActionErrorKinds(t *testing.T) {
+	db := testDB(t)
+	configured := &config.Config{PON: config.PONConfig{AdminForm: config.PONAdminConfig{
+		Path: "/goform/setPon", StatusField: "admin", EnableValue: "1", DisableValue: "0",
+	}}}
+
+	tests := []struct {
+		name   string
+		cfg    *config.Config
+		device string
+		action string
+		want   error
+	}{
+		{"unsupported action", configured, "olt1", "reboot", ErrInvalidInput},
+		{"form not configured", &config.Config{}, "olt1", "enable", ErrNotConfigured},
+		{"unknown device", configured, "missing", "enable", ErrNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewPONService(db, tt.cfg, NewDeviceService(db, tt.cfg))
+			err := svc.PerformAction(tt.device, "0/1", tt.action)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("PerformAction error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckFormReply(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want error
+	}{
+		{"accepted", "<html><script>location.href='/pon.asp'</script></html>", nil},
+		{"login page", `<form><input type="password" name="pwd"></form>`, ErrOLTRejected},
+		{"error alert", `<script>alert("Set failed: invalid port");</script>`, ErrOLTRejected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkFormReply(tt.body)
+			if tt.want == nil && err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Fatalf("error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,404 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"net"
+	"sync"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+	"olt-api/internal/parser"
+	"olt-api/internal/scraper"
+
+	"gorm.io/gorm"
+)
+
+// Polled device statuses. Degraded devices accept TCP connections but fail
+// authentication or do not serve system info.
+const (
+	DeviceUp       = "up"
+	DeviceDegraded = "degraded"
+	DeviceDown     = "down"
+)
+
+// Reachability check stages
+const (
+	CheckStageTCP    = "tcp"
+	CheckStageAuth   = "auth"
+	CheckStageSystem = "system"
+)
+
+// uptimeWindows are the windows device uptime is reported for
+var uptimeWindows = []struct {
+	Label    string
+	Duration time.Duration
+}{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+}
+
+// ReachabilityCheck is the outcome of one poll of a device
+type ReachabilityCheck struct {
+	Status    string
+	Stage     string // failed stage
+	Error     string
+	LatencyMs int64 // TCP connect time
+	CheckedAt time.Time
+	System    *parser.SystemInfoResponse // set when the system stage passed
+}
+
+// DeviceStatusHistory lists the status changes of a device over a range
+// with the time spent in each status. Time before the first known status
+// is not counted.
+type DeviceStatusHistory struct {
+	DeviceID  string                       `json:"device_id"`
+	From      time.Time                    `json:"from"`
+	To        time.Time                    `json:"to"`
+	UptimePct *float64                     `json:"uptime_pct"`
+	Seconds   map[string]int64             `json:"seconds"`
+	Count     int                          `json:"count"`
+	Events    []database.DeviceStatusEvent `json:"events"`
+}
+
+// ReachabilityService checks devices and keeps their status history
+type ReachabilityService struct {
+	db            *gorm.DB
+	cfg           *config.Config
+	deviceService *DeviceService
+}
+
+// NewReachabilityService creates a new ReachabilityService
+func NewReachabilityService(db *gorm.DB, cfg *config.Config, deviceService *DeviceService) *ReachabilityService {
+	return &ReachabilityService{
+		db:            db,
+		cfg:           cfg,
+		deviceService: deviceService,
+	}
+}
+
+func (s *ReachabilityService) timeout() time.Duration {
+	if s.cfg.Reachability.Timeout <= 0 {
+		return 10 * time.Second
+	}
+	return s.cfg.Reachability.Timeout
+}
+
+// Check runs the TCP, HTTP auth and system info checks in turn, stopping at
+// the first failure
+func (s *ReachabilityService) Check(device *database.Device) ReachabilityCheck {
+	check := ReachabilityCheck{CheckedAt: time.Now()}
+	fail := func(status, stage string, err error) ReachabilityCheck {
+		check.Status, check.Stage, check.Error = status, stage, err.Error()
+		return check
+	}
+
+	address, err := dialAddress(device)
+	if err != nil {
+		return fail(DeviceDown, CheckStageTCP, err)
+	}
+	started := time.Now()
+	conn, err := net.DialTimeout("tcp", address, s.timeout())
+	if err != nil {
+		return fail(DeviceDown, CheckStageTCP, err)
+	}
+	check.LatencyMs = time.Since(started).Milliseconds()
+	_ = conn.Close()
+
+	client := s.deviceService.clientFor(device, s.timeout())
+	if err := client.CheckConnection(); err != nil {
+		return fail(DeviceDegraded, CheckStageAuth, err)
+	}
+	info, err := systemInfo(client)
+	if err != nil {
+		return fail(DeviceDegraded, CheckStageSystem, err)
+	}
+	check.System = info
+
+	check.Status = DeviceUp
+	return check
+}
+
+// Record stores the outcome of a check on the device. When changed is set
+// the live status moves to the checked status and the change is logged.
+func (s *ReachabilityService) Record(device *database.Device, check ReachabilityCheck, changed bool) error {
+	checkedAt := check.CheckedAt
+	updates := map[string]interface{}{
+		"last_checked_at": checkedAt,
+		"latency_ms":      check.LatencyMs,
+	}
+	if check.Error != "" {
+		updates["last_error"] = fmt.Sprintf("%s: %s", check.Stage, check.Error)
+		updates["last_error_at"] = checkedAt
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if changed {
+			updates["live_status"] = check.Status
+			updates["live_status_since"] = checkedAt
+			event := database.DeviceStatusEvent{
+				DeviceID:  device.ID,
+				Status:    check.Status,
+				Previous:  device.LiveStatus,
+				Stage:     check.Stage,
+				Error:     check.Error,
+				ChangedAt: checkedAt,
+			}
+			if err := tx.Create(&event).Error; err != nil {
+				return fmt.Errorf("failed to record status change: %w", err)
+			}
+		}
+		if err := tx.Model(&database.Device{}).Where("id = ?", device.ID).UpdateColumns(updates).Error; err != nil {
+			return fmt.Errorf("failed to update device status: %w", err)
+		}
+		return nil
+	})
+}
+
+// History returns the status changes of a device between from and to
+func (s *ReachabilityService) History(deviceID string, from, to time.Time) (*DeviceStatusHistory, error) {
+	if !to.After(from) {
+		return nil, ErrInvalidRange
+	}
+	if _, err := s.deviceService.GetByID(deviceID); err != nil {
+		return nil, err
+	}
+	// Stored timestamps are in local time; compare like with like
+	from, to = from.Local(), to.Local()
+
+	seconds, err := s.statusSeconds(deviceID, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []database.DeviceStatusEvent
+	if err := s.db.Where("device_id = ? AND changed_at >= ? AND changed_at < ?", deviceID, from, to).
+		Order("changed_at DESC").
+		Find(&events).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch status history: %w", err)
+	}
+
+	return &DeviceStatusHistory{
+		DeviceID:  deviceID,
+		From:      from,
+		To:        to,
+		UptimePct: uptimePct(seconds),
+		Seconds:   seconds,
+		Count:     len(events),
+		Events:    events,
+	}, nil
+}
+
+// Uptime returns the uptime percentage of a device over the last 24 hours,
+// 7 days and 30 days; nil when no status was known in a window
+func (s *ReachabilityService) Uptime(deviceID string, now time.Time) (map[string]*float64, error) {
+	uptime := make(map[string]*float64, len(uptimeWindows))
+	for _, window := range uptimeWindows {
+		seconds, err := s.statusSeconds(deviceID, now.Add(-window.Duration).Local(), now.Local())
+		if err != nil {
+			return nil, err
+		}
+		uptime[window.Label] = uptimePct(seconds)
+	}
+	return uptime, nil
+}
+
+// statusSeconds totals the time a device spent in each status between from
+// and to, starting from the last change before from
+func (s *ReachabilityService) statusSeconds(deviceID string, from, to time.Time) (map[string]int64, error) {
+	seconds := map[string]int64{DeviceUp: 0, DeviceDegraded: 0, DeviceDown: 0}
+
+	var events []database.DeviceStatusEvent
+	var before database.DeviceStatusEvent
+	err := s.db.Where("device_id = ? AND changed_at < ?", deviceID, from).Order("changed_at DESC").First(&before).Error
+	if err == nil {
+		before.ChangedAt = from
+		events = append(events, before)
+	} else if err != gorm.ErrRecordNotFound {
+		return nil, fmt.Errorf("failed to fetch status history: %w", err)
+	}
+
+	var inRange []database.DeviceStatusEvent
+	if err := s.db.Where("device_id = ? AND changed_at >= ? AND changed_at < ?", deviceID, from, to).
+		Order("changed_at ASC").
+		Find(&inRange).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch status history: %w", err)
+	}
+	events = append(events, inRange...)
+
+	for i, event := range events {
+		end := to
+		if i+1 < len(events) {
+			end = events[i+1].ChangedAt
+		}
+		seconds[event.Status] += int64(end.Sub(event.ChangedAt).Seconds())
+	}
+	return seconds, nil
+}
+
+// uptimePct returns the share of up time over known time as a percentage
+// with three decimals, or nil when no time is known
+func uptimePct(seconds map[string]int64) *float64 {
+	var known int64
+	for _, value := range seconds {
+		known += value
+	}
+	if known <= 0 {
+		return nil
+	}
+	pct := math.Round(float64(seconds[DeviceUp])/float64(known)*100000) / 1000
+	return &pct
+}
+
+// Prune deletes status changes past the retention, keeping the last one
+// before the cutoff of each device so its status at the cutoff stays known
+func (s *ReachabilityService) Prune(now time.Time) error {
+	if s.cfg.Reachability.Retention <= 0 {
+		return nil
+	}
+	cutoff := now.Add(-s.cfg.Reachability.Retention)
+	keep := s.db.Model(&database.DeviceStatusEvent{}).
+		Select("MAX(id)").
+		Where("changed_at < ?", cutoff).
+		Group("device_id")
+	result := s.db.Where("changed_at < ? AND id NOT IN (?)", cutoff, keep).Delete(&database.DeviceStatusEvent{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to prune device status history: %w", result.Error)
+	}
+	if result.RowsAffected > 0 {
+		log.Printf("[REACHABILITY] Pruned %d device status events", result.RowsAffected)
+	}
+	return nil
+}
+
+// ReachabilityPoller checks every device on a fixed interval and records
+// status changes once they are seen on consecutive checks. The system info
+// read by each check is stored as a system metrics sample.
+type ReachabilityPoller struct {
+	db  *gorm.DB
+	cfg *config.Config
+
+	mu      sync.Mutex
+	pending map[string]*pendingStatus
+}
+
+// pendingStatus counts consecutive checks that disagree with the live status
+type pendingStatus struct {
+	Status string
+	Checks int
+}
+
+// NewReachabilityPoller creates a new ReachabilityPoller
+func NewReachabilityPoller(db *gorm.DB, cfg *config.Config) *ReachabilityPoller {
+	return &ReachabilityPoller{
+		db:      db,
+		cfg:     cfg,
+		pending: map[string]*pendingStatus{},
+	}
+}
+
+// Start launches the polling loop
+func (p *ReachabilityPoller) Start() {
+	if !p.cfg.Reachability.Enabled {
+		log.Printf("[REACHABILITY] Disabled by configuration")
+		return
+	}
+	interval := p.cfg.Reachability.Interval
+	if interval <= 0 {
+		interval = time.Minute
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			p.runCycle()
+			<-ticker.C
+		}
+	}()
+	log.Printf("[REACHABILITY] Started (interval %s)", interval)
+}
+
+// runCycle checks every device concurrently
+func (p *ReachabilityPoller) runCycle() {
+	deviceService := NewDeviceService(p.db, p.cfg)
+	reachability := NewReachabilityService(p.db, p.cfg, deviceService)
+	systemMetrics := NewSystemMetricsService(p.db, p.cfg, deviceService)
+
+	devices, err := deviceService.GetAll()
+	if err != nil {
+		log.Printf("[REACHABILITY] Failed to load devices: %v", err)
+		return
+	}
+
+	pool := scraper.NewWorkerPool(p.cfg.Scraper.MaxWorkers)
+	defer pool.Close()
+
+	for i := range devices {
+		device := &devices[i]
+		pool.Submit(func() {
+			check := reachability.Check(device)
+			changed := p.confirm(device, check.Status)
+			if changed {
+				log.Printf("[REACHABILITY] Device %s is %s (was %s)", device.ID, check.Status, statusOrUnknown(device.LiveStatus))
+			}
+			if err := reachability.Record(device, check, changed); err != nil {
+				log.Printf("[REACHABILITY] Device %s: %v", device.ID, err)
+			}
+			if check.System != nil && p.cfg.SystemMetrics.Collect {
+				if _, err := systemMetrics.Record(device.ID, check.System, check.CheckedAt); err != nil {
+					log.Printf("[REACHABILITY] Device %s: %v", device.ID, err)
+				}
+			}
+		})
+	}
+	pool.Wait()
+
+	if err := reachability.Prune(time.Now()); err != nil {
+		log.Printf("[REACHABILITY] %v", err)
+	}
+}
+
+// confirm reports whether a checked status should replace the live status.
+// The first check of a device is taken as is; later changes need
+// confirm_checks consecutive checks.
+func (p *ReachabilityPoller) confirm(device *database.Device, status string) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if status == device.LiveStatus {
+		delete(p.pending, device.ID)
+		return false
+	}
+	if device.LiveStatus == "" {
+		delete(p.pending, device.ID)
+		return true
+	}
+
+	pending := p.pending[device.ID]
+	if pending == nil || pending.Status != status {
+		pending = &pendingStatus{Status: status}
+		p.pending[device.ID] = pending
+	}
+	pending.Checks++
+
+	required := p.cfg.Reachability.ConfirmChecks
+	if required <= 0 {
+		required = 1
+	}
+	if pending.Checks < required {
+		return false
+	}
+	delete(p.pending, device.ID)
+	return true
+}
+
+func statusOrUnknown(status string) string {
+	if status == "" {
+		return "unknown"
+	}
+	return status
+}
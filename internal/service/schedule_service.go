@@ -0,0 +1,210 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// Schedule kinds
+const (
+	ScheduleKindONUAction  = "onu.action"
+	ScheduleKindBulkAction = "onu.bulk_action"
+	ScheduleKindSaveConfig = "device.save_config"
+)
+
+// Schedule run status values
+const (
+	ScheduleRunQueued  = "queued"
+	ScheduleRunSkipped = "skipped"
+	ScheduleRunFailed  = "failed"
+	ScheduleRunPartial = "partial" // schedule status only: some devices skipped or failed
+)
+
+// ScheduleRequest is used for creating schedules.
+// Exactly one of Cron or RunAt must be set.
+type ScheduleRequest struct {
+	Name      string                `json:"name" binding:"required"`
+	Kind      string                `json:"kind" binding:"required"` // onu.action, onu.bulk_action, device.save_config
+	DeviceID  string                `json:"device_id"`               // empty = all devices (device.save_config only)
+	Cron      string                `json:"cron"`
+	RunAt     *time.Time            `json:"run_at"`
+	ONUAction *BulkONUActionRequest `json:"onu_action"`
+}
+
+// ScheduleService manages stored schedules and their run history
+type ScheduleService struct {
+	db            *gorm.DB
+	cfg           *config.Config
+	deviceService *DeviceService
+}
+
+// NewScheduleService creates a new ScheduleService
+func NewScheduleService(db *gorm.DB, cfg *config.Config, deviceService *DeviceService) *ScheduleService {
+	return &ScheduleService{
+		db:            db,
+		cfg:           cfg,
+		deviceService: deviceService,
+	}
+}
+
+// Create validates and stores a new schedule owned by the given actor
+func (s *ScheduleService) Create(req *ScheduleRequest, actor JobActor) (*database.Schedule, error) {
+	kind := strings.ToLower(strings.TrimSpace(req.Kind))
+	deviceID := strings.TrimSpace(req.DeviceID)
+
+	switch kind {
+	case ScheduleKindONUAction, ScheduleKindBulkAction:
+		if deviceID == "" {
+			return nil, fmt.Errorf("device_id is required for %s schedules", kind)
+		}
+		if req.ONUAction == nil {
+			return nil, fmt.Errorf("onu_action is required for %s schedules", kind)
+		}
+		if err := ValidateBulkAction(req.ONUAction); err != nil {
+			return nil, err
+		}
+		if kind == ScheduleKindONUAction {
+			if _, err := onuOperationFor(req.ONUAction.Action); err != nil {
+				return nil, err
+			}
+			if len(req.ONUAction.ONUIDs) == 0 || req.ONUAction.Filter != nil {
+				return nil, fmt.Errorf("onu.action schedules require onu_ids and no filter")
+			}
+		} else if len(req.ONUAction.ONUIDs) == 0 && len(req.ONUAction.Names) == 0 && req.ONUAction.Filter == nil {
+			return nil, fmt.Errorf("onu_action requires onu_ids, names or filter")
+		}
+	case ScheduleKindSaveConfig:
+		if req.ONUAction != nil {
+			return nil, fmt.Errorf("onu_action is not allowed for %s schedules", kind)
+		}
+	default:
+		return nil, fmt.Errorf("unsupported kind: %s (supported: %s, %s, %s)", req.Kind,
+			ScheduleKindONUAction, ScheduleKindBulkAction, ScheduleKindSaveConfig)
+	}
+
+	if deviceID != "" {
+		if _, err := s.deviceService.GetByID(deviceID); err != nil {
+			return nil, err
+		}
+	}
+
+	cronExpr := strings.TrimSpace(req.Cron)
+	now := time.Now()
+	var nextRun time.Time
+	switch {
+	case cronExpr != "" && req.RunAt != nil:
+		return nil, fmt.Errorf("set either cron or run_at, not both")
+	case cronExpr != "":
+		parsed, err := ParseCron(cronExpr)
+		if err != nil {
+			return nil, err
+		}
+		nextRun = parsed.Next(now)
+		if nextRun.IsZero() {
+			return nil, fmt.Errorf("cron expression %q never fires", cronExpr)
+		}
+	case req.RunAt != nil:
+		if !req.RunAt.After(now) {
+			return nil, fmt.Errorf("run_at must be in the future")
+		}
+		nextRun = *req.RunAt
+	default:
+		return nil, fmt.Errorf("either cron or run_at is required")
+	}
+
+	payload := ""
+	if req.ONUAction != nil {
+		raw, err := json.Marshal(req.ONUAction)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode schedule payload: %w", err)
+		}
+		payload = string(raw)
+	}
+
+	schedule := &database.Schedule{
+		Name:          strings.TrimSpace(req.Name),
+		Kind:          kind,
+		DeviceID:      deviceID,
+		PayloadJSON:   payload,
+		Cron:          cronExpr,
+		RunAt:         req.RunAt,
+		Enabled:       true,
+		NextRunAt:     &nextRun,
+		CreatedByID:   actor.UserID,
+		CreatedBy:     actor.Username,
+		CreatedByRole: actor.Role,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+	}
+	if err := s.db.Create(schedule).Error; err != nil {
+		return nil, fmt.Errorf("failed to create schedule: %w", err)
+	}
+
+	decorateSchedule(schedule)
+	return schedule, nil
+}
+
+// List returns all schedules, soonest first
+func (s *ScheduleService) List() ([]database.Schedule, error) {
+	var schedules []database.Schedule
+	if err := s.db.Order("enabled DESC, next_run_at ASC").Find(&schedules).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch schedules: %w", err)
+	}
+	for i := range schedules {
+		decorateSchedule(&schedules[i])
+	}
+	return schedules, nil
+}
+
+// Get returns a schedule by ID
+func (s *ScheduleService) Get(id uint) (*database.Schedule, error) {
+	var schedule database.Schedule
+	if err := s.db.First(&schedule, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("schedule '%d' not found", id)
+		}
+		return nil, fmt.Errorf("failed to fetch schedule: %w", err)
+	}
+	decorateSchedule(&schedule)
+	return &schedule, nil
+}
+
+// Delete removes a schedule and its run history
+func (s *ScheduleService) Delete(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&database.Schedule{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete schedule: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("schedule '%d' not found", id)
+		}
+		return tx.Where("schedule_id = ?", id).Delete(&database.ScheduleRun{}).Error
+	})
+}
+
+// Runs returns the most recent executions of a schedule
+func (s *ScheduleService) Runs(id uint, limit int) ([]database.ScheduleRun, error) {
+	if limit <= 0 {
+		limit = 50
+	}
+
+	var runs []database.ScheduleRun
+	if err := s.db.Where("schedule_id = ?", id).Order("created_at DESC").Limit(limit).Find(&runs).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch schedule runs: %w", err)
+	}
+	return runs, nil
+}
+
+func decorateSchedule(schedule *database.Schedule) {
+	if schedule.PayloadJSON != "" {
+		schedule.Payload = json.RawMessage(schedule.PayloadJSON)
+	}
+}
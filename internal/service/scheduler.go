@@ -0,0 +1,278 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+
+	"gorm.io/gorm"
+)
+
+// SchedulerUserAgent is the user agent recorded on jobs queued by the scheduler
+const SchedulerUserAgent = "scheduler"
+
+// Scheduler fires due schedules by queueing jobs on behalf of their creators.
+// Runs that fall inside a device maintenance window are skipped and recorded.
+type Scheduler struct {
+	db   *gorm.DB
+	cfg  *config.Config
+	jobs *JobManager
+}
+
+// NewScheduler creates a new Scheduler
+func NewScheduler(db *gorm.DB, cfg *config.Config, jobs *JobManager) *Scheduler {
+	return &Scheduler{
+		db:   db,
+		cfg:  cfg,
+		jobs: jobs,
+	}
+}
+
+// Start launches the scheduler loop
+func (s *Scheduler) Start() {
+	if !s.cfg.Scheduler.Enabled {
+		log.Printf("[SCHEDULER] Disabled by configuration")
+		return
+	}
+
+	tick := s.cfg.Scheduler.Tick
+	if tick <= 0 {
+		tick = 30 * time.Second
+	}
+
+	go func() {
+		ticker := time.NewTicker(tick)
+		defer ticker.Stop()
+		for {
+			s.runDue(time.Now())
+			<-ticker.C
+		}
+	}()
+	log.Printf("[SCHEDULER] Started (tick %s)", tick)
+}
+
+func (s *Scheduler) runDue(now time.Time) {
+	var due []database.Schedule
+	if err := s.db.Where("enabled = ? AND next_run_at <= ?", true, now).
+		Order("next_run_at ASC").Find(&due).Error; err != nil {
+		log.Printf("[SCHEDULER] Failed to query due schedules: %v", err)
+		return
+	}
+
+	for i := range due {
+		s.fire(&due[i], now)
+	}
+}
+
+// fire queues one run of a schedule and advances its next run time.
+// Missed runs (e.g. while the server was down) are collapsed into one.
+func (s *Scheduler) fire(schedule *database.Schedule, now time.Time) {
+	scheduledFor := now
+	if schedule.NextRunAt != nil {
+		scheduledFor = *schedule.NextRunAt
+	}
+
+	deviceIDs, err := s.targetDevices(schedule)
+	status := ScheduleRunQueued
+	if err != nil {
+		s.recordRun(schedule, "", ScheduleRunFailed, 0, err.Error(), scheduledFor)
+		status = ScheduleRunFailed
+	}
+
+	queued, skipped, failed := 0, 0, 0
+	for _, deviceID := range deviceIDs {
+		switch s.fireDevice(schedule, deviceID, scheduledFor) {
+		case ScheduleRunQueued:
+			queued++
+		case ScheduleRunSkipped:
+			skipped++
+		default:
+			failed++
+		}
+	}
+	if err == nil {
+		switch {
+		case queued == 0 && failed == 0 && skipped > 0:
+			status = ScheduleRunSkipped
+		case queued == 0 && failed > 0:
+			status = ScheduleRunFailed
+		case failed > 0 || skipped > 0:
+			status = ScheduleRunPartial
+		}
+	}
+
+	updates := map[string]interface{}{
+		"last_run_at": &now,
+		"last_status": status,
+		"updated_at":  now,
+	}
+	if schedule.Cron != "" {
+		if parsed, parseErr := ParseCron(schedule.Cron); parseErr == nil {
+			if next := parsed.Next(now); !next.IsZero() {
+				updates["next_run_at"] = &next
+			} else {
+				updates["next_run_at"] = nil
+				updates["enabled"] = false
+			}
+		} else {
+			updates["next_run_at"] = nil
+			updates["enabled"] = false
+		}
+	} else {
+		updates["next_run_at"] = nil
+		updates["enabled"] = false
+	}
+
+	if err := s.db.Model(&database.Schedule{}).Where("id = ?", schedule.ID).Updates(updates).Error; err != nil {
+		log.Printf("[SCHEDULER] Failed to update schedule %d: %v", schedule.ID, err)
+	}
+	log.Printf("[SCHEDULER] Fired schedule %d (%s): %d queued, %d skipped, %d failed", schedule.ID, schedule.Kind, queued, skipped, failed)
+}
+
+func (s *Scheduler) targetDevices(schedule *database.Schedule) ([]string, error) {
+	if schedule.DeviceID != "" {
+		return []string{schedule.DeviceID}, nil
+	}
+
+	devices, err := NewDeviceService(s.db, s.cfg).GetAll()
+	if err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(devices))
+	for _, device := range devices {
+		ids = append(ids, device.ID)
+	}
+	return ids, nil
+}
+
+// fireDevice queues the schedule's job for one device and returns the run status
+func (s *Scheduler) fireDevice(schedule *database.Schedule, deviceID string, scheduledFor time.Time) string {
+	actor := JobActor{
+		UserID:    schedule.CreatedByID,
+		Username:  schedule.CreatedBy,
+		Role:      schedule.CreatedByRole,
+		UserAgent: SchedulerUserAgent,
+	}
+
+	window, err := NewMaintenanceService(s.db).ActiveWindow(deviceID, time.Now())
+	if err != nil {
+		s.recordRun(schedule, deviceID, ScheduleRunFailed, 0, err.Error(), scheduledFor)
+		return ScheduleRunFailed
+	}
+	if window != nil {
+		s.recordRun(schedule, deviceID, ScheduleRunSkipped, 0, freezeMessage(window), scheduledFor)
+		s.audit(actor, "schedule.run.skipped", schedule, deviceID, map[string]interface{}{
+			"maintenance_window_id": window.ID,
+		})
+		return ScheduleRunSkipped
+	}
+
+	job, err := s.enqueue(schedule, deviceID, actor)
+	if err != nil {
+		s.recordRun(schedule, deviceID, ScheduleRunFailed, 0, err.Error(), scheduledFor)
+		s.audit(actor, "schedule.run.failed", schedule, deviceID, map[string]interface{}{
+			"error": err.Error(),
+		})
+		return ScheduleRunFailed
+	}
+
+	s.recordRun(schedule, deviceID, ScheduleRunQueued, job.ID, "", scheduledFor)
+	s.audit(actor, "schedule.run.queued", schedule, deviceID, map[string]interface{}{
+		"job_id": job.ID,
+	})
+	return ScheduleRunQueued
+}
+
+func (s *Scheduler) enqueue(schedule *database.Schedule, deviceID string, actor JobActor) (*JobDescriptor, error) {
+	switch schedule.Kind {
+	case ScheduleKindSaveConfig:
+		return s.jobs.Enqueue(JobTypeSaveConfig, deviceID, actor, nil, nil)
+	case ScheduleKindONUAction, ScheduleKindBulkAction:
+		var req BulkONUActionRequest
+		if err := json.Unmarshal([]byte(schedule.PayloadJSON), &req); err != nil {
+			return nil, fmt.Errorf("invalid schedule payload: %w", err)
+		}
+		// Prepare does not query the OLT; filters are resolved by the job
+		bulkSvc := NewBulkService(s.db, s.cfg, NewDeviceService(s.db, s.cfg))
+		targets, err := bulkSvc.Prepare(deviceID, &req)
+		if err != nil {
+			return nil, err
+		}
+		return s.jobs.Enqueue(JobTypeONUBulkAction, deviceID, actor, req, targets)
+	default:
+		return nil, fmt.Errorf("unsupported kind: %s", schedule.Kind)
+	}
+}
+
+// CheckScheduledFreeze refuses to start a scheduled job on a device that
+// entered a maintenance freeze after the job was queued. The schedule run is
+// marked skipped.
+func CheckScheduledFreeze(db *gorm.DB, job *database.Job) error {
+	if job.UserAgent != SchedulerUserAgent || job.DeviceID == "" {
+		return nil
+	}
+	window, err := NewMaintenanceService(db).ActiveWindow(job.DeviceID, time.Now())
+	if err != nil {
+		return err
+	}
+	if window == nil {
+		return nil
+	}
+
+	message := freezeMessage(window)
+	if err := db.Model(&database.ScheduleRun{}).Where("job_id = ?", job.ID).
+		Updates(map[string]interface{}{
+			"status":  ScheduleRunSkipped,
+			"message": message,
+		}).Error; err != nil {
+		log.Printf("[SCHEDULER] Failed to mark run of job %d skipped: %v", job.ID, err)
+	}
+	return fmt.Errorf("%s", message)
+}
+
+func freezeMessage(window *database.MaintenanceWindow) string {
+	message := fmt.Sprintf("device in maintenance freeze until %s", window.EndsAt.Format(time.RFC3339))
+	if window.Reason != "" {
+		message += ": " + window.Reason
+	}
+	return message
+}
+
+func (s *Scheduler) recordRun(schedule *database.Schedule, deviceID, status string, jobID uint, message string, scheduledFor time.Time) {
+	run := &database.ScheduleRun{
+		ScheduleID:   schedule.ID,
+		DeviceID:     deviceID,
+		Status:       status,
+		JobID:        jobID,
+		Message:      message,
+		ScheduledFor: scheduledFor,
+		CreatedAt:    time.Now(),
+	}
+	if err := s.db.Create(run).Error; err != nil {
+		log.Printf("[SCHEDULER] Failed to record run of schedule %d: %v", schedule.ID, err)
+	}
+}
+
+func (s *Scheduler) audit(actor JobActor, action string, schedule *database.Schedule, deviceID string, metadata map[string]interface{}) {
+	metadata["schedule_id"] = schedule.ID
+	metadata["kind"] = schedule.Kind
+	metadata["device_id"] = deviceID
+
+	auditSvc := NewAuditService(s.db)
+	if err := auditSvc.Log(AuditLogEntry{
+		UserID:     actor.UserID,
+		Username:   actor.Username,
+		Role:       actor.Role,
+		Action:     action,
+		Resource:   "schedule",
+		ResourceID: fmt.Sprintf("%d", schedule.ID),
+		Metadata:   metadata,
+		UserAgent:  actor.UserAgent,
+	}); err != nil {
+		log.Printf("[AUDIT] failed to write log action=%s resource=schedule resource_id=%d: %v", action, schedule.ID, err)
+	}
+}
@@ -0,0 +1,327 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+	"olt-api/internal/parser"
+
+	"gorm.io/gorm"
+)
+
+// Search result sources
+const (
+	SearchSourceInventory = "inventory"
+	SearchSourceLive      = "live"
+)
+
+// ONUSearchRequest describes a cross-device ONU search
+type ONUSearchRequest struct {
+	Query    string
+	DeviceID string // optional: restrict to one device
+	Regex    bool   // treat Query as a regular expression for name matching
+	Live     bool   // scrape all devices instead of using the ONU inventory
+	Tags     []string
+	Limit    int
+}
+
+// ONUSearchHit is one matching ONU with its device and PON context
+type ONUSearchHit struct {
+	DeviceID   string             `json:"device_id"`
+	DeviceName string             `json:"device_name"`
+	PONID      string             `json:"pon_id"`
+	MatchedOn  []string           `json:"matched_on"` // mac, name, onu_id
+	Source     string             `json:"source"`     // inventory or live
+	SeenAt     time.Time          `json:"seen_at"`
+	ONU        parser.ONUResponse `json:"onu"`
+}
+
+// ONUSearchResult is returned by SearchService.Search
+type ONUSearchResult struct {
+	Query   string            `json:"query"`
+	Source  string            `json:"source"`
+	Total   int               `json:"total"`
+	Hits    []ONUSearchHit    `json:"hits"`
+	Errors  map[string]string `json:"errors,omitempty"` // device ID -> error (live mode)
+	Devices int               `json:"devices_searched"`
+}
+
+// SearchService finds ONUs across all devices
+type SearchService struct {
+	db            *gorm.DB
+	cfg           *config.Config
+	deviceService *DeviceService
+}
+
+// NewSearchService creates a new SearchService
+func NewSearchService(db *gorm.DB, cfg *config.Config, deviceService *DeviceService) *SearchService {
+	return &SearchService{
+		db:            db,
+		cfg:           cfg,
+		deviceService: deviceService,
+	}
+}
+
+// onuMatcher matches ONUs against a search query
+type onuMatcher struct {
+	tags    []string // hits must carry all of these
+	query   string
+	mac     string
+	onuID   string
+	pattern *regexp.Regexp
+}
+
+func newONUMatcher(query string, useRegex bool) (*onuMatcher, error) {
+	m := &onuMatcher{
+		query: strings.ToLower(query),
+		onuID: normalizeSearchONUID(query),
+	}
+
+	// Only treat the query as a MAC fragment when it is plausibly one.
+	if mac := NormalizeMAC(query); len(mac) >= 4 && isHex(mac) {
+		m.mac = mac
+	}
+
+	if useRegex {
+		pattern, err := regexp.Compile("(?i)" + query)
+		if err != nil {
+			return nil, invalidf("invalid regex: %v", err)
+		}
+		m.pattern = pattern
+	}
+	return m, nil
+}
+
+// match returns the fields the ONU matched on, or nil
+func (m *onuMatcher) match(onu parser.ONUResponse) []string {
+	var matched []string
+	if m.mac != "" && strings.Contains(NormalizeMAC(onu.MacAddress), m.mac) {
+		matched = append(matched, "mac")
+	}
+	if m.pattern != nil {
+		if m.pattern.MatchString(onu.Name) {
+			matched = append(matched, "name")
+		}
+	} else if strings.Contains(strings.ToLower(onu.Name), m.query) {
+		matched = append(matched, "name")
+	}
+	if m.onuID != "" && strings.EqualFold(strings.TrimSpace(onu.ONUID), m.onuID) {
+		matched = append(matched, "onu_id")
+	}
+	return matched
+}
+
+// NormalizeMAC strips separators and lowercases a MAC address, so
+// "AA:BB:CC:DD:EE:FF", "aabb.ccdd.eeff" and "AA-BB-CC-DD-EE-FF" compare equal.
+func NormalizeMAC(mac string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(mac) {
+		switch r {
+		case ':', '-', '.', ' ':
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+func isHex(s string) bool {
+	for _, r := range s {
+		if !strings.ContainsRune("0123456789abcdef", r) {
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeSearchONUID converts "1:8" to "0/1:8"; other ONU IDs are returned as-is.
+// Queries that are not ONU IDs return an empty string.
+func normalizeSearchONUID(query string) string {
+	parts := strings.Split(strings.TrimSpace(query), ":")
+	if len(parts) != 2 {
+		return ""
+	}
+	if _, err := strconv.Atoi(parts[1]); err != nil {
+		return ""
+	}
+	if _, err := strconv.Atoi(parts[0]); err == nil {
+		return "0/" + parts[0] + ":" + parts[1]
+	}
+	return strings.TrimSpace(query)
+}
+
+// Search finds ONUs by MAC, name or ONU ID across devices
+func (s *SearchService) Search(req ONUSearchRequest) (*ONUSearchResult, error) {
+	query := strings.TrimSpace(req.Query)
+	if query == "" {
+		return nil, invalidf("query is required")
+	}
+	matcher, err := newONUMatcher(query, req.Regex)
+	if err != nil {
+		return nil, err
+	}
+	matcher.tags = req.Tags
+
+	devices, err := s.searchDevices(req.DeviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ONUSearchResult{
+		Query:   query,
+		Source:  SearchSourceInventory,
+		Hits:    []ONUSearchHit{},
+		Devices: len(devices),
+	}
+	if req.Live {
+		result.Source = SearchSourceLive
+		result.Hits, result.Errors = s.searchLive(devices, matcher)
+	} else {
+		hits, err := s.searchInventory(devices, matcher)
+		if err != nil {
+			return nil, err
+		}
+		result.Hits = hits
+	}
+
+	sort.SliceStable(result.Hits, func(i, j int) bool {
+		if result.Hits[i].DeviceID != result.Hits[j].DeviceID {
+			return result.Hits[i].DeviceID < result.Hits[j].DeviceID
+		}
+		return result.Hits[i].ONU.ONUID < result.Hits[j].ONU.ONUID
+	})
+
+	result.Total = len(result.Hits)
+	limit := req.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if len(result.Hits) > limit {
+		result.Hits = result.Hits[:limit]
+	}
+	return result, nil
+}
+
+func (s *SearchService) searchDevices(deviceID string) ([]database.Device, error) {
+	if trimmed := strings.TrimSpace(deviceID); trimmed != "" {
+		device, err := s.deviceService.GetByID(trimmed)
+		if err != nil {
+			return nil, err
+		}
+		return []database.Device{*device}, nil
+	}
+	return s.deviceService.GetAll()
+}
+
+// searchInventory matches against the ONUs currently present in the
+// persistent ONU inventory. Hits carry the inventory fields only; optical
+// metrics need a live search.
+func (s *SearchService) searchInventory(devices []database.Device, matcher *onuMatcher) ([]ONUSearchHit, error) {
+	hits := []ONUSearchHit{}
+	for _, device := range devices {
+		var entries []database.ONUInventory
+		if err := s.db.Where("device_id = ? AND present = ?", device.ID, true).Find(&entries).Error; err != nil {
+			return nil, fmt.Errorf("failed to read ONU inventory: %w", err)
+		}
+		if len(entries) == 0 {
+			continue
+		}
+
+		subscribers, err := NewSubscriberService(s.db, s.cfg, s.deviceService).ForDevice(device.ID)
+		if err != nil {
+			return nil, err
+		}
+		tags, err := NewTagService(s.db).ONUTagsForDevice(device.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			onu := parser.ONUResponse{
+				ONUID:      entry.ONUID,
+				Name:       entry.Name,
+				MacAddress: entry.MacAddress,
+				Status:     entry.Status,
+				FwVersion:  entry.FwVersion,
+				ChipID:     entry.ChipID,
+				Tags:       tags[entry.MAC],
+			}
+			if !hasAllTags(onu.Tags, matcher.tags) {
+				continue
+			}
+			if matched := matcher.match(onu); len(matched) > 0 {
+				onu.Subscriber = onuSubscriber(subscribers[entry.MAC])
+				hits = append(hits, ONUSearchHit{
+					DeviceID:   device.ID,
+					DeviceName: device.Name,
+					PONID:      entry.PONID,
+					MatchedOn:  matched,
+					Source:     SearchSourceInventory,
+					SeenAt:     entry.LastSeenAt,
+					ONU:        onu,
+				})
+			}
+		}
+	}
+	return hits, nil
+}
+
+// searchLive scrapes every device concurrently; per-device rate limits are
+// enforced by the scraper client.
+func (s *SearchService) searchLive(devices []database.Device, matcher *onuMatcher) ([]ONUSearchHit, map[string]string) {
+	hits := []ONUSearchHit{}
+	errs := map[string]string{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	onuSvc := NewONUService(s.db, s.cfg, s.deviceService)
+	for _, device := range devices {
+		device := device
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			onus, err := onuSvc.GetAllONUs(device.ID, "")
+			seenAt := time.Now()
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				log.Printf("[SEARCH] Live scrape of device %s failed: %v", device.ID, err)
+				errs[device.ID] = err.Error()
+				return
+			}
+			for _, onu := range FilterONUsByTags(onus, matcher.tags) {
+				if matched := matcher.match(onu); len(matched) > 0 {
+					hits = append(hits, ONUSearchHit{
+						DeviceID:   device.ID,
+						DeviceName: device.Name,
+						PONID:      ponOfONU(onu.ONUID),
+						MatchedOn:  matched,
+						Source:     SearchSourceLive,
+						SeenAt:     seenAt,
+						ONU:        onu,
+					})
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	if len(errs) == 0 {
+		errs = nil
+	}
+	return hits, errs
+}
+
+// ponOfONU returns the PON part of an ONU ID ("0/1:8" -> "0/1")
+func ponOfONU(onuID string) string {
+	return strings.TrimSpace(strings.SplitN(onuID, ":", 2)[0])
+}
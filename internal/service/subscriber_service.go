@@ -0,0 +1,371 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+	"olt-api/internal/parser"
+
+	"gorm.io/gorm"
+)
+
+// SubscriberFilter controls subscriber list queries
+type SubscriberFilter struct {
+	Query    string // matches account number, name, phone or address
+	Plan     string
+	DeviceID string
+	Limit    int
+}
+
+// SubscriberService manages customer records linked to ONUs
+type SubscriberService struct {
+	db            *gorm.DB
+	cfg           *config.Config
+	deviceService *DeviceService
+}
+
+// NewSubscriberService creates a new SubscriberService
+func NewSubscriberService(db *gorm.DB, cfg *config.Config, deviceService *DeviceService) *SubscriberService {
+	return &SubscriberService{
+		db:            db,
+		cfg:           cfg,
+		deviceService: deviceService,
+	}
+}
+
+// Create stores a new subscriber
+func (s *SubscriberService) Create(req *database.SubscriberRequest) (*database.Subscriber, error) {
+	account := strings.TrimSpace(req.AccountNumber)
+	if account == "" {
+		return nil, invalidf("account_number is required")
+	}
+	if err := s.ensureAccountFree(account, 0); err != nil {
+		return nil, err
+	}
+
+	deviceID, mac, err := s.resolveLink(req.DeviceID, req.MAC, req.ONUID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.ensureLinkFree(deviceID, mac, 0); err != nil {
+		return nil, err
+	}
+
+	attributes, err := encodeAttributes(req.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	subscriber := &database.Subscriber{
+		AccountNumber:  account,
+		Name:           strings.TrimSpace(req.Name),
+		Address:        strings.TrimSpace(req.Address),
+		Phone:          strings.TrimSpace(req.Phone),
+		Plan:           strings.TrimSpace(req.Plan),
+		Notes:          req.Notes,
+		AttributesJSON: attributes,
+		DeviceID:       deviceID,
+		MAC:            mac,
+		CreatedAt:      now,
+		UpdatedAt:      now,
+	}
+	if err := s.db.Create(subscriber).Error; err != nil {
+		return nil, fmt.Errorf("failed to create subscriber: %w", err)
+	}
+
+	decorateSubscriber(subscriber)
+	return subscriber, nil
+}
+
+// List returns subscribers matching the filter, ordered by name
+func (s *SubscriberService) List(filter SubscriberFilter) ([]database.Subscriber, error) {
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+	if limit > 1000 {
+		limit = 1000
+	}
+
+	query := s.db.Order("name ASC").Limit(limit)
+	if trimmed := strings.TrimSpace(filter.Query); trimmed != "" {
+		like := "%" + strings.ToLower(trimmed) + "%"
+		query = query.Where("LOWER(account_number) LIKE ? OR LOWER(name) LIKE ? OR LOWER(phone) LIKE ? OR LOWER(address) LIKE ?",
+			like, like, like, like)
+	}
+	if trimmed := strings.TrimSpace(filter.Plan); trimmed != "" {
+		query = query.Where("LOWER(plan) = ?", strings.ToLower(trimmed))
+	}
+	if trimmed := strings.TrimSpace(filter.DeviceID); trimmed != "" {
+		query = query.Where("device_id = ?", trimmed)
+	}
+
+	var subscribers []database.Subscriber
+	if err := query.Find(&subscribers).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch subscribers: %w", err)
+	}
+	for i := range subscribers {
+		decorateSubscriber(&subscribers[i])
+	}
+	return subscribers, nil
+}
+
+// Get returns a subscriber by ID
+func (s *SubscriberService) Get(id uint) (*database.Subscriber, error) {
+	var subscriber database.Subscriber
+	if err := s.db.First(&subscriber, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("subscriber '%d' %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch subscriber: %w", err)
+	}
+	decorateSubscriber(&subscriber)
+	return &subscriber, nil
+}
+
+// Update applies the set fields of req to a subscriber
+func (s *SubscriberService) Update(id uint, req *database.SubscriberUpdateRequest) (*database.Subscriber, error) {
+	subscriber, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.AccountNumber != nil {
+		account := strings.TrimSpace(*req.AccountNumber)
+		if account == "" {
+			return nil, invalidf("account_number cannot be empty")
+		}
+		if err := s.ensureAccountFree(account, id); err != nil {
+			return nil, err
+		}
+		subscriber.AccountNumber = account
+	}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, invalidf("name cannot be empty")
+		}
+		subscriber.Name = name
+	}
+	if req.Address != nil {
+		subscriber.Address = strings.TrimSpace(*req.Address)
+	}
+	if req.Phone != nil {
+		subscriber.Phone = strings.TrimSpace(*req.Phone)
+	}
+	if req.Plan != nil {
+		subscriber.Plan = strings.TrimSpace(*req.Plan)
+	}
+	if req.Notes != nil {
+		subscriber.Notes = *req.Notes
+	}
+	if req.Attributes != nil {
+		attributes, err := encodeAttributes(req.Attributes)
+		if err != nil {
+			return nil, err
+		}
+		subscriber.AttributesJSON = attributes
+	}
+
+	if req.DeviceID != nil || req.MAC != nil || req.ONUID != nil {
+		deviceID, mac, onuID := subscriber.DeviceID, subscriber.MAC, ""
+		if req.DeviceID != nil {
+			deviceID = *req.DeviceID
+		}
+		if req.MAC != nil {
+			mac = *req.MAC
+		}
+		if req.ONUID != nil {
+			mac, onuID = "", *req.ONUID
+		}
+		if strings.TrimSpace(deviceID) == "" {
+			mac, onuID = "", ""
+		}
+
+		deviceID, mac, err = s.resolveLink(deviceID, mac, onuID)
+		if err != nil {
+			return nil, err
+		}
+		if err := s.ensureLinkFree(deviceID, mac, id); err != nil {
+			return nil, err
+		}
+		subscriber.DeviceID = deviceID
+		subscriber.MAC = mac
+	}
+
+	subscriber.UpdatedAt = time.Now()
+	if err := s.db.Save(subscriber).Error; err != nil {
+		return nil, fmt.Errorf("failed to update subscriber: %w", err)
+	}
+
+	decorateSubscriber(subscriber)
+	return subscriber, nil
+}
+
+// Delete removes a subscriber
+func (s *SubscriberService) Delete(id uint) error {
+	result := s.db.Where("id = ?", id).Delete(&database.Subscriber{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to delete subscriber: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("subscriber '%d' %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// ForDevice returns the subscribers linked to ONUs of a device, keyed by normalized MAC
+func (s *SubscriberService) ForDevice(deviceID string) (map[string]*database.Subscriber, error) {
+	var subscribers []database.Subscriber
+	if err := s.db.Where("device_id = ? AND mac <> ''", deviceID).Find(&subscribers).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch subscribers: %w", err)
+	}
+
+	byMAC := make(map[string]*database.Subscriber, len(subscribers))
+	for i := range subscribers {
+		decorateSubscriber(&subscribers[i])
+		byMAC[subscribers[i].MAC] = &subscribers[i]
+	}
+	return byMAC, nil
+}
+
+// AttachToONUs sets the Subscriber field of ONUs that have a linked subscriber
+func (s *SubscriberService) AttachToONUs(deviceID string, onus []parser.ONUResponse) {
+	if len(onus) == 0 {
+		return
+	}
+	byMAC, err := s.ForDevice(deviceID)
+	if err != nil || len(byMAC) == 0 {
+		return
+	}
+	for i := range onus {
+		onus[i].Subscriber = onuSubscriber(byMAC[NormalizeMAC(onus[i].MacAddress)])
+	}
+}
+
+// ForONU returns the subscriber linked to one ONU, or nil
+func (s *SubscriberService) ForONU(deviceID, macAddress string) *database.Subscriber {
+	mac := NormalizeMAC(macAddress)
+	if mac == "" {
+		return nil
+	}
+	var subscriber database.Subscriber
+	if err := s.db.Where("device_id = ? AND mac = ?", deviceID, mac).First(&subscriber).Error; err != nil {
+		return nil
+	}
+	decorateSubscriber(&subscriber)
+	return &subscriber
+}
+
+// onuSubscriber converts a subscriber for ONU responses; nil stays nil
+func onuSubscriber(subscriber *database.Subscriber) *parser.ONUSubscriber {
+	if subscriber == nil {
+		return nil
+	}
+	return &parser.ONUSubscriber{
+		ID:            subscriber.ID,
+		AccountNumber: subscriber.AccountNumber,
+		Name:          subscriber.Name,
+		Address:       subscriber.Address,
+		Phone:         subscriber.Phone,
+		Plan:          subscriber.Plan,
+		Notes:         subscriber.Notes,
+		Attributes:    subscriber.Attributes,
+		DeviceID:      subscriber.DeviceID,
+		MAC:           subscriber.MAC,
+		CreatedAt:     subscriber.CreatedAt,
+		UpdatedAt:     subscriber.UpdatedAt,
+	}
+}
+
+// resolveLink validates an ONU link and returns the device ID and normalized MAC.
+// An empty device ID means the subscriber is not linked.
+func (s *SubscriberService) resolveLink(deviceID, mac, onuID string) (string, string, error) {
+	deviceID = strings.TrimSpace(deviceID)
+	mac = NormalizeMAC(mac)
+	onuID = strings.TrimSpace(onuID)
+
+	if deviceID == "" {
+		if mac != "" || onuID != "" {
+			return "", "", invalidf("device_id is required to link an ONU")
+		}
+		return "", "", nil
+	}
+	// A device or ONU missing from the link is a bad request, not a missing
+	// subscriber
+	if _, err := s.deviceService.GetByID(deviceID); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return "", "", invalidf("%s", err)
+		}
+		return "", "", err
+	}
+
+	switch {
+	case mac != "":
+		if len(mac) != 12 || !isHex(mac) {
+			return "", "", invalidf("invalid MAC address")
+		}
+	case onuID != "":
+		resolved, err := NewInventoryService(s.db).MACForONU(deviceID, onuID)
+		if errors.Is(err, ErrNotFound) {
+			return "", "", invalidf("%s", err)
+		}
+		if err != nil {
+			return "", "", err
+		}
+		mac = resolved
+	default:
+		return "", "", invalidf("mac or onu_id is required when device_id is set")
+	}
+	return deviceID, mac, nil
+}
+
+func (s *SubscriberService) ensureAccountFree(account string, exceptID uint) error {
+	var count int64
+	if err := s.db.Model(&database.Subscriber{}).
+		Where("account_number = ? AND id <> ?", account, exceptID).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to check account number: %w", err)
+	}
+	if count > 0 {
+		return invalidf("account number '%s' already exists", account)
+	}
+	return nil
+}
+
+func (s *SubscriberService) ensureLinkFree(deviceID, mac string, exceptID uint) error {
+	if deviceID == "" || mac == "" {
+		return nil
+	}
+	var existing database.Subscriber
+	err := s.db.Where("device_id = ? AND mac = ? AND id <> ?", deviceID, mac, exceptID).First(&existing).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to check ONU link: %w", err)
+	}
+	return invalidf("ONU is already linked to subscriber '%s'", existing.AccountNumber)
+}
+
+func encodeAttributes(attributes map[string]interface{}) (string, error) {
+	if len(attributes) == 0 {
+		return "", nil
+	}
+	raw, err := json.Marshal(attributes)
+	if err != nil {
+		return "", fmt.Errorf("failed to encode attributes: %w", err)
+	}
+	return string(raw), nil
+}
+
+func decorateSubscriber(subscriber *database.Subscriber) {
+	if subscriber.AttributesJSON != "" {
+		_ = json.Unmarshal([]byte(subscriber.AttributesJSON), &subscriber.Attributes)
+	}
+}
@@ -0,0 +1,432 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+	"olt-api/internal/parser"
+
+	"gorm.io/gorm"
+)
+
+// SystemHistorySummary aggregates the CPU and memory usage of a device over
+// a history range
+type SystemHistorySummary struct {
+	Samples    int        `json:"samples"`
+	CPUAvg     *float64   `json:"cpu_avg"`
+	CPUMax     *float64   `json:"cpu_max"`
+	MemoryAvg  *float64   `json:"memory_avg"`
+	MemoryMax  *float64   `json:"memory_max"`
+	Reboots    int        `json:"reboots"`
+	LastBootAt *time.Time `json:"last_boot_at,omitempty"`
+}
+
+// SystemHistory is a time series of one device's CPU, memory and run time.
+// Points holds []database.DeviceSystemSample for raw steps and
+// []database.DeviceSystemRollup otherwise.
+type SystemHistory struct {
+	DeviceID  string                  `json:"device_id"`
+	From      time.Time               `json:"from"`
+	To        time.Time               `json:"to"`
+	Step      string                  `json:"step"`
+	Summary   SystemHistorySummary    `json:"summary"`
+	Reboots   []database.DeviceReboot `json:"reboots"`
+	Count     int                     `json:"count"`
+	Truncated bool                    `json:"truncated,omitempty"`
+	Points    interface{}             `json:"points"`
+}
+
+// systemRollupRow is one aggregated device row of a bucket
+type systemRollupRow struct {
+	DeviceID  string
+	Samples   int
+	CPUMin    *float64 `gorm:"column:cpu_min"`
+	CPUAvg    *float64 `gorm:"column:cpu_avg"`
+	CPUMax    *float64 `gorm:"column:cpu_max"`
+	MemoryMin *float64
+	MemoryAvg *float64
+	MemoryMax *float64
+	Reboots   int
+}
+
+const systemHourlySelect = `device_id, COUNT(*) AS samples,
+	MIN(cpu_usage) AS cpu_min, AVG(cpu_usage) AS cpu_avg, MAX(cpu_usage) AS cpu_max,
+	MIN(memory_usage) AS memory_min, AVG(memory_usage) AS memory_avg, MAX(memory_usage) AS memory_max,
+	SUM(CASE WHEN rebooted THEN 1 ELSE 0 END) AS reboots`
+
+// Daily averages weight hourly averages by their sample counts
+const systemDailySelect = `device_id, SUM(samples) AS samples,
+	MIN(cpu_min) AS cpu_min, SUM(cpu_avg * samples) / NULLIF(SUM(samples), 0) AS cpu_avg, MAX(cpu_max) AS cpu_max,
+	MIN(memory_min) AS memory_min, SUM(memory_avg * samples) / NULLIF(SUM(samples), 0) AS memory_avg, MAX(memory_max) AS memory_max,
+	SUM(reboots) AS reboots`
+
+// SystemMetricsService records OLT CPU, memory and run time samples, detects
+// reboots from the run time and maintains the rollups of the samples
+type SystemMetricsService struct {
+	db            *gorm.DB
+	cfg           *config.Config
+	deviceService *DeviceService
+}
+
+// NewSystemMetricsService creates a new SystemMetricsService
+func NewSystemMetricsService(db *gorm.DB, cfg *config.Config, deviceService *DeviceService) *SystemMetricsService {
+	return &SystemMetricsService{
+		db:            db,
+		cfg:           cfg,
+		deviceService: deviceService,
+	}
+}
+
+// Record stores a sample of system info read at the given time. The device rebooted when its run time went
+// backwards or its boot time (sample time minus run time) moved forward by
+// more than system_metrics.reboot_tolerance, which also catches reboots
+// between samples far apart.
+func (s *SystemMetricsService) Record(deviceID string, info *parser.SystemInfoResponse, at time.Time) (*database.DeviceSystemSample, error) {
+	sample := &database.DeviceSystemSample{
+		DeviceID:      deviceID,
+		RecordedAt:    at,
+		CPUUsage:      info.CPUUsage,
+		MemoryUsage:   info.MemoryUsage,
+		RunTime:       info.RunTime,
+		UptimeSeconds: info.UptimeSeconds,
+	}
+
+	var reboot *database.DeviceReboot
+	if sample.UptimeSeconds != nil {
+		var previous database.DeviceSystemSample
+		err := s.db.Where("device_id = ? AND uptime_seconds IS NOT NULL", deviceID).
+			Order("recorded_at DESC").
+			First(&previous).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("failed to load previous system sample: %w", err)
+		}
+		if err == nil {
+			uptime, previousUptime := *sample.UptimeSeconds, *previous.UptimeSeconds
+			bootedAt := at.Add(-time.Duration(uptime) * time.Second)
+			previousBoot := previous.RecordedAt.Add(-time.Duration(previousUptime) * time.Second)
+			if uptime < previousUptime || bootedAt.Sub(previousBoot) > s.rebootTolerance() {
+				sample.Rebooted = true
+				reboot = &database.DeviceReboot{
+					DeviceID:              deviceID,
+					DetectedAt:            at,
+					BootedAt:              bootedAt,
+					PreviousUptimeSeconds: previousUptime,
+					UptimeSeconds:         uptime,
+				}
+			}
+		}
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(sample).Error; err != nil {
+			return fmt.Errorf("failed to store system sample: %w", err)
+		}
+		if reboot != nil {
+			if err := tx.Create(reboot).Error; err != nil {
+				return fmt.Errorf("failed to store reboot: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if reboot != nil {
+		log.Printf("[SYSTEM] Device %s rebooted at about %s (run time %s)",
+			deviceID, reboot.BootedAt.Format(time.RFC3339), info.RunTime)
+	}
+	return sample, nil
+}
+
+// Latest returns the newest sample of a device recorded at or after since,
+// or nil when there is none
+func (s *SystemMetricsService) Latest(deviceID string, since time.Time) (*database.DeviceSystemSample, error) {
+	var sample database.DeviceSystemSample
+	err := s.db.Where("device_id = ? AND recorded_at >= ?", deviceID, since).
+		Order("recorded_at DESC").
+		First(&sample).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load system sample: %w", err)
+	}
+	return &sample, nil
+}
+
+func (s *SystemMetricsService) rebootTolerance() time.Duration {
+	if s.cfg.SystemMetrics.RebootTolerance > 0 {
+		return s.cfg.SystemMetrics.RebootTolerance
+	}
+	return 2 * time.Minute
+}
+
+// History returns the system metrics and reboots of a device between from
+// and to
+func (s *SystemMetricsService) History(deviceID string, from, to time.Time, step string) (*SystemHistory, error) {
+	if !to.After(from) {
+		return nil, ErrInvalidRange
+	}
+	if _, err := s.deviceService.GetByID(deviceID); err != nil {
+		return nil, err
+	}
+	// Stored timestamps are in local time; compare like with like
+	from, to = from.Local(), to.Local()
+	if step == HistoryStepAuto {
+		step = s.autoStep(from, to)
+	}
+
+	history := &SystemHistory{DeviceID: deviceID, From: from, To: to, Step: step}
+	if err := s.db.Where("device_id = ? AND detected_at >= ? AND detected_at < ?", deviceID, from, to).
+		Order("detected_at DESC").
+		Find(&history.Reboots).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch reboots: %w", err)
+	}
+
+	var summary *gorm.DB
+	if step == HistoryStepRaw {
+		var samples []database.DeviceSystemSample
+		if err := s.db.Where("device_id = ? AND recorded_at >= ? AND recorded_at < ?", deviceID, from, to).
+			Order("recorded_at ASC").
+			Limit(maxRawHistoryPoints + 1).
+			Find(&samples).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch system history: %w", err)
+		}
+		if len(samples) > maxRawHistoryPoints {
+			samples = samples[:maxRawHistoryPoints]
+			history.Truncated = true
+		}
+		history.Count = len(samples)
+		history.Points = samples
+		summary = s.db.Model(&database.DeviceSystemSample{}).
+			Select(`COUNT(*) AS samples, AVG(cpu_usage) AS cpu_avg, MAX(cpu_usage) AS cpu_max,
+				AVG(memory_usage) AS memory_avg, MAX(memory_usage) AS memory_max`).
+			Where("device_id = ? AND recorded_at >= ? AND recorded_at < ?", deviceID, from, to)
+	} else {
+		var rollups []database.DeviceSystemRollup
+		if err := s.db.Where("device_id = ? AND tier = ? AND bucket_start >= ? AND bucket_start < ?",
+			deviceID, step, bucketStart(from, step), to).
+			Order("bucket_start ASC").
+			Find(&rollups).Error; err != nil {
+			return nil, fmt.Errorf("failed to fetch system history: %w", err)
+		}
+		history.Count = len(rollups)
+		history.Points = rollups
+		summary = s.db.Model(&database.DeviceSystemRollup{}).
+			Select(`SUM(samples) AS samples, SUM(cpu_avg * samples) / NULLIF(SUM(samples), 0) AS cpu_avg, MAX(cpu_max) AS cpu_max,
+				SUM(memory_avg * samples) / NULLIF(SUM(samples), 0) AS memory_avg, MAX(memory_max) AS memory_max`).
+			Where("device_id = ? AND tier = ? AND bucket_start >= ? AND bucket_start < ?", deviceID, step, bucketStart(from, step), to)
+	}
+
+	if err := summary.Scan(&history.Summary).Error; err != nil {
+		return nil, fmt.Errorf("failed to summarize system history: %w", err)
+	}
+	history.Summary.CPUAvg = roundMetric(history.Summary.CPUAvg)
+	history.Summary.MemoryAvg = roundMetric(history.Summary.MemoryAvg)
+	history.Summary.Reboots = len(history.Reboots)
+
+	var last database.DeviceSystemSample
+	if err := s.db.Where("device_id = ? AND uptime_seconds IS NOT NULL AND recorded_at < ?", deviceID, to).
+		Order("recorded_at DESC").
+		First(&last).Error; err == nil {
+		bootedAt := last.RecordedAt.Add(-time.Duration(*last.UptimeSeconds) * time.Second)
+		history.Summary.LastBootAt = &bootedAt
+	}
+	return history, nil
+}
+
+// autoStep picks raw samples for short ranges still inside raw retention,
+// hourly rollups up to 60 days and daily rollups beyond
+func (s *SystemMetricsService) autoStep(from, to time.Time) string {
+	span := to.Sub(from)
+	retention := s.cfg.SystemMetrics.Retention
+	rawKept := retention <= 0 || from.After(time.Now().Add(-retention))
+	switch {
+	case span <= 48*time.Hour && rawKept:
+		return HistoryStepRaw
+	case span <= 60*24*time.Hour:
+		return HistoryStepHour
+	}
+	return HistoryStepDay
+}
+
+// Rollup aggregates completed hours of samples and completed days of hourly
+// rollups. Buckets are rebuilt idempotently.
+func (s *SystemMetricsService) Rollup(now time.Time) error {
+	hours, err := s.rollupTier(HistoryStepHour, bucketStart(now, HistoryStepHour))
+	if err != nil {
+		return err
+	}
+
+	// Only roll up days whose hours are all done
+	dayEnd := bucketStart(now, HistoryStepDay)
+	if last, ok := s.lastBucket(HistoryStepHour); ok && nextBucket(last, HistoryStepHour).Before(dayEnd) {
+		dayEnd = bucketStart(nextBucket(last, HistoryStepHour), HistoryStepDay)
+	}
+	days, err := s.rollupTier(HistoryStepDay, dayEnd)
+	if err != nil {
+		return err
+	}
+
+	if hours > 0 || days > 0 {
+		log.Printf("[HISTORY] Rolled up %d hour and %d day system metric buckets", hours, days)
+	}
+	return nil
+}
+
+func (s *SystemMetricsService) rollupTier(tier string, end time.Time) (int, error) {
+	start, ok := s.lastBucket(tier)
+	if ok {
+		start = nextBucket(start, tier)
+	} else {
+		first, found := s.firstSource(tier)
+		if !found {
+			return 0, nil
+		}
+		start = bucketStart(first, tier)
+	}
+
+	processed := 0
+	for bucket := start; !nextBucket(bucket, tier).After(end) && processed < maxRollupBuckets; bucket = nextBucket(bucket, tier) {
+		if err := s.rollupBucket(tier, bucket); err != nil {
+			return processed, err
+		}
+		processed++
+	}
+	return processed, nil
+}
+
+func (s *SystemMetricsService) rollupBucket(tier string, bucket time.Time) error {
+	until := nextBucket(bucket, tier)
+
+	var rows []systemRollupRow
+	var err error
+	if tier == HistoryStepHour {
+		err = s.db.Model(&database.DeviceSystemSample{}).Select(systemHourlySelect).
+			Where("recorded_at >= ? AND recorded_at < ?", bucket, until).
+			Group("device_id").Scan(&rows).Error
+	} else {
+		err = s.db.Model(&database.DeviceSystemRollup{}).Select(systemDailySelect).
+			Where("tier = ? AND bucket_start >= ? AND bucket_start < ? AND device_id <> ''", HistoryStepHour, bucket, until).
+			Group("device_id").Scan(&rows).Error
+	}
+	if err != nil {
+		return fmt.Errorf("failed to aggregate system %s bucket %s: %w", tier, bucket.Format(time.RFC3339), err)
+	}
+
+	rollups := make([]database.DeviceSystemRollup, 0, len(rows))
+	for _, row := range rows {
+		rollups = append(rollups, database.DeviceSystemRollup{
+			DeviceID:    row.DeviceID,
+			Tier:        tier,
+			BucketStart: bucket,
+			Samples:     row.Samples,
+			CPUMin:      row.CPUMin,
+			CPUAvg:      roundMetric(row.CPUAvg),
+			CPUMax:      row.CPUMax,
+			MemoryMin:   row.MemoryMin,
+			MemoryAvg:   roundMetric(row.MemoryAvg),
+			MemoryMax:   row.MemoryMax,
+			Reboots:     row.Reboots,
+		})
+	}
+
+	// An empty bucket still gets a marker row so progress is remembered
+	if len(rollups) == 0 {
+		rollups = append(rollups, database.DeviceSystemRollup{Tier: tier, BucketStart: bucket})
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tier = ? AND bucket_start = ?", tier, bucket).
+			Delete(&database.DeviceSystemRollup{}).Error; err != nil {
+			return fmt.Errorf("failed to replace system %s bucket: %w", tier, err)
+		}
+		if err := tx.CreateInBatches(rollups, 500).Error; err != nil {
+			return fmt.Errorf("failed to store system %s bucket: %w", tier, err)
+		}
+		return nil
+	})
+}
+
+// lastBucket returns the newest processed bucket of a tier
+func (s *SystemMetricsService) lastBucket(tier string) (time.Time, bool) {
+	var rollup database.DeviceSystemRollup
+	if err := s.db.Where("tier = ?", tier).Order("bucket_start DESC").First(&rollup).Error; err != nil {
+		return time.Time{}, false
+	}
+	return rollup.BucketStart, true
+}
+
+// firstSource returns the oldest input row of a tier
+func (s *SystemMetricsService) firstSource(tier string) (time.Time, bool) {
+	if tier == HistoryStepHour {
+		var sample database.DeviceSystemSample
+		if err := s.db.Order("recorded_at ASC").First(&sample).Error; err != nil {
+			return time.Time{}, false
+		}
+		return sample.RecordedAt, true
+	}
+	var rollup database.DeviceSystemRollup
+	if err := s.db.Where("tier = ?", HistoryStepHour).Order("bucket_start ASC").First(&rollup).Error; err != nil {
+		return time.Time{}, false
+	}
+	return rollup.BucketStart, true
+}
+
+// ApplyRetention deletes samples, rollups and reboots past their retention.
+// Samples are kept until their hour has been rolled up; reboots are kept as
+// long as daily rollups.
+func (s *SystemMetricsService) ApplyRetention(now time.Time) error {
+	if s.cfg.SystemMetrics.Retention > 0 {
+		if last, ok := s.lastBucket(HistoryStepHour); ok {
+			cutoff := now.Add(-s.cfg.SystemMetrics.Retention)
+			if rolled := nextBucket(last, HistoryStepHour); rolled.Before(cutoff) {
+				cutoff = rolled
+			}
+			result := s.db.Where("recorded_at < ?", cutoff).Delete(&database.DeviceSystemSample{})
+			if result.Error != nil {
+				return fmt.Errorf("failed to prune system samples: %w", result.Error)
+			}
+			if result.RowsAffected > 0 {
+				log.Printf("[HISTORY] Pruned %d system sample rows", result.RowsAffected)
+			}
+		}
+	}
+
+	tiers := map[string]time.Duration{
+		HistoryStepHour: s.cfg.Retention.Hourly,
+		HistoryStepDay:  s.cfg.Retention.Daily,
+	}
+	for tier, retention := range tiers {
+		if retention <= 0 {
+			continue
+		}
+		// Keep the newest bucket of each tier; it marks rollup progress
+		last, ok := s.lastBucket(tier)
+		if !ok {
+			continue
+		}
+		cutoff := now.Add(-retention)
+		if last.Before(cutoff) {
+			cutoff = last
+		}
+		result := s.db.Where("tier = ? AND bucket_start < ?", tier, cutoff).Delete(&database.DeviceSystemRollup{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to prune system %s rollups: %w", tier, result.Error)
+		}
+		if result.RowsAffected > 0 {
+			log.Printf("[HISTORY] Pruned %d system %s rollup rows", result.RowsAffected, tier)
+		}
+	}
+
+	if s.cfg.Retention.Daily > 0 {
+		result := s.db.Where("detected_at < ?", now.Add(-s.cfg.Retention.Daily)).Delete(&database.DeviceReboot{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to prune reboots: %w", result.Error)
+		}
+	}
+	return nil
+}
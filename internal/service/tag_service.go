@@ -0,0 +1,358 @@
+package service
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"olt-api/internal/database"
+	"olt-api/internal/parser"
+
+	"gorm.io/gorm"
+)
+
+var tagNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9._:-]{0,63}$`)
+
+// TagService manages tags and their links to devices and ONUs
+type TagService struct {
+	db *gorm.DB
+}
+
+// NewTagService creates a new TagService
+func NewTagService(db *gorm.DB) *TagService {
+	return &TagService{db: db}
+}
+
+// NormalizeTagName lowercases and trims a tag name
+func NormalizeTagName(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// ParseTagList splits a comma-separated tag filter ("vip,building-12")
+func ParseTagList(raw string) []string {
+	var tags []string
+	for _, part := range strings.Split(raw, ",") {
+		if name := NormalizeTagName(part); name != "" {
+			tags = append(tags, name)
+		}
+	}
+	return tags
+}
+
+func validateTagName(name string) error {
+	if !tagNamePattern.MatchString(name) {
+		return invalidf("invalid tag name '%s' (use lowercase letters, digits, '.', '_', ':' or '-', max 64 chars)", name)
+	}
+	return nil
+}
+
+// List returns all tags ordered by name
+func (s *TagService) List() ([]database.Tag, error) {
+	var tags []database.Tag
+	if err := s.db.Order("name ASC").Find(&tags).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch tags: %w", err)
+	}
+	return tags, nil
+}
+
+// Get returns a tag by ID
+func (s *TagService) Get(id uint) (*database.Tag, error) {
+	var tag database.Tag
+	if err := s.db.First(&tag, id).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("tag '%d' %w", id, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch tag: %w", err)
+	}
+	return &tag, nil
+}
+
+// Create stores a new tag
+func (s *TagService) Create(req *database.TagRequest) (*database.Tag, error) {
+	name := NormalizeTagName(req.Name)
+	if err := validateTagName(name); err != nil {
+		return nil, err
+	}
+
+	var count int64
+	if err := s.db.Model(&database.Tag{}).Where("name = ?", name).Count(&count).Error; err != nil {
+		return nil, fmt.Errorf("failed to check tag name: %w", err)
+	}
+	if count > 0 {
+		return nil, invalidf("tag '%s' already exists", name)
+	}
+
+	now := time.Now()
+	tag := &database.Tag{
+		Name:        name,
+		Color:       strings.TrimSpace(req.Color),
+		Description: strings.TrimSpace(req.Description),
+		CreatedAt:   now,
+		UpdatedAt:   now,
+	}
+	if err := s.db.Create(tag).Error; err != nil {
+		return nil, fmt.Errorf("failed to create tag: %w", err)
+	}
+	return tag, nil
+}
+
+// Update renames or re-describes a tag; links are kept
+func (s *TagService) Update(id uint, req *database.TagRequest) (*database.Tag, error) {
+	tag, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+
+	name := NormalizeTagName(req.Name)
+	if err := validateTagName(name); err != nil {
+		return nil, err
+	}
+	if name != tag.Name {
+		var count int64
+		if err := s.db.Model(&database.Tag{}).Where("name = ? AND id <> ?", name, id).Count(&count).Error; err != nil {
+			return nil, fmt.Errorf("failed to check tag name: %w", err)
+		}
+		if count > 0 {
+			return nil, invalidf("tag '%s' already exists", name)
+		}
+	}
+
+	tag.Name = name
+	tag.Color = strings.TrimSpace(req.Color)
+	tag.Description = strings.TrimSpace(req.Description)
+	tag.UpdatedAt = time.Now()
+	if err := s.db.Save(tag).Error; err != nil {
+		return nil, fmt.Errorf("failed to update tag: %w", err)
+	}
+	return tag, nil
+}
+
+// Delete removes a tag and all its device and ONU links
+func (s *TagService) Delete(id uint) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("DELETE FROM device_tags WHERE tag_id = ?", id).Error; err != nil {
+			return fmt.Errorf("failed to unlink tag: %w", err)
+		}
+		if err := tx.Where("tag_id = ?", id).Delete(&database.ONUTag{}).Error; err != nil {
+			return fmt.Errorf("failed to unlink tag: %w", err)
+		}
+		result := tx.Where("id = ?", id).Delete(&database.Tag{})
+		if result.Error != nil {
+			return fmt.Errorf("failed to delete tag: %w", result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return fmt.Errorf("tag '%d' %w", id, ErrNotFound)
+		}
+		return nil
+	})
+}
+
+// ensureTags returns the tags with the given names, creating missing ones
+func (s *TagService) ensureTags(names []string) ([]database.Tag, error) {
+	tags := make([]database.Tag, 0, len(names))
+	for _, raw := range names {
+		name := NormalizeTagName(raw)
+		if err := validateTagName(name); err != nil {
+			return nil, err
+		}
+
+		now := time.Now()
+		tag := database.Tag{Name: name, CreatedAt: now, UpdatedAt: now}
+		if err := s.db.Where("name = ?", name).FirstOrCreate(&tag).Error; err != nil {
+			return nil, fmt.Errorf("failed to create tag '%s': %w", name, err)
+		}
+		tags = append(tags, tag)
+	}
+	return tags, nil
+}
+
+// AddDeviceTags attaches tags to a device and returns its full tag list
+func (s *TagService) AddDeviceTags(deviceID string, names []string) ([]database.Tag, error) {
+	device, err := s.device(deviceID)
+	if err != nil {
+		return nil, err
+	}
+	tags, err := s.ensureTags(names)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.Model(device).Association("Tags").Append(tags); err != nil {
+		return nil, fmt.Errorf("failed to tag device: %w", err)
+	}
+	return s.DeviceTags(deviceID)
+}
+
+// RemoveDeviceTag detaches a tag from a device
+func (s *TagService) RemoveDeviceTag(deviceID, name string) error {
+	device, err := s.device(deviceID)
+	if err != nil {
+		return err
+	}
+	var tag database.Tag
+	if err := s.db.Where("name = ?", NormalizeTagName(name)).First(&tag).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("tag '%s' %w", name, ErrNotFound)
+		}
+		return fmt.Errorf("failed to fetch tag: %w", err)
+	}
+	if err := s.db.Model(device).Association("Tags").Delete(&tag); err != nil {
+		return fmt.Errorf("failed to untag device: %w", err)
+	}
+	return nil
+}
+
+// DeviceTags returns the tags of a device
+func (s *TagService) DeviceTags(deviceID string) ([]database.Tag, error) {
+	var tags []database.Tag
+	if err := s.db.Joins("JOIN device_tags ON device_tags.tag_id = tags.id").
+		Where("device_tags.device_id = ?", deviceID).Order("tags.name ASC").Find(&tags).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch device tags: %w", err)
+	}
+	return tags, nil
+}
+
+// DeviceIDsWithTags returns the IDs of devices carrying all the given tags
+func (s *TagService) DeviceIDsWithTags(names []string) ([]string, error) {
+	var ids []string
+	err := s.db.Table("device_tags").
+		Select("device_tags.device_id").
+		Joins("JOIN tags ON tags.id = device_tags.tag_id").
+		Where("tags.name IN ?", names).
+		Group("device_tags.device_id").
+		Having("COUNT(DISTINCT tags.id) = ?", len(names)).
+		Pluck("device_tags.device_id", &ids).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to filter devices by tag: %w", err)
+	}
+	return ids, nil
+}
+
+// AddONUTags attaches tags to the ONU with the given MAC and returns its tag names
+func (s *TagService) AddONUTags(deviceID, mac string, names []string) ([]string, error) {
+	if _, err := s.device(deviceID); err != nil {
+		return nil, err
+	}
+	tags, err := s.ensureTags(names)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, tag := range tags {
+		link := database.ONUTag{TagID: tag.ID, DeviceID: deviceID, MAC: mac, CreatedAt: time.Now()}
+		if err := s.db.Where("tag_id = ? AND device_id = ? AND mac = ?", tag.ID, deviceID, mac).
+			FirstOrCreate(&link).Error; err != nil {
+			return nil, fmt.Errorf("failed to tag ONU: %w", err)
+		}
+	}
+	return s.onuTagNames(deviceID, mac)
+}
+
+// RemoveONUTag detaches a tag from the ONU with the given MAC
+func (s *TagService) RemoveONUTag(deviceID, mac, name string) error {
+	var tag database.Tag
+	if err := s.db.Where("name = ?", NormalizeTagName(name)).First(&tag).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return fmt.Errorf("tag '%s' %w", name, ErrNotFound)
+		}
+		return fmt.Errorf("failed to fetch tag: %w", err)
+	}
+	result := s.db.Where("tag_id = ? AND device_id = ? AND mac = ?", tag.ID, deviceID, mac).Delete(&database.ONUTag{})
+	if result.Error != nil {
+		return fmt.Errorf("failed to untag ONU: %w", result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return kindErrorf(ErrNotFound, "ONU is not tagged '%s'", tag.Name)
+	}
+	return nil
+}
+
+// ONUTagsForDevice returns the tag names of every tagged ONU of a device,
+// keyed by normalized MAC
+func (s *TagService) ONUTagsForDevice(deviceID string) (map[string][]string, error) {
+	type row struct {
+		MAC  string
+		Name string
+	}
+	var rows []row
+	if err := s.db.Table("onu_tags").
+		Select("onu_tags.mac AS mac, tags.name AS name").
+		Joins("JOIN tags ON tags.id = onu_tags.tag_id").
+		Where("onu_tags.device_id = ?", deviceID).
+		Order("tags.name ASC").
+		Scan(&rows).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch ONU tags: %w", err)
+	}
+
+	byMAC := map[string][]string{}
+	for _, r := range rows {
+		byMAC[r.MAC] = append(byMAC[r.MAC], r.Name)
+	}
+	return byMAC, nil
+}
+
+// AttachToONUs sets the Tags field of ONUs that carry tags
+func (s *TagService) AttachToONUs(deviceID string, onus []parser.ONUResponse) {
+	if len(onus) == 0 {
+		return
+	}
+	byMAC, err := s.ONUTagsForDevice(deviceID)
+	if err != nil || len(byMAC) == 0 {
+		return
+	}
+	for i := range onus {
+		onus[i].Tags = byMAC[NormalizeMAC(onus[i].MacAddress)]
+	}
+}
+
+// FilterONUsByTags keeps ONUs carrying all the given tags. Tags must already
+// be attached with AttachToONUs.
+func FilterONUsByTags(onus []parser.ONUResponse, tags []string) []parser.ONUResponse {
+	if len(tags) == 0 {
+		return onus
+	}
+	filtered := make([]parser.ONUResponse, 0, len(onus))
+	for _, onu := range onus {
+		if hasAllTags(onu.Tags, tags) {
+			filtered = append(filtered, onu)
+		}
+	}
+	return filtered
+}
+
+func hasAllTags(have, want []string) bool {
+	set := make(map[string]bool, len(have))
+	for _, name := range have {
+		set[name] = true
+	}
+	for _, name := range want {
+		if !set[name] {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *TagService) onuTagNames(deviceID, mac string) ([]string, error) {
+	var names []string
+	if err := s.db.Table("onu_tags").
+		Joins("JOIN tags ON tags.id = onu_tags.tag_id").
+		Where("onu_tags.device_id = ? AND onu_tags.mac = ?", deviceID, mac).
+		Pluck("tags.name", &names).Error; err != nil {
+		return nil, fmt.Errorf("failed to fetch ONU tags: %w", err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (s *TagService) device(deviceID string) (*database.Device, error) {
+	var device database.Device
+	if err := s.db.Where("id = ?", deviceID).First(&device).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, fmt.Errorf("device '%s' %w", deviceID, ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to fetch device: %w", err)
+	}
+	return &device, nil
+}
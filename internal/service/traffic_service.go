@@ -0,0 +1,243 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	"olt-api/internal/config"
+	"olt-api/internal/database"
+	"olt-api/internal/parser"
+
+	"gorm.io/gorm"
+)
+
+const (
+	maxTrafficHistoryPoints = 10000
+
+	// A previous sample older than this gives an average, not a current rate
+	maxCurrentRateAge = 15 * time.Minute
+)
+
+// trafficSampleMu serializes reading the previous sample and storing the
+// next one, so concurrent polls of one ONU do not compute against the same
+// baseline
+var trafficSampleMu sync.Mutex
+
+// ONUTrafficHistory is the traffic rate time series of one ONU
+type ONUTrafficHistory struct {
+	DeviceID  string                      `json:"device_id"`
+	ONUID     string                      `json:"onu_id"`
+	From      time.Time                   `json:"from"`
+	To        time.Time                   `json:"to"`
+	Count     int                         `json:"count"`
+	Truncated bool                        `json:"truncated,omitempty"`
+	Points    []database.ONUTrafficSample `json:"points"`
+}
+
+// TrafficService samples ONU traffic counters and turns them into rates
+type TrafficService struct {
+	db         *gorm.DB
+	cfg        *config.Config
+	onuService *ONUService
+}
+
+// NewTrafficService creates a new TrafficService
+func NewTrafficService(db *gorm.DB, cfg *config.Config, deviceService *DeviceService) *TrafficService {
+	return &TrafficService{
+		db:         db,
+		cfg:        cfg,
+		onuService: NewONUService(db, cfg, deviceService),
+	}
+}
+
+// Sample reads the ONU counters, computes rates against the previous sample
+// and stores the result
+func (s *TrafficService) Sample(deviceID, onuID string) (*database.ONUTrafficSample, error) {
+	counters, err := s.onuService.GetONUTraffic(deviceID, onuID)
+	if err != nil {
+		return nil, err
+	}
+	return s.record(deviceID, onuID, counters, time.Now())
+}
+
+// CurrentRate returns the current traffic rate of an ONU. A sample newer than
+// traffic.min_interval is reused; when there is no recent baseline the
+// counters are read twice, traffic.probe_delay apart.
+func (s *TrafficService) CurrentRate(deviceID, onuID string) (*database.ONUTrafficSample, error) {
+	if last, err := s.latest(deviceID, onuID); err == nil && last.RxBps != nil &&
+		time.Since(last.RecordedAt) < s.cfg.Traffic.MinInterval {
+		return last, nil
+	}
+
+	sample, err := s.Sample(deviceID, onuID)
+	if err != nil {
+		return nil, err
+	}
+	if sample.RxBps != nil && sample.IntervalSeconds <= maxCurrentRateAge.Seconds() {
+		return sample, nil
+	}
+
+	delay := s.cfg.Traffic.ProbeDelay
+	if delay <= 0 {
+		delay = 3 * time.Second
+	}
+	time.Sleep(delay)
+	return s.Sample(deviceID, onuID)
+}
+
+// History returns stored traffic samples of an ONU between from and to
+func (s *TrafficService) History(deviceID, onuID string, from, to time.Time) (*ONUTrafficHistory, error) {
+	if !from.Before(to) {
+		return nil, ErrInvalidRange
+	}
+	from, to = from.Local(), to.Local()
+
+	var points []database.ONUTrafficSample
+	if err := s.db.Where("device_id = ? AND onu_id = ? AND recorded_at >= ? AND recorded_at < ?",
+		deviceID, onuID, from, to).
+		Order("recorded_at ASC").
+		Limit(maxTrafficHistoryPoints + 1).
+		Find(&points).Error; err != nil {
+		return nil, fmt.Errorf("failed to load traffic history: %w", err)
+	}
+
+	history := &ONUTrafficHistory{DeviceID: deviceID, ONUID: onuID, From: from, To: to}
+	if len(points) > maxTrafficHistoryPoints {
+		points = points[:maxTrafficHistoryPoints]
+		history.Truncated = true
+	}
+	history.Count = len(points)
+	history.Points = points
+	return history, nil
+}
+
+func (s *TrafficService) latest(deviceID, onuID string) (*database.ONUTrafficSample, error) {
+	var sample database.ONUTrafficSample
+	err := s.db.Where("device_id = ? AND onu_id = ?", deviceID, onuID).
+		Order("recorded_at DESC").
+		First(&sample).Error
+	if err != nil {
+		return nil, err
+	}
+	return &sample, nil
+}
+
+func (s *TrafficService) record(deviceID, onuID string, counters *parser.ONUTrafficResponse, at time.Time) (*database.ONUTrafficSample, error) {
+	sample := &database.ONUTrafficSample{
+		DeviceID:   deviceID,
+		ONUID:      onuID,
+		RecordedAt: at,
+		RxBytes:    counters.RxBytes,
+		TxBytes:    counters.TxBytes,
+		RxPackets:  counters.RxUnicast + counters.RxBroadcast + counters.RxMulticast,
+		TxPackets:  counters.TxUnicast + counters.TxBroadcast + counters.TxMulticast,
+		RxErrors:   counters.RxError,
+		TxErrors:   counters.TxError,
+
+		RxUnicast:   counters.RxUnicast,
+		RxBroadcast: counters.RxBroadcast,
+		RxMulticast: counters.RxMulticast,
+		TxUnicast:   counters.TxUnicast,
+		TxBroadcast: counters.TxBroadcast,
+		TxMulticast: counters.TxMulticast,
+	}
+
+	trafficSampleMu.Lock()
+	defer trafficSampleMu.Unlock()
+
+	prev, err := s.latest(deviceID, onuID)
+	switch {
+	case err == nil:
+		computeTrafficRates(prev, sample, s.cfg.Traffic.MaxBps)
+	case !errors.Is(err, gorm.ErrRecordNotFound):
+		return nil, fmt.Errorf("failed to load previous traffic sample: %w", err)
+	}
+
+	if err := s.db.Create(sample).Error; err != nil {
+		return nil, fmt.Errorf("failed to store traffic sample: %w", err)
+	}
+	return sample, nil
+}
+
+// computeTrafficRates fills the rates of cur from the counter deltas since
+// prev. A delta that cannot be explained by a 32-bit wrap, or that exceeds
+// maxBps, marks the sample as a reset and leaves its rates empty.
+func computeTrafficRates(prev, cur *database.ONUTrafficSample, maxBps float64) {
+	interval := cur.RecordedAt.Sub(prev.RecordedAt).Seconds()
+	if interval <= 0 {
+		return
+	}
+	cur.IntervalSeconds = math.Round(interval*1000) / 1000
+
+	rxBytes, ok1 := counterDelta(prev.RxBytes, cur.RxBytes)
+	txBytes, ok2 := counterDelta(prev.TxBytes, cur.TxBytes)
+	rxPackets, ok3 := packetDelta(prev.RxPackets, cur.RxPackets,
+		[3]uint64{prev.RxUnicast, prev.RxBroadcast, prev.RxMulticast},
+		[3]uint64{cur.RxUnicast, cur.RxBroadcast, cur.RxMulticast})
+	txPackets, ok4 := packetDelta(prev.TxPackets, cur.TxPackets,
+		[3]uint64{prev.TxUnicast, prev.TxBroadcast, prev.TxMulticast},
+		[3]uint64{cur.TxUnicast, cur.TxBroadcast, cur.TxMulticast})
+	rxErrors, ok5 := counterDelta(prev.RxErrors, cur.RxErrors)
+	txErrors, ok6 := counterDelta(prev.TxErrors, cur.TxErrors)
+	if !(ok1 && ok2 && ok3 && ok4 && ok5 && ok6) {
+		cur.Reset = true
+		return
+	}
+
+	rxBps := float64(rxBytes) * 8 / interval
+	txBps := float64(txBytes) * 8 / interval
+	if maxBps > 0 && (rxBps > maxBps || txBps > maxBps) {
+		cur.Reset = true
+		return
+	}
+
+	cur.RxBps = roundRate(rxBps)
+	cur.TxBps = roundRate(txBps)
+	cur.RxPps = roundRate(float64(rxPackets) / interval)
+	cur.TxPps = roundRate(float64(txPackets) / interval)
+	cur.RxErrorRate = roundRate(float64(rxErrors) / interval)
+	cur.TxErrorRate = roundRate(float64(txErrors) / interval)
+}
+
+// counterDelta returns how far a counter advanced. A decrease is accepted as a
+// 32-bit wrap when the previous value fits in 32 bits and the wrapped delta
+// is under half the counter range; anything else is a reset.
+func counterDelta(prev, cur uint64) (uint64, bool) {
+	if cur >= prev {
+		return cur - prev, true
+	}
+	if prev <= math.MaxUint32 {
+		delta := math.MaxUint32 - prev + cur + 1
+		if delta <= math.MaxUint32/2 {
+			return delta, true
+		}
+	}
+	return 0, false
+}
+
+// packetDelta returns how far a packet total advanced by applying
+// counterDelta to each of its unicast, broadcast and multicast parts, since
+// the OLT wraps them separately. A previous sample without parts (stored
+// before they were kept) falls back to the totals.
+func packetDelta(prevTotal, curTotal uint64, prevParts, curParts [3]uint64) (uint64, bool) {
+	if prevParts[0]+prevParts[1]+prevParts[2] != prevTotal {
+		return counterDelta(prevTotal, curTotal)
+	}
+	var total uint64
+	for i := range prevParts {
+		delta, ok := counterDelta(prevParts[i], curParts[i])
+		if !ok {
+			return 0, false
+		}
+		total += delta
+	}
+	return total, true
+}
+
+func roundRate(value float64) *float64 {
+	rounded := math.Round(value*100) / 100
+	return &rounded
+}
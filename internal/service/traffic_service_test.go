@@ -0,0 +1,189 @@
+package service
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"olt-api/internal/database"
+)
+
+func TestCounterDelta(t *testing.T) {
+	tests := []struct {
+		name      string
+		prev, cur uint64
+		want      uint64
+		wantOK    bool
+	}{
+		{"unchanged", 100, 100, 0, true},
+		{"increase", 100, 250, 150, true},
+		{"64-bit increase", 1 << 40, 1<<40 + 5, 5, true},
+		{"32-bit wrap", math.MaxUint32 - 9, 10, 20, true},
+		{"wrap from the maximum", math.MaxUint32, 0, 1, true},
+		{"wrap just under half the range", math.MaxUint32, math.MaxUint32/2 - 1, math.MaxUint32 / 2, true},
+		{"wrap over half the range is a reset", math.MaxUint32, math.MaxUint32 / 2, 0, false},
+		{"small drop is a reset", 5000, 10, 0, false},
+		{"drop from a 64-bit value is a reset", 1 << 40, 10, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := counterDelta(tt.prev, tt.cur)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("counterDelta(%d, %d) = %d, %t, want %d, %t", tt.prev, tt.cur, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestPacketDelta(t *testing.T) {
+	const max = math.MaxUint32
+	tests := []struct {
+		name      string
+		prevTotal uint64
+		prevParts [3]uint64
+		curParts  [3]uint64
+		want      uint64
+		wantOK    bool
+	}{
+		{"all parts increase", 60, [3]uint64{10, 20, 30}, [3]uint64{15, 25, 35}, 15, true},
+		{"one part wraps", max - 5 + 20 + 30, [3]uint64{max - 5, 20, 30}, [3]uint64{4, 21, 31}, 12, true},
+		{"two parts wrap", max - 1 + max + 30, [3]uint64{max - 1, max, 30}, [3]uint64{0, 1, 30}, 4, true},
+		{"one part resets", 100 + 20 + 30, [3]uint64{100, 20, 30}, [3]uint64{1, 25, 35}, 0, false},
+		{"legacy sample uses the totals", 60, [3]uint64{}, [3]uint64{15, 25, 35}, 15, true},
+		{"legacy sample with a lower total is a reset", 600, [3]uint64{}, [3]uint64{15, 25, 35}, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			curTotal := tt.curParts[0] + tt.curParts[1] + tt.curParts[2]
+			got, ok := packetDelta(tt.prevTotal, curTotal, tt.prevParts, tt.curParts)
+			if got != tt.want || ok != tt.wantOK {
+				t.Fatalf("packetDelta = %d, %t, want %d, %t", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestComputeTrafficRates(t *testing.T) {
+	start := time.Date(2026, 5, 10, 12, 0, 0, 0, time.UTC)
+	sample := func(offset time.Duration, rxBytes, txBytes, rxUnicast, rxErrors uint64) *database.ONUTrafficSample {
+		return &database.ONUTrafficSample{
+			RecordedAt:  start.Add(offset),
+			RxBytes:     rxBytes,
+			TxBytes:     txBytes,
+			RxPackets:   rxUnicast + 10,
+			RxErrors:    rxErrors,
+			RxUnicast:   rxUnicast,
+			RxBroadcast: 10,
+		}
+	}
+	rate := func(v float64) *float64 { return &v }
+
+	tests := []struct {
+		name      string
+		prev, cur *database.ONUTrafficSample
+		maxBps    float64
+		wantReset bool
+		wantRx    *float64
+		wantTx    *float64
+		wantRxPps *float64
+		wantErr   *float64
+	}{
+		{
+			name:      "rates over the interval",
+			prev:      sample(0, 1000, 500, 100, 0),
+			cur:       sample(10*time.Second, 11000, 2500, 300, 5),
+			wantRx:    rate(8000),
+			wantTx:    rate(1600),
+			wantRxPps: rate(20),
+			wantErr:   rate(0.5),
+		},
+		{
+			name:      "byte counter wrap",
+			prev:      sample(0, math.MaxUint32-999, 0, 100, 0),
+			cur:       sample(4*time.Second, 1000, 0, 100, 0),
+			wantRx:    rate(4000),
+			wantTx:    rate(0),
+			wantRxPps: rate(0),
+			wantErr:   rate(0),
+		},
+		{
+			name:      "packet part wrap",
+			prev:      sample(0, 0, 0, math.MaxUint32-49, 0),
+			cur:       sample(10*time.Second, 0, 0, 50, 0),
+			wantRx:    rate(0),
+			wantTx:    rate(0),
+			wantRxPps: rate(10),
+			wantErr:   rate(0),
+		},
+		{
+			name:      "counter reset",
+			prev:      sample(0, 5_000_000_000, 0, 100, 0),
+			cur:       sample(10*time.Second, 1000, 0, 100, 0),
+			wantReset: true,
+		},
+		{
+			name:      "error counter reset",
+			prev:      sample(0, 0, 0, 100, 50),
+			cur:       sample(10*time.Second, 0, 0, 100, 1),
+			wantReset: true,
+		},
+		{
+			name:      "over max_bps",
+			prev:      sample(0, 0, 0, 100, 0),
+			cur:       sample(time.Second, 200_000_000, 0, 100, 0),
+			maxBps:    1e9,
+			wantReset: true,
+		},
+		{
+			name:      "at max_bps",
+			prev:      sample(0, 0, 0, 100, 0),
+			cur:       sample(time.Second, 125_000_000, 0, 100, 0),
+			maxBps:    1e9,
+			wantRx:    rate(1e9),
+			wantTx:    rate(0),
+			wantRxPps: rate(0),
+			wantErr:   rate(0),
+		},
+		{
+			name:      "no max_bps",
+			prev:      sample(0, 0, 0, 100, 0),
+			cur:       sample(time.Second, 200_000_000, 0, 100, 0),
+			wantRx:    rate(1.6e9),
+			wantTx:    rate(0),
+			wantRxPps: rate(0),
+			wantErr:   rate(0),
+		},
+		{
+			name: "no interval",
+			prev: sample(time.Second, 0, 0, 100, 0),
+			cur:  sample(time.Second, 1000, 0, 100, 0),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			computeTrafficRates(tt.prev, tt.cur, tt.maxBps)
+			if tt.cur.Reset != tt.wantReset {
+				t.Fatalf("reset = %t, want %t", tt.cur.Reset, tt.wantReset)
+			}
+			checks := []struct {
+				field     string
+				got, want *float64
+			}{
+				{"rx_bps", tt.cur.RxBps, tt.wantRx},
+				{"tx_bps", tt.cur.TxBps, tt.wantTx},
+				{"rx_pps", tt.cur.RxPps, tt.wantRxPps},
+				{"rx_errors_per_sec", tt.cur.RxErrorRate, tt.wantErr},
+			}
+			for _, check := range checks {
+				switch {
+				case check.want == nil && check.got != nil:
+					t.Errorf("%s = %v, want null", check.field, *check.got)
+				case check.want != nil && check.got == nil:
+					t.Errorf("%s = null, want %v", check.field, *check.want)
+				case check.want != nil && *check.got != *check.want:
+					t.Errorf("%s = %v, want %v", check.field, *check.got, *check.want)
+				}
+			}
+		})
+	}
+}
@@ -57,6 +57,16 @@ func Created(c *gin.Context, message string, data interface{}) {
 	})
 }
 
+// Accepted sends a 202 Accepted response for work queued in the background
+func Accepted(c *gin.Context, message string, data interface{}) {
+	c.JSON(202, Response{
+		Success:   true,
+		Message:   message,
+		Data:      data,
+		Timestamp: time.Now(),
+	})
+}
+
 // Error sends an error response
 func Error(c *gin.Context, code int, message string) {
 	c.JSON(code, Response{